@@ -1,6 +1,7 @@
 package main
 
 import (
+	"os"
 	"wbtech_l2/18/internal/app"
 
 	"github.com/joho/godotenv"
@@ -21,6 +22,17 @@ func main() {
 		logrus.Fatalf("Error loading .env variables: %s", err.Error())
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "apikey":
+			app.RunAPIKey(os.Args[2:])
+			return
+		case "migrate":
+			app.RunMigrate(os.Args[2:])
+			return
+		}
+	}
+
 	app.Run()
 }
 
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHistoryPageSize is the limit of the history endpoints if none is
+// given.
+const defaultHistoryPageSize = 50
+
+// getEventHistoryV2 handles GET /v2/events/{id}/history and returns the
+// changes of the event, the latest first. The history outlives the event, so
+// unknown events have an empty history rather than 404. With limit and cursor
+// it pages like GET /v2/events.
+func (h *Handler) getEventHistoryV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	auditRange, ok := auditRangeQuery(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	page, err := h.services.GetEventHistory(ctx.Request.Context(), userID, eventID, auditRange)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	returnAuditPage(ctx, page)
+}
+
+// getUserHistoryV2 handles GET /v2/history and returns the changes of the
+// events of the user together with the changes the user made in calendars
+// shared with them, the latest first.
+func (h *Handler) getUserHistoryV2(ctx *gin.Context) {
+	auditRange, ok := auditRangeQuery(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := h.services.GetUserHistory(ctx.Request.Context(), getUserID(ctx), auditRange)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	returnAuditPage(ctx, page)
+}
+
+// auditRangeQuery parses limit and the cursor of the history endpoints.
+func auditRangeQuery(ctx *gin.Context) (model.AuditRange, bool) {
+	var auditRange model.AuditRange
+
+	var ok bool
+	if auditRange.Limit, ok = limitQuery(ctx, defaultHistoryPageSize); !ok {
+		return model.AuditRange{}, false
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		before, err := strconv.Atoi(cursor)
+		if err != nil || before <= 0 {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, model.InvalidCursorError.Error())
+			return model.AuditRange{}, false
+		}
+		auditRange.Before = before
+	}
+
+	return auditRange, true
+}
+
+func returnAuditPage(ctx *gin.Context, page model.AuditPage) {
+	result := gin.H{"status": "ok", "records": page.Records}
+	if page.NextCursor != "" {
+		result["next_cursor"] = page.NextCursor
+	}
+
+	ReturnResultResponse(ctx, result)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type historyResponseV2 struct {
+	Result struct {
+		Records    []model.AuditRecord `json:"records"`
+		NextCursor string              `json:"next_cursor"`
+	} `json:"result"`
+}
+
+func TestHistoryV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	ownerID, editorID := 1, 2
+	ownerToken := createAPIKey(t, services, ownerID)
+	editorToken := createAPIKey(t, services, editorID)
+	assert.NoError(t, services.ShareCalendar(ctx, ownerID, editorID, model.PermissionWrite))
+	eventID, _ := repos.Event.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+
+	send := func(token, method, path string, data any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(data)
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(editorToken, "PATCH", fmt.Sprintf("/v2/events/%d?calendar=%d", eventID, ownerID), model.EventUpdate{Description: "review"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = send(ownerToken, "DELETE", fmt.Sprintf("/v2/events/%d", eventID), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = send(ownerToken, "GET", fmt.Sprintf("/v2/events/%d/history?limit=2", eventID), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response historyResponseV2
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if assert.Len(t, response.Result.Records, 2) {
+		assert.Equal(t, model.AuditDelete, response.Result.Records[0].Action)
+		assert.Equal(t, model.AuditUpdate, response.Result.Records[1].Action)
+		assert.Equal(t, editorID, response.Result.Records[1].ActorID)
+	}
+	assert.NotEmpty(t, response.Result.NextCursor)
+
+	rec = send(ownerToken, "GET", fmt.Sprintf("/v2/events/%d/history?limit=2&cursor=%s", eventID, response.Result.NextCursor), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	response = historyResponseV2{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if assert.Len(t, response.Result.Records, 1) {
+		assert.Equal(t, model.AuditCreate, response.Result.Records[0].Action)
+	}
+	assert.Empty(t, response.Result.NextCursor)
+
+	rec = send(editorToken, "GET", "/v2/history", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	response = historyResponseV2{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if assert.Len(t, response.Result.Records, 1) {
+		assert.Equal(t, ownerID, response.Result.Records[0].OwnerID)
+	}
+
+	testCases := []struct {
+		name         string
+		token        string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "event of another user",
+			token:        editorToken,
+			path:         fmt.Sprintf("/v2/events/%d/history", eventID),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "calendar not shared",
+			token:        ownerToken,
+			path:         fmt.Sprintf("/v2/events/%d/history?calendar=%d", eventID, editorID),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid cursor",
+			token:        ownerToken,
+			path:         "/v2/history?cursor=abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid limit",
+			token:        ownerToken,
+			path:         "/v2/history?limit=0",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := send(tc.token, "GET", tc.path, nil)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userIDKey = "userID"
+
+// authenticate resolves the API key given as "Authorization: Bearer <key>"
+// or in the X-API-Key header to the ID of its user. Handlers behind it get
+// the user with getUserID and never take it from the request.
+func (h *Handler) authenticate(ctx *gin.Context) {
+	key := ctx.GetHeader("X-API-Key")
+	if header := ctx.GetHeader("Authorization"); header != "" {
+		scheme, token, _ := strings.Cut(header, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			key = strings.TrimSpace(token)
+		}
+	}
+
+	userID, err := h.services.Authenticate(ctx.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, service.UnauthorizedError) {
+			ctx.Header("WWW-Authenticate", `Bearer realm="calendar"`)
+			ReturnErrorResponse(ctx, http.StatusUnauthorized, err.Error())
+		} else {
+			ReturnInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.Set(userIDKey, userID)
+	ctx.Next()
+}
+
+func getUserID(ctx *gin.Context) int {
+	return ctx.GetInt(userIDKey)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchSize bounds the operations of POST /v2/events/batch.
+const maxBatchSize = 100
+
+// batchSchemas are the schemas of the events of batch operations by op: the
+// bodies of the v1 endpoints.
+var batchSchemas = map[string]string{
+	model.BatchCreate: "EventCreate",
+	model.BatchUpdate: "EventUpdate",
+	model.BatchDelete: "EventDelete",
+}
+
+// batchItem is a validated batch operation.
+type batchItem struct {
+	index          int
+	op             string
+	eventID        int
+	occurrenceDate string
+	version        int
+	event          model.Event
+}
+
+// batchResult is the outcome of an operation: the status and the event the
+// operation would respond with as a request of its own, or the error.
+type batchResult struct {
+	Index   int                  `json:"index"`
+	Status  int                  `json:"status"`
+	Event   *model.Event         `json:"event,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Details []openapi.FieldError `json:"details,omitempty"`
+}
+
+// batchEventsV2 handles POST /v2/events/batch. Every operation is validated
+// as the body of /create_event, /update_event or /delete_event and the
+// operations are applied in order. Atomic batches run in one unit of work:
+// if an operation is invalid or fails nothing is changed and the request
+// fails with its error. Otherwise every operation is applied on its own and
+// the results hold the status of each.
+func (h *Handler) batchEventsV2(ctx *gin.Context) {
+	var batch model.EventBatch
+	if !bindJSON(ctx, &batch) {
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	items := make([]batchItem, 0, len(batch.Operations))
+	results := make([]batchResult, len(batch.Operations))
+
+	var invalid []openapi.FieldError
+	for i, operation := range batch.Operations {
+		item, errs := h.parseBatchOperation(i, operation, loc)
+		if len(errs) > 0 {
+			results[i] = batchResult{Index: i, Status: http.StatusBadRequest, Error: "invalid operation", Details: errs}
+			invalid = append(invalid, errs...)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	events := h.services.As(getUserID(ctx))
+
+	if !batch.Atomic {
+		for _, item := range items {
+			result, err := applyBatchItem(ctx.Request.Context(), events, userID, item, loc)
+			if err != nil {
+				ctx.Error(err)
+				result.Status, result.Error = eventErrorV2(err)
+			}
+			results[item.index] = result
+		}
+
+		ReturnResultResponse(ctx, gin.H{"status": "ok", "results": results})
+		return
+	}
+
+	if len(invalid) > 0 {
+		ReturnValidationError(ctx, invalid)
+		return
+	}
+
+	var failed int
+	err := events.Atomically(ctx.Request.Context(), func(unitCtx context.Context) error {
+		for _, item := range items {
+			result, err := applyBatchItem(unitCtx, events, userID, item, loc)
+			if err != nil {
+				failed = item.index
+				return err
+			}
+			results[item.index] = result
+		}
+
+		return nil
+	})
+	if err != nil {
+		status, message := eventErrorV2(err)
+		ReturnErrorResponse(ctx, status, fmt.Sprintf("operations.%d: %s", failed, message))
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "results": results})
+}
+
+// parseBatchOperation validates the event of the operation with the schema
+// of its op, which validateRequest has checked, and reads it. The fields of
+// the errors are the paths in the request body.
+func (h *Handler) parseBatchOperation(index int, operation model.BatchOperation, loc *time.Location) (batchItem, []openapi.FieldError) {
+	field := fmt.Sprintf("operations.%d.event", index)
+
+	decoder := json.NewDecoder(bytes.NewReader(operation.Event))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return batchItem{}, []openapi.FieldError{{In: "body", Field: field, Message: "must be an object"}}
+	}
+
+	if errs := h.validator.ValidateValue(openapi.Ref(batchSchemas[operation.Op]), value, "body", field); len(errs) > 0 {
+		return batchItem{}, errs
+	}
+
+	item := batchItem{index: index, op: operation.Op, version: operation.Version}
+
+	switch operation.Op {
+	case model.BatchCreate:
+		var eventToCreate model.EventCreate
+		if err := json.Unmarshal(operation.Event, &eventToCreate); err != nil {
+			return batchItem{}, []openapi.FieldError{{In: "body", Field: field, Message: "must be an object"}}
+		}
+		if eventToCreate.Timezone == "" {
+			eventToCreate.Timezone = loc.String()
+		}
+		item.event = createdEvent(eventToCreate)
+	case model.BatchUpdate:
+		var eventUpdate model.EventUpdate
+		if err := json.Unmarshal(operation.Event, &eventUpdate); err != nil {
+			return batchItem{}, []openapi.FieldError{{In: "body", Field: field, Message: "must be an object"}}
+		}
+		if eventUpdate.ID == 0 {
+			return batchItem{}, []openapi.FieldError{{In: "body", Field: field + ".id", Message: "is required"}}
+		}
+		item.eventID, item.occurrenceDate = eventUpdate.ID, eventUpdate.OccurrenceDate
+		item.event = updatedFields(eventUpdate)
+		if reflect.DeepEqual(item.event, model.Event{}) {
+			return batchItem{}, []openapi.FieldError{{In: "body", Field: field, Message: "has no fields to update"}}
+		}
+		item.event.Version = operation.Version
+	case model.BatchDelete:
+		var eventDelete model.EventDelete
+		if err := json.Unmarshal(operation.Event, &eventDelete); err != nil {
+			return batchItem{}, []openapi.FieldError{{In: "body", Field: field, Message: "must be an object"}}
+		}
+		item.eventID, item.occurrenceDate = eventDelete.ID, eventDelete.OccurrenceDate
+	}
+
+	return item, nil
+}
+
+// applyBatchItem applies the operation as the matching v2 endpoint does and
+// returns the status and the event that endpoint responds with.
+func applyBatchItem(ctx context.Context, events service.Event, userID int, item batchItem, loc *time.Location) (batchResult, error) {
+	result := batchResult{Index: item.index}
+
+	var (
+		eventID = item.eventID
+		err     error
+	)
+	switch {
+	case item.op == model.BatchCreate:
+		result.Status = http.StatusCreated
+		eventID, err = events.Create(ctx, userID, item.event)
+	case item.op == model.BatchUpdate && item.occurrenceDate != "":
+		result.Status = http.StatusCreated
+		eventID, err = events.UpdateOccurrence(ctx, userID, item.eventID, item.occurrenceDate, item.event)
+	case item.op == model.BatchUpdate:
+		result.Status = http.StatusOK
+		err = events.Update(ctx, userID, item.eventID, item.event)
+	case item.occurrenceDate != "":
+		result.Status = http.StatusNoContent
+		return result, events.DeleteOccurrence(ctx, userID, item.eventID, item.occurrenceDate, item.version)
+	default:
+		result.Status = http.StatusNoContent
+		return result, events.Delete(ctx, userID, item.eventID, item.version)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	event, err := events.Get(ctx, userID, eventID, loc)
+	if err != nil {
+		return result, err
+	}
+	result.Event = &event
+
+	return result, nil
+}
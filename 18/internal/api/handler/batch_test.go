@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchResponse struct {
+	Result struct {
+		Results []batchResult `json:"results"`
+	} `json:"result"`
+}
+
+func TestBatchEventsV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	deletedID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "to delete",
+		Date:        "2026-02-07",
+		Time:        "09:00",
+	})
+
+	created := `{"op":"create","event":{"description":"batch","date":"2026-02-10","time":"10:00"}}`
+
+	testCases := []struct {
+		name             string
+		body             string
+		expectedCode     int
+		expectedStatuses []int
+		expectedCount    int
+	}{
+		{
+			name: "valid (best effort)",
+			body: fmt.Sprintf(`{"operations":[%s,
+				{"op":"create","event":{"description":"bad","date":"date","time":"10:00"}},
+				{"op":"update","version":1,"event":{"id":%d,"description":"renamed"}},
+				{"op":"delete","event":{"id":%d}},
+				{"op":"delete","event":{"id":12345}}]}`, created, eventID, deletedID),
+			expectedCode:     http.StatusOK,
+			expectedStatuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusOK, http.StatusNoContent, http.StatusNotFound},
+			expectedCount:    2,
+		},
+		{
+			name:             "valid (atomic)",
+			body:             fmt.Sprintf(`{"atomic":true,"operations":[%s,{"op":"update","event":{"id":%d,"time":"15:00"}}]}`, created, eventID),
+			expectedCode:     http.StatusOK,
+			expectedStatuses: []int{http.StatusCreated, http.StatusOK},
+			expectedCount:    3,
+		},
+		{
+			name:          "invalid (atomic batch with a failing operation)",
+			body:          fmt.Sprintf(`{"atomic":true,"operations":[%s,{"op":"update","version":1,"event":{"id":%d,"time":"16:00"}}]}`, created, eventID),
+			expectedCode:  http.StatusPreconditionFailed,
+			expectedCount: 3,
+		},
+		{
+			name:          "invalid (atomic batch with an invalid operation)",
+			body:          fmt.Sprintf(`{"atomic":true,"operations":[%s,{"op":"update","event":{"id":%d}}]}`, created, eventID),
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 3,
+		},
+		{
+			name:          "invalid op",
+			body:          `{"operations":[{"op":"move","event":{}}]}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 3,
+		},
+		{
+			name:          "invalid (no operations)",
+			body:          `{"operations":[]}`,
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 3,
+		},
+		{
+			name:          "invalid (too many operations)",
+			body:          fmt.Sprintf(`{"operations":[%s]}`, strings.Repeat(created+",", maxBatchSize)+created),
+			expectedCode:  http.StatusBadRequest,
+			expectedCount: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v2/events/batch", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedStatuses != nil {
+				var response batchResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+				statuses := make([]int, 0, len(response.Result.Results))
+				for i, result := range response.Result.Results {
+					assert.Equal(t, i, result.Index)
+					statuses = append(statuses, result.Status)
+				}
+				assert.Equal(t, tc.expectedStatuses, statuses)
+			}
+
+			events, err := repos.Event.GetEventsForMonth(ctx, userID, "2026-02-01", time.UTC)
+			assert.NoError(t, err)
+			assert.Len(t, events, tc.expectedCount)
+		})
+	}
+
+	event, err := repos.Event.GetByID(ctx, userID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", event.Description)
+	assert.Equal(t, "15:00:00", event.Time)
+}
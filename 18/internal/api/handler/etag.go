@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sets the ETag of a single event response to the event's version,
+// e.g. "3".
+func setETag(ctx *gin.Context, event model.Event) {
+	ctx.Header("ETag", strconv.Quote(strconv.Itoa(event.Version)))
+}
+
+// ifMatchVersion returns the version in the If-Match header of the request,
+// or 0 when the header is missing or "*", which any existing event matches.
+// Weak tags are accepted, as the version doesn't depend on the
+// representation.
+func ifMatchVersion(ctx *gin.Context) (int, bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	if strings.HasPrefix(tag, `"`) {
+		tag, _ = strconv.Unquote(tag)
+		if version, err := strconv.Atoi(tag); err == nil && version > 0 {
+			return version, true
+		}
+	}
+
+	ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid If-Match header: a single event version such as \"3\" is expected")
+	return 0, false
+}
@@ -1,46 +1,32 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
-	"strconv"
 	"time"
 	"wbtech_l2/18/internal/model"
 	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/rrule"
+	"wbtech_l2/18/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 func (h *Handler) createEvent(ctx *gin.Context) {
-	var eventToCreate model.EventCreate
-	if err := ctx.BindJSON(&eventToCreate); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "json contains incorrect data")
-		return
-	}
-
-	if _, err := time.Parse("2006-01-02", eventToCreate.Date); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
-		return
-	} else if _, err = time.Parse("15:04", eventToCreate.Time); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid time")
+	event, ok := bindEventCreate(ctx)
+	if !ok {
 		return
-	} else if eventToCreate.Description == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "no description given")
-		return
-	} else if eventToCreate.UserID == 0 {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "no user_id given")
-		return
-	}
-
-	event := model.Event{
-		Description: eventToCreate.Description,
-		Date:        eventToCreate.Date,
-		Time:        eventToCreate.Time,
 	}
 
-	id, err := h.services.Create(eventToCreate.UserID, event)
+	id, err := h.services.Create(ctx.Request.Context(), getUserID(ctx), event)
 	if err != nil {
-		ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
+		var conflictErr *service.ConflictError
+		if errors.As(err, &conflictErr) {
+			ReturnErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
+		} else {
+			ReturnInternalError(ctx, err)
+		}
 		return
 	}
 
@@ -48,30 +34,30 @@ func (h *Handler) createEvent(ctx *gin.Context) {
 }
 
 func (h *Handler) updateEvent(ctx *gin.Context) {
-	var event model.Event
-	if err := ctx.BindJSON(&event); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "json contains incorrect data")
+	var eventUpdate model.EventUpdate
+	event, ok := bindEventUpdate(ctx, &eventUpdate)
+	if !ok {
 		return
 	}
 
-	_, err := time.Parse("2006-01-02", event.Date)
-	if event.Date != "" && err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
-		return
-	}
+	if eventUpdate.OccurrenceDate != "" {
+		id, err := h.services.UpdateOccurrence(ctx.Request.Context(), getUserID(ctx), eventUpdate.ID, eventUpdate.OccurrenceDate, event)
+		if err != nil {
+			returnOccurrenceError(ctx, err)
+			return
+		}
 
-	_, err = time.Parse("15:04", event.Time)
-	if event.Time != "" && err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid time")
+		ReturnResultResponse(ctx, gin.H{"status": "ok", "id": id})
 		return
 	}
 
-	err = h.services.Update(event.ID, event)
+	err := h.services.Update(ctx.Request.Context(), getUserID(ctx), eventUpdate.ID, event)
 	if err != nil {
-		if errors.Is(err, repository.NotFoundError) {
+		var conflictErr *service.ConflictError
+		if errors.Is(err, repository.NotFoundError) || errors.As(err, &conflictErr) {
 			ReturnErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
 		} else {
-			ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
+			ReturnInternalError(ctx, err)
 		}
 		return
 	}
@@ -81,27 +67,26 @@ func (h *Handler) updateEvent(ctx *gin.Context) {
 
 func (h *Handler) deleteEvent(ctx *gin.Context) {
 	var eventDelete model.EventDelete
-	if err := ctx.BindJSON(&eventDelete); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "json contains incorrect data")
+	if !bindJSON(ctx, &eventDelete) {
 		return
 	}
 
-	if eventDelete.UserID == 0 {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "no user_id given")
-		return
-	}
+	if eventDelete.OccurrenceDate != "" {
+		if err := h.services.DeleteOccurrence(ctx.Request.Context(), getUserID(ctx), eventDelete.ID, eventDelete.OccurrenceDate, 0); err != nil {
+			returnOccurrenceError(ctx, err)
+			return
+		}
 
-	if eventDelete.ID == 0 {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "no event id given")
+		ReturnResultResponse(ctx, gin.H{"status": "ok"})
 		return
 	}
 
-	err := h.services.Delete(eventDelete.UserID, eventDelete.ID)
+	err := h.services.Delete(ctx.Request.Context(), getUserID(ctx), eventDelete.ID, 0)
 	if err != nil {
 		if errors.Is(err, repository.NotFoundError) {
 			ReturnErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
 		} else {
-			ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
+			ReturnInternalError(ctx, err)
 		}
 		return
 	}
@@ -110,91 +95,158 @@ func (h *Handler) deleteEvent(ctx *gin.Context) {
 }
 
 func (h *Handler) getEventsForDay(ctx *gin.Context) {
-	stringUserID, ok := ctx.GetQuery("user_id")
-	userID, err := strconv.Atoi(stringUserID)
-	if !ok || stringUserID == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "user_id is required")
-		return
-	} else if err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid user_id")
-		return
-	}
+	h.getEventsForPeriod(ctx, h.services.GetEventsForDay)
+}
 
-	date, ok := ctx.GetQuery("date")
-	if !ok || date == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "date is required")
-		return
-	} else if _, err = time.Parse("2006-01-02", date); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
+// getEventsForWeek handles GET /events_for_week. Unlike the weeks of
+// GET /v2/events, the window is the 7 days from date rather than a calendar
+// week, which v1 clients rely on.
+func (h *Handler) getEventsForWeek(ctx *gin.Context) {
+	h.getEventsForPeriod(ctx, h.services.GetEventsForWeek)
+}
+
+// getEventsForMonth handles GET /events_for_month, the 31 days from date
+// rather than a calendar month, as getEventsForWeek does for weeks.
+func (h *Handler) getEventsForMonth(ctx *gin.Context) {
+	h.getEventsForPeriod(ctx, h.services.GetEventsForMonth)
+}
+
+// getEventsForPeriod serves the v1 period endpoints with the events getEvents
+// returns for the date, which validateRequest has checked.
+func (h *Handler) getEventsForPeriod(ctx *gin.Context, getEvents func(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)) {
+	loc, ok := requestLocation(ctx)
+	if !ok {
 		return
 	}
 
-	var events []model.Event
-	events, err = h.services.GetEventsForDay(userID, date)
+	events, err := getEvents(ctx.Request.Context(), getUserID(ctx), ctx.Query("date"), loc)
 	if err != nil {
-		ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
+		ReturnInternalError(ctx, err)
 		return
 	}
 
 	ReturnResultResponse(ctx, gin.H{"status": "ok", "events": events})
 }
 
-func (h *Handler) getEventsForWeek(ctx *gin.Context) {
-	stringUserID, ok := ctx.GetQuery("user_id")
-	userID, err := strconv.Atoi(stringUserID)
-	if !ok || stringUserID == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "user_id is required")
-		return
-	} else if err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid user_id")
-		return
+// bindEventCreate reads the event to create, which validateRequest has
+// checked. Date and time without a timezone are on the wall clock of the
+// caller.
+func bindEventCreate(ctx *gin.Context) (model.Event, bool) {
+	var eventToCreate model.EventCreate
+	if !bindJSON(ctx, &eventToCreate) {
+		return model.Event{}, false
 	}
 
-	date, ok := ctx.GetQuery("date")
-	if !ok || date == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "date is required")
-		return
-	} else if _, err = time.Parse("2006-01-02", date); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
-		return
+	if eventToCreate.Timezone == "" {
+		loc, ok := requestLocation(ctx)
+		if !ok {
+			return model.Event{}, false
+		}
+		eventToCreate.Timezone = loc.String()
 	}
 
-	var events []model.Event
-	events, err = h.services.GetEventsForWeek(userID, date)
-	if err != nil {
-		ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
-		return
+	return createdEvent(eventToCreate), true
+}
+
+func createdEvent(eventToCreate model.EventCreate) model.Event {
+	return model.Event{
+		Description:  eventToCreate.Description,
+		Date:         eventToCreate.Date,
+		Time:         eventToCreate.Time,
+		Timezone:     eventToCreate.Timezone,
+		RRule:        eventToCreate.RRule,
+		ExDates:      eventToCreate.ExDates,
+		RemindBefore: eventToCreate.RemindBefore,
+		Duration:     eventToCreate.Duration,
 	}
+}
 
-	ReturnResultResponse(ctx, gin.H{"status": "ok", "events": events})
+// bindEventUpdate reads eventUpdate, which validateRequest has checked, and
+// returns the fields to change.
+func bindEventUpdate(ctx *gin.Context, eventUpdate *model.EventUpdate) (model.Event, bool) {
+	if !bindJSON(ctx, eventUpdate) {
+		return model.Event{}, false
+	}
+
+	return updatedFields(*eventUpdate), true
 }
 
-func (h *Handler) getEventsForMonth(ctx *gin.Context) {
-	stringUserID, ok := ctx.GetQuery("user_id")
-	userID, err := strconv.Atoi(stringUserID)
-	if !ok || stringUserID == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "user_id is required")
-		return
-	} else if err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid user_id")
-		return
+// updatedFields returns the fields eventUpdate changes.
+func updatedFields(eventUpdate model.EventUpdate) model.Event {
+	return model.Event{
+		Description:  eventUpdate.Description,
+		Date:         eventUpdate.Date,
+		Time:         eventUpdate.Time,
+		Timezone:     eventUpdate.Timezone,
+		RRule:        eventUpdate.RRule,
+		ExDates:      eventUpdate.ExDates,
+		RemindBefore: eventUpdate.RemindBefore,
+		Duration:     eventUpdate.Duration,
 	}
+}
 
-	date, ok := ctx.GetQuery("date")
-	if !ok || date == "" {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "date is required")
-		return
-	} else if _, err = time.Parse("2006-01-02", date); err != nil {
-		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
-		return
+func returnOccurrenceError(ctx *gin.Context, err error) {
+	var conflictErr *service.ConflictError
+
+	switch {
+	case errors.Is(err, repository.NotFoundError), errors.As(err, &conflictErr):
+		ReturnErrorResponse(ctx, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, service.NotRecurringError), errors.Is(err, service.OccurrenceNotFoundError):
+		ReturnErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		ReturnInternalError(ctx, err)
 	}
+}
 
-	var events []model.Event
-	events, err = h.services.GetEventsForMonth(userID, date)
+// requestLocation returns the timezone of the caller given by the "tz" query
+// parameter or the X-Timezone header, UTC by default. Dates in the request and
+// in the response are on the wall clock of this timezone. It responds with
+// 400 if the timezone is unknown.
+func requestLocation(ctx *gin.Context) (*time.Location, bool) {
+	name := ctx.Query("tz")
+	if name == "" {
+		name = ctx.GetHeader("X-Timezone")
+	}
+
+	loc, err := model.LoadLocation(name)
 	if err != nil {
-		ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
-		return
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid timezone")
+		return nil, false
 	}
 
-	ReturnResultResponse(ctx, gin.H{"status": "ok", "events": events})
+	return loc, true
+}
+
+func isValidTimezone(timezone string) bool {
+	_, err := model.LoadLocation(timezone)
+	return err == nil
+}
+
+func isValidRRule(rule string) bool {
+	if rule == "" {
+		return true
+	}
+
+	_, err := rrule.Parse(rule)
+	return err == nil
+}
+
+func isValidRemindBefore(remindBefore string) bool {
+	if remindBefore == "" {
+		return true
+	}
+
+	offset, err := time.ParseDuration(remindBefore)
+	return err == nil && offset >= 0
+}
+
+// isValidDuration accepts positive durations of whole seconds, as they are
+// stored.
+func isValidDuration(duration string) bool {
+	if duration == "" {
+		return true
+	}
+
+	parsed, err := time.ParseDuration(duration)
+	return err == nil && parsed >= time.Second
 }
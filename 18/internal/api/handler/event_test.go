@@ -4,35 +4,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"wbtech_l2/18/internal/api/server"
 	"wbtech_l2/18/internal/model"
 	"wbtech_l2/18/internal/repository"
 	"wbtech_l2/18/internal/service"
 
-	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateEvent(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
+	token := createAPIKey(t, services, 1)
 
 	testCases := []struct {
 		name         string
@@ -42,7 +31,6 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "valid",
 			data: model.EventCreate{
-				UserID:      1,
 				Description: "something that I used to do",
 				Date:        "2026-02-04",
 				Time:        "14:55",
@@ -52,7 +40,6 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "invalid date",
 			data: model.EventCreate{
-				UserID:      1,
 				Description: "something that I used to do",
 				Date:        "date",
 				Time:        "14:55",
@@ -62,7 +49,6 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "invalid (no date)",
 			data: model.EventCreate{
-				UserID:      1,
 				Description: "something that I used to do",
 				Time:        "14:55",
 			},
@@ -71,7 +57,6 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "invalid time",
 			data: model.EventCreate{
-				UserID:      1,
 				Description: "something that I used to do",
 				Date:        "2026-02-06",
 				Time:        "time",
@@ -81,7 +66,6 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "invalid (no time)",
 			data: model.EventCreate{
-				UserID:      1,
 				Description: "something that I used to do",
 				Date:        "2026-02-06",
 			},
@@ -90,18 +74,90 @@ func TestCreateEvent(t *testing.T) {
 		{
 			name: "invalid (no description)",
 			data: model.EventCreate{
-				UserID: 1,
-				Date:   "2026-02-06",
-				Time:   "14:55",
+				Date: "2026-02-06",
+				Time: "14:55",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "valid (recurring)",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				RRule:       "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=10",
+				ExDates:     []string{"2026-02-09"},
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "invalid rrule",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				RRule:       "FREQ=SOMETIMES",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid remind_before",
+			data: model.EventCreate{
+				Description:  "something that I used to do",
+				Date:         "2026-02-06",
+				Time:         "14:55",
+				RemindBefore: "an hour",
 			},
 			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name: "invalid (no user_id)",
+			name: "invalid exdates",
 			data: model.EventCreate{
 				Description: "something that I used to do",
 				Date:        "2026-02-06",
 				Time:        "14:55",
+				RRule:       "FREQ=DAILY",
+				ExDates:     []string{"date"},
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "valid (with timezone)",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				Timezone:    "Europe/Moscow",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "invalid timezone",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				Timezone:    "Mars/Olympus",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "valid (with duration)",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				Duration:    "1h30m",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "invalid duration",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-06",
+				Time:        "14:55",
+				Duration:    "-1h",
 			},
 			expectedCode: http.StatusBadRequest,
 		},
@@ -115,35 +171,29 @@ func TestCreateEvent(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonData))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
-
-	err := srv.Shutdown(context.Background())
-	if err != nil {
-		return
-	}
 }
 
 func TestUpdateEvent(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
 
 	userID := 1
-	eventID, _ := repos.Event.Create(userID, model.Event{
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
@@ -184,6 +234,14 @@ func TestUpdateEvent(t *testing.T) {
 			},
 			expectedCode: http.StatusBadRequest,
 		},
+		{
+			name: "invalid (event of another user)",
+			data: model.Event{
+				ID:          otherEventID,
+				Description: "something that I used to do",
+			},
+			expectedCode: http.StatusServiceUnavailable,
+		},
 		{
 			name: "invalid ID",
 			data: model.Event{
@@ -204,40 +262,29 @@ func TestUpdateEvent(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("POST", "/update_event", bytes.NewBuffer(jsonData))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
-
-	err := srv.Shutdown(context.Background())
-	if err != nil {
-		return
-	}
 }
 
 func TestDeleteEvent(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
 
 	userID := 1
-	eventID1, _ := repos.Event.Create(userID, model.Event{
+	token := createAPIKey(t, services, userID)
+	eventID1, _ := repos.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
 	})
-	eventID2, _ := repos.Event.Create(userID, model.Event{
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
@@ -251,47 +298,34 @@ func TestDeleteEvent(t *testing.T) {
 		{
 			name: "valid",
 			data: model.EventDelete{
-				ID:     eventID1,
-				UserID: userID,
+				ID: eventID1,
 			},
 			expectedCode: http.StatusOK,
 		},
 		{
 			name: "invalid (deleting same event twice)",
 			data: model.EventDelete{
-				ID:     eventID1,
-				UserID: userID,
+				ID: eventID1,
 			},
 			expectedCode: http.StatusServiceUnavailable,
 		},
 		{
 			name: "invalid (non-existing event_id)",
 			data: model.EventDelete{
-				ID:     12345,
-				UserID: userID,
+				ID: 12345,
 			},
 			expectedCode: http.StatusServiceUnavailable,
 		},
 		{
-			name: "invalid (non-existing user_id)",
+			name: "invalid (event of another user)",
 			data: model.EventDelete{
-				ID:     eventID2,
-				UserID: 12345,
+				ID: otherEventID,
 			},
 			expectedCode: http.StatusServiceUnavailable,
 		},
 		{
-			name: "invalid (no event id)",
-			data: model.EventDelete{
-				UserID: userID,
-			},
-			expectedCode: http.StatusBadRequest,
-		},
-		{
-			name: "invalid (no user_id)",
-			data: model.EventDelete{
-				ID: eventID2,
-			},
+			name:         "invalid (no event id)",
+			data:         model.EventDelete{},
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -304,36 +338,130 @@ func TestDeleteEvent(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("POST", "/delete_event", bytes.NewBuffer(jsonData))
 			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
+}
 
-	err := srv.Shutdown(context.Background())
-	if err != nil {
-		return
+func TestUpdateAndDeleteOccurrence(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	seriesID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+	singleID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	otherSeriesID, _ := repos.Event.Create(ctx, 12345, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+
+	testCases := []struct {
+		name         string
+		path         string
+		data         any
+		expectedCode int
+	}{
+		{
+			name:         "valid update",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: seriesID, Time: "11:00", OccurrenceDate: "2026-02-03"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid update (invalid occurrence_date)",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: seriesID, Time: "11:00", OccurrenceDate: "date"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid update (no occurrence on date)",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: seriesID, Time: "11:00", OccurrenceDate: "2026-02-03"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid update (event is not recurring)",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: singleID, Time: "11:00", OccurrenceDate: "2026-02-06"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid update (non-existing event_id)",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: 12345, Time: "11:00", OccurrenceDate: "2026-02-06"},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "invalid update (series of another user)",
+			path:         "/update_event",
+			data:         model.EventUpdate{ID: otherSeriesID, Time: "11:00", OccurrenceDate: "2026-02-05"},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "valid delete",
+			path:         "/delete_event",
+			data:         model.EventDelete{ID: seriesID, OccurrenceDate: "2026-02-04"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid delete (series of another user)",
+			path:         "/delete_event",
+			data:         model.EventDelete{ID: otherSeriesID, OccurrenceDate: "2026-02-05"},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "invalid delete (invalid occurrence_date)",
+			path:         "/delete_event",
+			data:         model.EventDelete{ID: seriesID, OccurrenceDate: "date"},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.path, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
 	}
 }
 
 func TestGetEventsForDay(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
 
 	userID := 1
+	token := createAPIKey(t, services, userID)
 	eventDate := "2026-02-06"
-	repos.Event.Create(userID, model.Event{
+	repos.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        eventDate,
 		Time:        "14:00",
@@ -346,37 +474,32 @@ func TestGetEventsForDay(t *testing.T) {
 	}{
 		{
 			name:         "valid",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, eventDate),
-			expectedCode: http.StatusOK,
-		},
-		{
-			name:         "valid (non-existing user_id)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", 12345, eventDate),
+			params:       fmt.Sprintf("?date=%s", eventDate),
 			expectedCode: http.StatusOK,
 		},
 		{
 			name:         "valid (no events on given date)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, "2000-01-01"),
+			params:       fmt.Sprintf("?date=%s", "2000-01-01"),
 			expectedCode: http.StatusOK,
 		},
 		{
-			name:         "invalid (no user_id)",
-			params:       fmt.Sprintf("?date=%s", eventDate),
+			name:         "invalid (no date)",
+			params:       "?",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name:         "invalid user_id",
-			params:       fmt.Sprintf("?user_id=qwerty&date=%s", eventDate),
+			name:         "invalid date",
+			params:       "?date=qwerty",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name:         "invalid (no date)",
-			params:       fmt.Sprintf("?user_id=%d", userID),
-			expectedCode: http.StatusBadRequest,
+			name:         "valid (with timezone)",
+			params:       fmt.Sprintf("?date=%s&tz=%s", eventDate, "Asia/Tokyo"),
+			expectedCode: http.StatusOK,
 		},
 		{
-			name:         "invalid date",
-			params:       fmt.Sprintf("?user_id=%d&date=qwerty", userID),
+			name:         "invalid timezone",
+			params:       fmt.Sprintf("?date=%s&tz=%s", eventDate, "Mars/Olympus"),
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -386,36 +509,25 @@ func TestGetEventsForDay(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", fmt.Sprintf("/events_for_day%s", tc.params), nil)
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Authorization", "Bearer "+token)
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
-
-	err := srv.Shutdown(context.Background())
-	if err != nil {
-		return
-	}
 }
 
 func TestGetEventsForWeek(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
 
 	userID := 1
+	token := createAPIKey(t, services, userID)
 	eventDate := "2026-02-06"
-	repos.Event.Create(userID, model.Event{
+	repos.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        eventDate,
 		Time:        "14:00",
@@ -428,37 +540,22 @@ func TestGetEventsForWeek(t *testing.T) {
 	}{
 		{
 			name:         "valid",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, eventDate),
-			expectedCode: http.StatusOK,
-		},
-		{
-			name:         "valid (non-existing user_id)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", 12345, eventDate),
+			params:       fmt.Sprintf("?date=%s", eventDate),
 			expectedCode: http.StatusOK,
 		},
 		{
 			name:         "valid (no events on given date)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, "2000-01-01"),
+			params:       fmt.Sprintf("?date=%s", "2000-01-01"),
 			expectedCode: http.StatusOK,
 		},
-		{
-			name:         "invalid (no user_id)",
-			params:       fmt.Sprintf("?date=%s", eventDate),
-			expectedCode: http.StatusBadRequest,
-		},
-		{
-			name:         "invalid user_id",
-			params:       fmt.Sprintf("?user_id=qwerty&date=%s", eventDate),
-			expectedCode: http.StatusBadRequest,
-		},
 		{
 			name:         "invalid (no date)",
-			params:       fmt.Sprintf("?user_id=%d", userID),
+			params:       "?",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "invalid date",
-			params:       fmt.Sprintf("?user_id=%d&date=qwerty", userID),
+			params:       "?date=qwerty",
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -468,36 +565,25 @@ func TestGetEventsForWeek(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", fmt.Sprintf("/events_for_week%s", tc.params), nil)
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Authorization", "Bearer "+token)
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
-
-	err := srv.Shutdown(context.Background())
-	if err != nil {
-		return
-	}
 }
 
 func TestGetEventsForMonth(t *testing.T) {
-	db, teardown := repository.TestDB(t)
-	defer teardown()
-
-	repos := repository.NewRepository(db)
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
 	services := service.NewService(repos)
 	handlers := NewHandler(services)
 
-	srv := new(server.Server)
 	router := handlers.InitRoutes()
-	go func() {
-		if err := srv.Run("8888", router); err != nil && !errors.Is(http.ErrServerClosed, err) {
-			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
-		}
-	}()
 
 	userID := 1
+	token := createAPIKey(t, services, userID)
 	eventDate := "2026-02-06"
-	repos.Event.Create(userID, model.Event{
+	repos.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        eventDate,
 		Time:        "14:00",
@@ -510,37 +596,22 @@ func TestGetEventsForMonth(t *testing.T) {
 	}{
 		{
 			name:         "valid",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, eventDate),
-			expectedCode: http.StatusOK,
-		},
-		{
-			name:         "valid (non-existing user_id)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", 12345, eventDate),
+			params:       fmt.Sprintf("?date=%s", eventDate),
 			expectedCode: http.StatusOK,
 		},
 		{
 			name:         "valid (no events on given date)",
-			params:       fmt.Sprintf("?user_id=%d&date=%s", userID, "2000-01-01"),
+			params:       fmt.Sprintf("?date=%s", "2000-01-01"),
 			expectedCode: http.StatusOK,
 		},
-		{
-			name:         "invalid (no user_id)",
-			params:       fmt.Sprintf("?date=%s", eventDate),
-			expectedCode: http.StatusBadRequest,
-		},
-		{
-			name:         "invalid user_id",
-			params:       fmt.Sprintf("?user_id=qwerty&date=%s", eventDate),
-			expectedCode: http.StatusBadRequest,
-		},
 		{
 			name:         "invalid (no date)",
-			params:       fmt.Sprintf("?user_id=%d", userID),
+			params:       "?",
 			expectedCode: http.StatusBadRequest,
 		},
 		{
 			name:         "invalid date",
-			params:       fmt.Sprintf("?user_id=%d&date=qwerty", userID),
+			params:       "?date=qwerty",
 			expectedCode: http.StatusBadRequest,
 		},
 	}
@@ -550,13 +621,161 @@ func TestGetEventsForMonth(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", fmt.Sprintf("/events_for_month%s", tc.params), nil)
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestTimezones(t *testing.T) {
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+	token := createAPIKey(t, services, 1)
+
+	type eventsResponse struct {
+		Result struct {
+			Events []model.Event `json:"events"`
+		} `json:"result"`
+	}
+
+	// Without a timezone in the body the event is on the caller's wall clock
+	jsonData, err := json.Marshal(model.EventCreate{
+		Description: "late call",
+		Date:        "2026-02-06",
+		Time:        "23:30",
+	})
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/create_event", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Timezone", "America/New_York")
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	testCases := []struct {
+		name         string
+		params       string
+		expectedDate string
+		expectedTime string
+	}{
+		{
+			name:         "in the event's timezone",
+			params:       "?date=2026-02-06&tz=America/New_York",
+			expectedDate: "2026-02-06",
+			expectedTime: "23:30:00",
+		},
+		{
+			name:         "in UTC",
+			params:       "?date=2026-02-07",
+			expectedDate: "2026-02-07",
+			expectedTime: "04:30:00",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/events_for_day%s", tc.params), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var response eventsResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, 1, len(response.Result.Events))
+			assert.Equal(t, tc.expectedDate, response.Result.Events[0].Date)
+			assert.Equal(t, tc.expectedTime, response.Result.Events[0].Time)
+			assert.Equal(t, "America/New_York", response.Result.Events[0].Timezone)
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	revokedID, revokedToken, err := services.CreateAPIKey(ctx, userID)
+	assert.NoError(t, err)
+	assert.NoError(t, services.RevokeAPIKey(ctx, revokedID))
+
+	testCases := []struct {
+		name         string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{
+			name:         "valid (bearer token)",
+			header:       "Authorization",
+			value:        "Bearer " + token,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "valid (X-API-Key)",
+			header:       "X-API-Key",
+			value:        token,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid (no api key)",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "invalid (unknown api key)",
+			header:       "Authorization",
+			value:        "Bearer cal_qwerty",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "invalid (revoked api key)",
+			header:       "Authorization",
+			value:        "Bearer " + revokedToken,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "invalid (not a bearer token)",
+			header:       "Authorization",
+			value:        "Basic " + token,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/events_for_day?date=2026-02-06", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
 			router.ServeHTTP(rec, req)
 			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
 		})
 	}
+}
+
+func createAPIKey(t *testing.T, services *service.Service, userID int) string {
+	t.Helper()
 
-	err := srv.Shutdown(context.Background())
+	_, key, err := services.CreateAPIKey(context.Background(), userID)
 	if err != nil {
-		return
+		t.Fatal(err)
 	}
+
+	return key
 }
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createEventV2 handles POST /v2/events and responds with 201 and the
+// created event.
+func (h *Handler) createEventV2(ctx *gin.Context) {
+	event, ok := bindEventCreate(ctx)
+	if !ok {
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	id, err := h.services.As(getUserID(ctx)).Create(ctx.Request.Context(), userID, event)
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	h.returnCreatedEvent(ctx, userID, id, loc)
+}
+
+// getEventV2 handles GET /v2/events/{id}. Recurring events are returned as
+// series, not expanded.
+func (h *Handler) getEventV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	event, err := h.services.Get(ctx.Request.Context(), userID, eventID, loc)
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	setETag(ctx, event)
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "event": event})
+}
+
+// updateEventV2 handles PATCH /v2/events/{id}. Only the given fields are
+// changed. With occurrence_date the occurrence is detached from the series
+// and the new event is returned with 201. With If-Match the change is made
+// only if the event (or the series) still has that version.
+func (h *Handler) updateEventV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var eventUpdate model.EventUpdate
+	event, ok := bindEventUpdate(ctx, &eventUpdate)
+	if !ok {
+		return
+	}
+
+	if reflect.DeepEqual(event, model.Event{}) {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "no fields to update given")
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	if event.Version, ok = ifMatchVersion(ctx); !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if eventUpdate.OccurrenceDate != "" {
+		id, err := h.services.As(getUserID(ctx)).UpdateOccurrence(ctx.Request.Context(), userID, eventID, eventUpdate.OccurrenceDate, event)
+		if err != nil {
+			returnEventErrorV2(ctx, err)
+			return
+		}
+
+		h.returnCreatedEvent(ctx, userID, id, loc)
+		return
+	}
+
+	if err := h.services.As(getUserID(ctx)).Update(ctx.Request.Context(), userID, eventID, event); err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	updated, err := h.services.Get(ctx.Request.Context(), userID, eventID, loc)
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	setETag(ctx, updated)
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "event": updated})
+}
+
+// deleteEventV2 handles DELETE /v2/events/{id} and moves the event to the
+// trash. With the occurrence_date query parameter only that occurrence of the
+// series is deleted. If-Match works as in updateEventV2.
+func (h *Handler) deleteEventV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	var err error
+	if occurrenceDate := ctx.Query("occurrence_date"); occurrenceDate != "" {
+		if _, err = time.Parse("2006-01-02", occurrenceDate); err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid occurrence_date")
+			return
+		}
+
+		err = h.services.As(getUserID(ctx)).DeleteOccurrence(ctx.Request.Context(), userID, eventID, occurrenceDate, version)
+	} else {
+		err = h.services.As(getUserID(ctx)).Delete(ctx.Request.Context(), userID, eventID, version)
+	}
+
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// maxPageSize bounds the limit of GET /v2/events.
+const maxPageSize = 1000
+
+// getEventsV2 handles GET /v2/events and returns single events and
+// occurrences ordered by start. The range is either [from, to) or the
+// calendar-aligned period (day, week or month) containing date; dates are in
+// the timezone of the request. Weeks start on the configured first day of the
+// week unless week_start is given. With limit the events are paginated and
+// next_cursor continues the listing.
+func (h *Handler) getEventsV2(ctx *gin.Context) {
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	var eventRange model.EventRange
+	if eventRange.From, eventRange.To, ok = h.rangeOrPeriodQuery(ctx, loc); !ok {
+		return
+	}
+
+	if eventRange.Limit, ok = limitQuery(ctx, 0); !ok {
+		return
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		parsedCursor, err := model.ParseCursor(cursor)
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		eventRange.After = parsedCursor
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	page, err := h.services.GetEvents(ctx.Request.Context(), userID, eventRange, loc)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	result := gin.H{
+		"status": "ok",
+		"from":   eventRange.From.Format("2006-01-02"),
+		"to":     eventRange.To.Format("2006-01-02"),
+		"events": page.Events,
+	}
+	if page.NextCursor != "" {
+		result["next_cursor"] = page.NextCursor
+	}
+
+	ReturnResultResponse(ctx, result)
+}
+
+// limitQuery parses the optional page size, defaultLimit if it is not given.
+func limitQuery(ctx *gin.Context, defaultLimit int) (int, bool) {
+	limit := ctx.Query("limit")
+	if limit == "" {
+		return defaultLimit, true
+	}
+
+	parsedLimit, err := strconv.Atoi(limit)
+	if err != nil || parsedLimit <= 0 || parsedLimit > maxPageSize {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageSize))
+		return 0, false
+	}
+
+	return parsedLimit, true
+}
+
+// rangeOrPeriodQuery returns the period given by the period and date
+// parameters or the range given by from and to.
+func (h *Handler) rangeOrPeriodQuery(ctx *gin.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	if period := ctx.Query("period"); period != "" {
+		return h.periodQuery(ctx, model.Period(period), loc)
+	}
+
+	return rangeQuery(ctx, loc)
+}
+
+// rangeQuery parses the required from and to dates in loc.
+func rangeQuery(ctx *gin.Context, loc *time.Location) (time.Time, time.Time, bool) {
+	from, ok := ctx.GetQuery("from")
+	if !ok || from == "" {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "from is required")
+		return time.Time{}, time.Time{}, false
+	}
+
+	parsedFrom, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid from")
+		return time.Time{}, time.Time{}, false
+	}
+
+	to, ok := ctx.GetQuery("to")
+	if !ok || to == "" {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "to is required")
+		return time.Time{}, time.Time{}, false
+	}
+
+	parsedTo, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid to")
+		return time.Time{}, time.Time{}, false
+	} else if !parsedTo.After(parsedFrom) {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "to must be after from")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return parsedFrom, parsedTo, true
+}
+
+// periodQuery returns the bounds of the period containing the required date
+// in loc.
+func (h *Handler) periodQuery(ctx *gin.Context, period model.Period, loc *time.Location) (time.Time, time.Time, bool) {
+	if _, ok := ctx.GetQuery("from"); ok {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "period can't be combined with from and to")
+		return time.Time{}, time.Time{}, false
+	} else if _, ok = ctx.GetQuery("to"); ok {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "period can't be combined with from and to")
+		return time.Time{}, time.Time{}, false
+	}
+
+	date, ok := ctx.GetQuery("date")
+	if !ok || date == "" {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "date is required")
+		return time.Time{}, time.Time{}, false
+	}
+
+	parsedDate, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid date")
+		return time.Time{}, time.Time{}, false
+	}
+
+	weekStart := h.firstDayOfWeek
+	if weekday := ctx.Query("week_start"); weekday != "" {
+		weekStart, err = model.ParseWeekday(weekday)
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid week_start")
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	from, to, err := period.Bounds(parsedDate, weekStart)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "period must be one of day, week, month")
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+func (h *Handler) returnCreatedEvent(ctx *gin.Context, userID, eventID int, loc *time.Location) {
+	event, err := h.services.Get(ctx.Request.Context(), userID, eventID, loc)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v2/events/%d", eventID))
+	setETag(ctx, event)
+	ReturnStatusResponse(ctx, http.StatusCreated, gin.H{"status": "ok", "event": event})
+}
+
+func eventIDParam(ctx *gin.Context) (int, bool) {
+	eventID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || eventID <= 0 {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid event id")
+		return 0, false
+	}
+
+	return eventID, true
+}
+
+// returnEventErrorV2 maps service errors to v2 status codes: missing events
+// and occurrences are 404, occurrence operations on single events and
+// overlapping events are 409, failed If-Match preconditions are 412.
+func returnEventErrorV2(ctx *gin.Context, err error) {
+	status, message := eventErrorV2(err)
+	ReturnErrorResponse(ctx, status, message)
+}
+
+// eventErrorV2 returns the status and the message returnEventErrorV2
+// responds with.
+func eventErrorV2(err error) (int, string) {
+	var conflictErr *service.ConflictError
+
+	switch {
+	case errors.Is(err, repository.NotFoundError), errors.Is(err, service.OccurrenceNotFoundError):
+		return http.StatusNotFound, err.Error()
+	case errors.Is(err, service.NotRecurringError), errors.As(err, &conflictErr):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, repository.VersionMismatchError):
+		return http.StatusPreconditionFailed, err.Error()
+	default:
+		return internalError(err)
+	}
+}
@@ -0,0 +1,663 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eventResponseV2 struct {
+	Result struct {
+		Event      model.Event   `json:"event"`
+		Events     []model.Event `json:"events"`
+		From       string        `json:"from"`
+		To         string        `json:"to"`
+		NextCursor string        `json:"next_cursor"`
+	} `json:"result"`
+}
+
+func TestCreateAndGetEventV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+
+	testCases := []struct {
+		name         string
+		data         any
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "2026-02-04",
+				Time:        "14:55",
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "invalid date",
+			data: model.EventCreate{
+				Description: "something that I used to do",
+				Date:        "date",
+				Time:        "14:55",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid json",
+			data:         "qwerty",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v2/events", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode != http.StatusCreated {
+				return
+			}
+
+			var created eventResponseV2
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+			assert.Equal(t, fmt.Sprintf("/v2/events/%d", created.Result.Event.ID), rec.Header().Get("Location"))
+			assert.Equal(t, "something that I used to do", created.Result.Event.Description)
+
+			location := rec.Header().Get("Location")
+			rec = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", location, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var fetched eventResponseV2
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &fetched))
+			assert.Equal(t, created.Result.Event, fetched.Result.Event)
+		})
+	}
+
+	getCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "invalid (non-existing event)",
+			path:         "/v2/events/12345",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (event of another user)",
+			path:         fmt.Sprintf("/v2/events/%d", otherEventID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			path:         "/v2/events/qwerty",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range getCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestUpdateEventV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	seriesID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+
+	testCases := []struct {
+		name         string
+		eventID      int
+		data         model.EventUpdate
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			eventID:      eventID,
+			data:         model.EventUpdate{Time: "16:00"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "valid (occurrence)",
+			eventID:      seriesID,
+			data:         model.EventUpdate{Time: "11:00", OccurrenceDate: "2026-02-03"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid (no fields)",
+			eventID:      eventID,
+			data:         model.EventUpdate{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid time",
+			eventID:      eventID,
+			data:         model.EventUpdate{Time: "time"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (non-existing event)",
+			eventID:      12345,
+			data:         model.EventUpdate{Time: "16:00"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (event of another user)",
+			eventID:      otherEventID,
+			data:         model.EventUpdate{Time: "16:00"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (no occurrence on date)",
+			eventID:      seriesID,
+			data:         model.EventUpdate{Time: "11:00", OccurrenceDate: "2026-02-03"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (event is not recurring)",
+			eventID:      eventID,
+			data:         model.EventUpdate{Time: "11:00", OccurrenceDate: "2026-02-06"},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("/v2/events/%d", tc.eventID), bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	event, err := services.Get(ctx, userID, eventID, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, "test_data", event.Description)
+	assert.Equal(t, "16:00:00", event.Time)
+}
+
+func TestDeleteEventV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	seriesID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			path:         fmt.Sprintf("/v2/events/%d", eventID),
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "invalid (deleting same event twice)",
+			path:         fmt.Sprintf("/v2/events/%d", eventID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (event of another user)",
+			path:         fmt.Sprintf("/v2/events/%d", otherEventID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "valid (occurrence)",
+			path:         fmt.Sprintf("/v2/events/%d?occurrence_date=2026-02-04", seriesID),
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "invalid (deleting same occurrence twice)",
+			path:         fmt.Sprintf("/v2/events/%d?occurrence_date=2026-02-04", seriesID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid occurrence_date",
+			path:         fmt.Sprintf("/v2/events/%d?occurrence_date=date", seriesID),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusNoContent {
+				assert.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestEventVersionsV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	path := fmt.Sprintf("/v2/events/%d", eventID)
+
+	send := func(method, ifMatch, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		ifMatch      string
+		body         string
+		expectedCode int
+		expectedETag string
+	}{
+		{name: "get", method: "GET", expectedCode: http.StatusOK, expectedETag: `"1"`},
+		{name: "update with current version", method: "PATCH", ifMatch: `"1"`, body: `{"description": "v2"}`, expectedCode: http.StatusOK, expectedETag: `"2"`},
+		{name: "update with stale version", method: "PATCH", ifMatch: `"1"`, body: `{"description": "lost"}`, expectedCode: http.StatusPreconditionFailed},
+		{name: "invalid If-Match", method: "PATCH", ifMatch: "2", body: `{"description": "lost"}`, expectedCode: http.StatusBadRequest},
+		{name: "update without If-Match", method: "PATCH", body: `{"description": "v3"}`, expectedCode: http.StatusOK, expectedETag: `"3"`},
+		{name: "update with any version", method: "PATCH", ifMatch: "*", body: `{"description": "v4"}`, expectedCode: http.StatusOK, expectedETag: `"4"`},
+		{name: "delete with stale version", method: "DELETE", ifMatch: `"3"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "delete with weak current version", method: "DELETE", ifMatch: `W/"4"`, expectedCode: http.StatusNoContent},
+		{name: "delete deleted event", method: "DELETE", ifMatch: `"4"`, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := send(tc.method, tc.ifMatch, tc.body)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedETag != "" {
+				assert.Equal(t, tc.expectedETag, rec.Header().Get("ETag"))
+
+				var response eventResponseV2
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedETag, fmt.Sprintf("%q", fmt.Sprint(response.Result.Event.Version)))
+			}
+		})
+	}
+
+	event, err := repos.Event.GetByID(ctx, userID, eventID)
+	assert.Equal(t, repository.NotFoundError, err)
+	assert.Empty(t, event.Description)
+}
+
+func TestGetEventsV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "dentist",
+		Date:        "2026-02-04",
+		Time:        "09:00",
+	})
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-01-26",
+		Time:        "10:00",
+		RRule:       "FREQ=WEEKLY",
+	})
+
+	testCases := []struct {
+		name           string
+		params         string
+		expectedCode   int
+		expectedEvents int
+		expectedFrom   string
+		expectedTo     string
+	}{
+		{
+			name:           "valid",
+			params:         "?from=2026-02-01&to=2026-02-15",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 3,
+			expectedFrom:   "2026-02-01",
+			expectedTo:     "2026-02-15",
+		},
+		{
+			name:           "valid (quarter)",
+			params:         "?from=2026-01-01&to=2026-04-01",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 11,
+			expectedFrom:   "2026-01-01",
+			expectedTo:     "2026-04-01",
+		},
+		{
+			name:           "valid (day)",
+			params:         "?period=day&date=2026-02-02",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 1,
+			expectedFrom:   "2026-02-02",
+			expectedTo:     "2026-02-03",
+		},
+		{
+			name:           "valid (week from monday)",
+			params:         "?period=week&date=2026-02-04",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 2,
+			expectedFrom:   "2026-02-02",
+			expectedTo:     "2026-02-09",
+		},
+		{
+			name:           "valid (week from tuesday)",
+			params:         "?period=week&date=2026-02-02&week_start=tuesday",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 1,
+			expectedFrom:   "2026-01-27",
+			expectedTo:     "2026-02-03",
+		},
+		{
+			name:           "valid (month)",
+			params:         "?period=month&date=2026-02-20",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 5,
+			expectedFrom:   "2026-02-01",
+			expectedTo:     "2026-03-01",
+		},
+		{
+			name:         "invalid period",
+			params:       "?period=year&date=2026-02-20",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (period without date)",
+			params:       "?period=week",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (period with from)",
+			params:       "?period=week&date=2026-02-20&from=2026-02-01",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid week_start",
+			params:       "?period=week&date=2026-02-20&week_start=funday",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid limit",
+			params:       "?from=2026-02-01&to=2026-02-15&limit=0",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (limit too big)",
+			params:       "?from=2026-02-01&to=2026-02-15&limit=1001",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid cursor",
+			params:       "?from=2026-02-01&to=2026-02-15&cursor=qwerty",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:           "valid (no events)",
+			params:         "?from=2000-01-01&to=2000-02-01",
+			expectedCode:   http.StatusOK,
+			expectedEvents: 0,
+		},
+		{
+			name:         "invalid (no from)",
+			params:       "?to=2026-02-15",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid to",
+			params:       "?from=2026-02-01&to=qwerty",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (to before from)",
+			params:       "?from=2026-02-15&to=2026-02-01",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/v2/events%s", tc.params), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				var response eventResponseV2
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedEvents, len(response.Result.Events))
+				assert.Empty(t, response.Result.NextCursor)
+				if tc.expectedFrom != "" {
+					assert.Equal(t, tc.expectedFrom, response.Result.From)
+					assert.Equal(t, tc.expectedTo, response.Result.To)
+				}
+			}
+		})
+	}
+}
+
+func TestGetEventsV2Pagination(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services, WithFirstDayOfWeek(time.Sunday))
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "dentist",
+		Date:        "2026-02-04",
+		Time:        "09:00",
+	})
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-01-26",
+		Time:        "10:00",
+		RRule:       "FREQ=WEEKLY",
+	})
+
+	get := func(params string) eventResponseV2 {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v2/events"+params, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response eventResponseV2
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		return response
+	}
+
+	// Weeks start on Sunday for this handler
+	week := get("?period=week&date=2026-02-04")
+	assert.Equal(t, "2026-02-01", week.Result.From)
+	assert.Equal(t, "2026-02-08", week.Result.To)
+
+	dates := make([]string, 0)
+	params := "?period=month&date=2026-02-04&limit=2"
+	for pages := 0; pages < 5; pages++ {
+		page := get(params)
+		assert.LessOrEqual(t, len(page.Result.Events), 2)
+		for _, event := range page.Result.Events {
+			dates = append(dates, event.Date)
+		}
+
+		if page.Result.NextCursor == "" {
+			break
+		}
+		params = "?period=month&date=2026-02-04&limit=2&cursor=" + page.Result.NextCursor
+	}
+
+	assert.Equal(t, []string{"2026-02-02", "2026-02-04", "2026-02-09", "2026-02-16", "2026-02-23"}, dates)
+}
+
+// doneEvents fails reads and creates with err once the context of the call is
+// done, the way the postgres repository does.
+type doneEvents struct {
+	repository.Event
+	err error
+}
+
+func (r *doneEvents) GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) ([]model.Event, error) {
+	if ctx.Err() != nil {
+		return nil, r.err
+	}
+
+	return r.Event.GetEventsInRange(ctx, userID, eventRange)
+}
+
+func (r *doneEvents) Create(ctx context.Context, userID int, event model.Event) (int, error) {
+	if ctx.Err() != nil {
+		return 0, r.err
+	}
+
+	return r.Event.Create(ctx, userID, event)
+}
+
+func TestQueryCancellationV2(t *testing.T) {
+	testCases := []struct {
+		name         string
+		cancel       bool
+		err          error
+		expectedCode int
+	}{
+		{name: "running", err: repository.CanceledError, expectedCode: http.StatusOK},
+		{name: "cancelled", cancel: true, err: repository.CanceledError, expectedCode: StatusClientClosedRequest},
+		{name: "timed out", cancel: true, err: repository.TimeoutError, expectedCode: http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepository()
+			repos.Event = &doneEvents{Event: repos.Event, err: tc.err}
+			services := service.NewService(repos)
+			router := NewHandler(services).InitRoutes()
+			token := createAPIKey(t, services, 1)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(ctx, "GET", "/v2/events?from=2026-02-01&to=2026-02-02", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// freeBusyV2 handles GET /v2/free_busy and returns the busy intervals of the
+// user and the free ones between them. The range is given the same way as
+// for GET /v2/events; the intervals are in the timezone of the request.
+func (h *Handler) freeBusyV2(ctx *gin.Context) {
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	from, to, ok := h.rangeOrPeriodQuery(ctx, loc)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	freeBusy, err := h.services.FreeBusy(ctx.Request.Context(), userID, from, to, loc)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "busy": freeBusy.Busy, "free": freeBusy.Free})
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type freeBusyResponse struct {
+	Result struct {
+		Busy []model.Interval `json:"busy"`
+		Free []model.Interval `json:"free"`
+	} `json:"result"`
+}
+
+func TestFreeBusyV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+	repos.Event.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "09:00", Duration: "15m", RRule: "FREQ=DAILY"})
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 2, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		name         string
+		params       string
+		expectedCode int
+		expectedBusy []model.Interval
+		expectedFree []model.Interval
+	}{
+		{
+			name:         "valid",
+			params:       "?from=2026-02-04&to=2026-02-05",
+			expectedCode: http.StatusOK,
+			expectedBusy: []model.Interval{
+				{Start: at(4, 9, 0), End: at(4, 9, 15)},
+				{Start: at(4, 10, 0), End: at(4, 11, 0)},
+			},
+			expectedFree: []model.Interval{
+				{Start: at(4, 0, 0), End: at(4, 9, 0)},
+				{Start: at(4, 9, 15), End: at(4, 10, 0)},
+				{Start: at(4, 11, 0), End: at(5, 0, 0)},
+			},
+		},
+		{
+			name:         "valid (period)",
+			params:       "?period=day&date=2026-02-01",
+			expectedCode: http.StatusOK,
+			expectedBusy: []model.Interval{},
+			expectedFree: []model.Interval{{Start: at(1, 0, 0), End: at(2, 0, 0)}},
+		},
+		{
+			name:         "invalid (no to)",
+			params:       "?from=2026-02-04",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid timezone",
+			params:       "?from=2026-02-04&to=2026-02-05&tz=Mars/Olympus",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v2/free_busy"+tc.params, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				var response freeBusyResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, tc.expectedBusy, response.Result.Busy)
+				assert.Equal(t, tc.expectedFree, response.Result.Free)
+			}
+		})
+	}
+}
+
+func TestRejectConflicts(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos, service.WithRejectConflicts())
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+
+	overlapping := model.EventCreate{
+		Description: "review",
+		Date:        "2026-02-04",
+		Time:        "10:30",
+		Duration:    "1h",
+	}
+
+	testCases := []struct {
+		name         string
+		method       string
+		url          string
+		data         any
+		expectedCode int
+	}{
+		{
+			name:         "v1 create",
+			method:       "POST",
+			url:          "/create_event",
+			data:         overlapping,
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "v2 create",
+			method:       "POST",
+			url:          "/v2/events",
+			data:         overlapping,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "v2 create (no overlap)",
+			method: "POST",
+			url:    "/v2/events",
+			data: model.EventCreate{
+				Description: "review",
+				Date:        "2026-02-04",
+				Time:        "11:00",
+				Duration:    "1h",
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "v2 update",
+			method:       "PATCH",
+			url:          "/v2/events/2",
+			data:         model.EventUpdate{Time: "10:30"},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
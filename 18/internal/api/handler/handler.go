@@ -1,31 +1,168 @@
 package handler
 
 import (
+	"context"
+	"sync/atomic"
+	"time"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/metrics"
+	"wbtech_l2/18/internal/ratelimit"
 	"wbtech_l2/18/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	services *service.Service
+	services       *service.Service
+	firstDayOfWeek time.Weekday
+	registry       *metrics.Registry
+	httpMetrics    *metrics.HTTPMetrics
+	readinessCheck func(ctx context.Context) error
+	draining       atomic.Bool
+	drained        chan struct{}
+	rateLimit      RateLimitConfig
+	maxBodyBytes   int64
+	maxImportBytes int64
+	spec           *openapi.Document
+	validator      *openapi.Validator
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{
-		services: services,
+// RateLimitConfig limits requests per client IP and per user with token
+// buckets kept in Store. Limits with a zero rate are off.
+type RateLimitConfig struct {
+	Store   ratelimit.Store
+	PerIP   ratelimit.Limit
+	PerUser ratelimit.Limit
+}
+
+// Option configures optional behaviour of Handler.
+type Option func(*Handler)
+
+// WithFirstDayOfWeek sets the day calendar-aligned weeks start on, Monday by
+// default.
+func WithFirstDayOfWeek(day time.Weekday) Option {
+	return func(h *Handler) {
+		h.firstDayOfWeek = day
+	}
+}
+
+// WithMetrics exposes the metrics of registry on /metrics along with the
+// request metrics. By default the handler has a registry of its own.
+func WithMetrics(registry *metrics.Registry) Option {
+	return func(h *Handler) {
+		h.registry = registry
+	}
+}
+
+// WithReadinessCheck makes /readyz fail while check fails, e.g. with
+// db.PingContext.
+func WithReadinessCheck(check func(ctx context.Context) error) Option {
+	return func(h *Handler) {
+		h.readinessCheck = check
 	}
 }
 
+// WithRateLimit makes the API respond with 429 to clients over the limits.
+// Probes and /metrics are not limited.
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(h *Handler) {
+		h.rateLimit = cfg
+	}
+}
+
+// WithBodyLimits bounds the request bodies of JSON endpoints and of the
+// calendars uploaded to /import_ics. Zero keeps the default.
+func WithBodyLimits(maxBodyBytes, maxImportBytes int64) Option {
+	return func(h *Handler) {
+		if maxBodyBytes > 0 {
+			h.maxBodyBytes = maxBodyBytes
+		}
+		if maxImportBytes > 0 {
+			h.maxImportBytes = maxImportBytes
+		}
+	}
+}
+
+func NewHandler(services *service.Service, opts ...Option) *Handler {
+	h := &Handler{
+		services:       services,
+		firstDayOfWeek: time.Monday,
+		maxBodyBytes:   defaultMaxBodyBytes,
+		maxImportBytes: defaultMaxImportBytes,
+		spec:           newSpec(),
+		drained:        make(chan struct{}),
+	}
+	h.validator = openapi.NewValidator(h.spec, formats)
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if h.registry == nil {
+		h.registry = metrics.NewRegistry()
+	}
+	h.httpMetrics = metrics.NewHTTPMetrics(h.registry)
+
+	return h
+}
+
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
+	router.Use(h.requestID, h.logRequest, h.recordMetrics)
+
+	router.GET("/metrics", gin.WrapH(h.registry.Handler()))
+	router.GET("/healthz", h.healthz)
+	router.GET("/readyz", h.readyz)
+	router.GET("/openapi.json", h.getOpenAPI)
+
+	api := router.Group("", h.limitIP, h.authenticate, h.limitUser, limitBody(h.maxBodyBytes), h.validateRequest)
+	// Uploaded calendars are larger than the bodies of the JSON endpoints
+	imports := router.Group("", h.limitIP, h.authenticate, h.limitUser, limitBody(h.maxImportBytes), h.validateRequest)
+
+	api.POST("/create_event", h.createEvent)
+	api.POST("/update_event", h.updateEvent)
+	api.POST("/delete_event", h.deleteEvent)
+
+	api.GET("/events_for_day", h.getEventsForDay)
+	api.GET("/events_for_week", h.getEventsForWeek)
+	api.GET("/events_for_month", h.getEventsForMonth)
 
-	router.POST("/create_event", handlerFunc(h.createEvent))
-	router.POST("/update_event", handlerFunc(h.updateEvent))
-	router.POST("/delete_event", handlerFunc(h.deleteEvent))
+	api.GET("/events/stream", h.streamEvents)
 
-	router.GET("/events_for_day", handlerFunc(h.getEventsForDay))
-	router.GET("/events_for_week", handlerFunc(h.getEventsForWeek))
-	router.GET("/events_for_month", handlerFunc(h.getEventsForMonth))
+	api.GET("/export.ics", h.exportICS)
+	imports.POST("/import_ics", h.importICS)
+
+	v2 := api.Group("/v2")
+	{
+		v2.POST("/events", h.createEventV2)
+		v2.GET("/events", h.getEventsV2)
+		v2.GET("/events/search", h.searchEventsV2)
+		v2.POST("/events/batch", h.batchEventsV2)
+		v2.GET("/events/:id", h.getEventV2)
+		v2.PATCH("/events/:id", h.updateEventV2)
+		v2.DELETE("/events/:id", h.deleteEventV2)
+
+		v2.GET("/events/:id/attendees", h.getAttendeesV2)
+		v2.POST("/events/:id/attendees", h.inviteAttendeeV2)
+		v2.DELETE("/events/:id/attendees/:user_id", h.removeAttendeeV2)
+		v2.PUT("/events/:id/rsvp", h.respondToInviteV2)
+		v2.GET("/events/:id/history", h.getEventHistoryV2)
+
+		v2.GET("/trash", h.getTrashV2)
+		v2.POST("/trash/:id/restore", h.restoreEventV2)
+
+		v2.GET("/history", h.getUserHistoryV2)
+
+		v2.GET("/free_busy", h.freeBusyV2)
+
+		v2.GET("/shares", h.getSharesV2)
+		v2.PUT("/shares/:user_id", h.shareCalendarV2)
+		v2.DELETE("/shares/:user_id", h.unshareCalendarV2)
+
+		v2.GET("/webhooks", h.getWebhooksV2)
+		v2.POST("/webhooks", h.createWebhookV2)
+		v2.DELETE("/webhooks/:id", h.deleteWebhookV2)
+	}
 
 	return router
 }
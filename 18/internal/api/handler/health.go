@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the readiness check, so that a hanging database
+// makes the instance unready instead of hanging the probe.
+const readinessTimeout = 2 * time.Second
+
+// healthz handles GET /healthz: the process is up and serving requests.
+func (h *Handler) healthz(ctx *gin.Context) {
+	ReturnResultResponse(ctx, gin.H{"status": "ok"})
+}
+
+// readyz handles GET /readyz: the instance takes traffic unless it is
+// draining or its readiness check, e.g. a ping of the database, fails.
+func (h *Handler) readyz(ctx *gin.Context) {
+	if h.draining.Load() {
+		ReturnErrorResponse(ctx, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+
+	if h.readinessCheck != nil {
+		checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := h.readinessCheck(checkCtx); err != nil {
+			ReturnErrorResponse(ctx, http.StatusServiceUnavailable, "not ready: "+err.Error())
+			return
+		}
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok"})
+}
+
+// Drain makes /readyz fail, so that load balancers stop sending requests
+// before the server shuts down. Requests are still served, but event
+// streams are ended for their clients to reconnect to other instances.
+func (h *Handler) Drain() {
+	if h.draining.CompareAndSwap(false, true) {
+		close(h.drained)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	var pingErr error
+	services := service.NewService(repository.NewMemoryRepository())
+	handler := NewHandler(services, WithReadinessCheck(func(ctx context.Context) error {
+		return pingErr
+	}))
+	router := handler.InitRoutes()
+
+	probe := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Probes need no API key
+	rec := probe("/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"result":{"status":"ok"}}`, rec.Body.String())
+
+	rec = probe("/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Failing readiness check
+	pingErr = errors.New("connection refused")
+	rec = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "connection refused")
+	assert.Equal(t, http.StatusOK, probe("/healthz").Code)
+
+	// Draining
+	pingErr = nil
+	handler.Drain()
+	rec = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "shutting down")
+	assert.Equal(t, http.StatusOK, probe("/healthz").Code)
+}
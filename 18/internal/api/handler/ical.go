@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"wbtech_l2/18/internal/ical"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type importError struct {
+	Index int    `json:"index"`
+	UID   string `json:"uid,omitempty"`
+	Error string `json:"error"`
+}
+
+// exportICS writes user's events starting in [from, to) as an iCalendar file.
+// from and to are dates in the timezone of the request.
+func (h *Handler) exportICS(ctx *gin.Context) {
+	from, ok := ctx.GetQuery("from")
+	if !ok || from == "" {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "from is required")
+		return
+	}
+
+	parsedFrom, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid from")
+		return
+	}
+
+	to, ok := ctx.GetQuery("to")
+	if !ok || to == "" {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "to is required")
+		return
+	}
+
+	parsedTo, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid to")
+		return
+	} else if !parsedTo.After(parsedFrom) {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "to must be after from")
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	var events []model.Event
+	events, err = h.services.Export(ctx.Request.Context(), getUserID(ctx), from, to, loc)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = ical.Encode(&buf, events, time.Now()); err != nil {
+		ReturnErrorResponse(ctx, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="calendar.ics"`)
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// importICS creates an event for every VEVENT of the uploaded calendar. The
+// calendar is either the request body or the "file" field of a multipart
+// form. VEVENTs that fail validation or conflict with other events are
+// reported in "errors" and do not stop the import; any other error does,
+// keeping the events created until then.
+func (h *Handler) importICS(ctx *gin.Context) {
+	userID := getUserID(ctx)
+
+	var body io.Reader = ctx.Request.Body
+	if strings.HasPrefix(ctx.ContentType(), "multipart/form-data") {
+		file, err := ctx.FormFile("file")
+		if isTooLarge(err) {
+			returnTooLarge(ctx)
+			return
+		}
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "file is required")
+			return
+		}
+
+		opened, err := file.Open()
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid file")
+			return
+		}
+		defer opened.Close()
+
+		body = opened
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	items, err := ical.Decode(body, loc)
+	if isTooLarge(err) {
+		returnTooLarge(ctx)
+		return
+	}
+	if err != nil {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid calendar")
+		return
+	}
+
+	created := make([]int, 0, len(items))
+	importErrors := make([]importError, 0)
+
+	for i, item := range items {
+		if item.Error != "" {
+			importErrors = append(importErrors, importError{Index: i, UID: item.UID, Error: item.Error})
+			continue
+		}
+
+		eventToCreate := model.EventCreate{
+			Description:  item.Description,
+			Date:         item.Date,
+			Time:         item.Time,
+			Timezone:     item.Timezone,
+			RRule:        item.RRule,
+			ExDates:      item.ExDates,
+			RemindBefore: item.RemindBefore,
+			Duration:     item.Duration,
+		}
+
+		if message := h.validateEventCreate(eventToCreate); message != "" {
+			importErrors = append(importErrors, importError{Index: i, UID: item.UID, Error: message})
+			continue
+		}
+
+		id, err := h.services.Create(ctx.Request.Context(), userID, createdEvent(eventToCreate))
+		var conflictErr *service.ConflictError
+		if errors.As(err, &conflictErr) {
+			importErrors = append(importErrors, importError{Index: i, UID: item.UID, Error: err.Error()})
+			continue
+		}
+		// The remaining VEVENTs would fail the same way, e.g. once the client
+		// is gone or the query timed out
+		if err != nil {
+			ReturnInternalError(ctx, err)
+			return
+		}
+
+		created = append(created, id)
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "created": created, "errors": importErrors})
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportICS(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "in range",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "out of range",
+		Date:        "2026-03-06",
+		Time:        "14:00",
+	})
+	repos.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-01-05",
+		Time:        "10:00",
+		RRule:       "FREQ=WEEKLY",
+	})
+
+	testCases := []struct {
+		name         string
+		params       string
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			params:       fmt.Sprintf("?from=%s&to=%s", "2026-02-01", "2026-03-01"),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid (no from)",
+			params:       fmt.Sprintf("?to=%s", "2026-03-01"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid to",
+			params:       fmt.Sprintf("?from=%s&to=qwerty", "2026-02-01"),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (to before from)",
+			params:       fmt.Sprintf("?from=%s&to=%s", "2026-03-01", "2026-02-01"),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/export.ics%s", tc.params), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"))
+				assert.Equal(t, 2, strings.Count(rec.Body.String(), "BEGIN:VEVENT"))
+				assert.Contains(t, rec.Body.String(), "SUMMARY:in range")
+				assert.Contains(t, rec.Body.String(), "RRULE:FREQ=WEEKLY")
+			}
+		})
+	}
+}
+
+func TestImportICS(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:valid",
+		"DTSTART:20260206T140000",
+		"SUMMARY:dentist",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:no-summary",
+		"DTSTART:20260206T150000",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:invalid-rrule",
+		"DTSTART:20260206T150000",
+		"SUMMARY:stand-up",
+		"RRULE:FREQ=HOURLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:no-start",
+		"SUMMARY:no start",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	type importResponse struct {
+		Result struct {
+			Created []int         `json:"created"`
+			Errors  []importError `json:"errors"`
+		} `json:"result"`
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/import_ics", strings.NewReader(calendar))
+	req.Header.Set("Content-Type", "text/calendar")
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response importResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 1, len(response.Result.Created))
+	assert.Equal(t, []importError{
+		{Index: 1, UID: "no-summary", Error: "no description given"},
+		{Index: 2, UID: "invalid-rrule", Error: "invalid rrule"},
+		{Index: 3, UID: "no-start", Error: "no DTSTART given"},
+	}, response.Result.Errors)
+
+	events, err := services.GetEventsForDay(ctx, userID, "2026-02-06", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "dentist", events[0].Description)
+
+	// Multipart upload
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "calendar.ics")
+	part.Write([]byte(calendar))
+	writer.Close()
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/import_ics", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	response = importResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 1, len(response.Result.Created))
+	assert.Equal(t, 3, len(response.Result.Errors))
+
+	// No api key
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/import_ics", strings.NewReader(calendar))
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Cancelled requests stop the import rather than failing every VEVENT
+	repos.Event = &doneEvents{Event: repos.Event, err: repository.CanceledError}
+	services = service.NewService(repos)
+	router = NewHandler(services).InitRoutes()
+	token = createAPIKey(t, services, userID)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(cancelled, "POST", "/import_ics", strings.NewReader(calendar))
+	req.Header.Set("Content-Type", "text/calendar")
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, StatusClientClosedRequest, rec.Code)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"wbtech_l2/18/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultMaxBodyBytes bounds the bodies of JSON endpoints.
+	defaultMaxBodyBytes = 1 << 20
+	// defaultMaxImportBytes bounds the calendars uploaded to /import_ics.
+	defaultMaxImportBytes = 10 << 20
+)
+
+// limitIP throttles requests per client IP. It runs before authentication,
+// so that guessing API keys is throttled too.
+func (h *Handler) limitIP(ctx *gin.Context) {
+	h.takeToken(ctx, "ip:"+ctx.ClientIP(), h.rateLimit.PerIP)
+}
+
+// limitUser throttles requests per authenticated user, wherever they come
+// from.
+func (h *Handler) limitUser(ctx *gin.Context) {
+	h.takeToken(ctx, "user:"+strconv.Itoa(getUserID(ctx)), h.rateLimit.PerUser)
+}
+
+// takeToken responds with 429 and Retry-After in whole seconds when the
+// bucket of key is empty. The limiter fails open: if the store is
+// unavailable the request is served and the error logged.
+func (h *Handler) takeToken(ctx *gin.Context, key string, limit ratelimit.Limit) {
+	if h.rateLimit.Store == nil || limit.Rate <= 0 {
+		ctx.Next()
+		return
+	}
+
+	allowed, wait, err := h.rateLimit.Store.Take(ctx.Request.Context(), key, limit)
+	if err != nil {
+		logrus.WithField("request_id", ctx.GetString(requestIDKey)).Warnf("Rate limiter is unavailable: %s", err.Error())
+		ctx.Next()
+		return
+	}
+
+	if !allowed {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ReturnErrorResponse(ctx, http.StatusTooManyRequests, "rate limit exceeded")
+		return
+	}
+
+	ctx.Next()
+}
+
+// limitBody makes reading more than limit bytes of the body fail with
+// *http.MaxBytesError, which handlers report as 413.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit)
+		ctx.Next()
+	}
+}
+
+// bindJSON reads the body into obj. It responds with 413 if the body exceeds
+// its limit and with 400 if it is not valid JSON for obj.
+func bindJSON(ctx *gin.Context, obj any) bool {
+	if err := ctx.ShouldBindJSON(obj); err != nil {
+		if isTooLarge(err) {
+			returnTooLarge(ctx)
+		} else {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "json contains incorrect data")
+		}
+		return false
+	}
+
+	return true
+}
+
+func isTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func returnTooLarge(ctx *gin.Context) {
+	ReturnErrorResponse(ctx, http.StatusRequestEntityTooLarge, "request body is too large")
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wbtech_l2/18/internal/ratelimit"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	router := NewHandler(services, WithRateLimit(RateLimitConfig{
+		Store:   ratelimit.NewMemoryStore(),
+		PerIP:   ratelimit.Limit{Rate: 0.1, Burst: 3},
+		PerUser: ratelimit.Limit{Rate: 0.5, Burst: 2},
+	})).InitRoutes()
+
+	firstToken := createAPIKey(t, services, 1)
+	secondToken := createAPIKey(t, services, 2)
+
+	send := func(path, token, ip string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.RemoteAddr = ip + ":12345"
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	const path = "/v2/events?from=2026-02-01&to=2026-02-02"
+
+	// Users are limited wherever they come from
+	assert.Equal(t, http.StatusOK, send(path, firstToken, "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, send(path, firstToken, "10.0.0.2").Code)
+	rec := send(path, firstToken, "10.0.0.3")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	// IPs are limited whoever calls, including unauthenticated clients
+	assert.Equal(t, http.StatusOK, send(path, secondToken, "10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, send(path, "wrong", "10.0.0.1").Code)
+	rec = send(path, secondToken, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+
+	// Probes are not limited
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, send("/healthz", "", "10.0.0.1").Code)
+	}
+}
+
+func TestBodyLimits(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	router := NewHandler(services, WithBodyLimits(64, 256)).InitRoutes()
+
+	token := createAPIKey(t, services, 1)
+
+	calendar := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:" + strings.Repeat("a", 300) + "\r\nDTSTART:20260202T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	file, _ := writer.CreateFormFile("file", "calendar.ics")
+	file.Write([]byte(calendar))
+	writer.Close()
+
+	testCases := []struct {
+		name         string
+		path         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "json within limit",
+			path:         "/v2/events",
+			body:         `{"description":"a","date":"2026-02-02","time":"10:00"}`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "json over limit",
+			path:         "/v2/events",
+			body:         `{"description":"` + strings.Repeat("a", 64) + `","date":"2026-02-02","time":"10:00"}`,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "calendar over json limit",
+			path:         "/import_ics",
+			body:         "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:a\r\nDTSTART:20260202T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "calendar over import limit",
+			path:         "/import_ics",
+			body:         calendar,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "uploaded calendar over import limit",
+			path:         "/import_ics",
+			contentType:  writer.FormDataContentType(),
+			body:         form.String(),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
@@ -1,15 +1,104 @@
 package handler
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-func handlerFunc(f gin.HandlerFunc) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		logrus.Printf("%s %s %s\n", c.Request.Method, c.Request.RequestURI, time.Now().Format(time.RFC3339))
-		f(c)
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestID"
+	// maxRequestIDLength bounds request IDs taken from clients.
+	maxRequestIDLength = 128
+)
+
+// requestID takes the request ID from X-Request-ID or generates one, and
+// returns it in the response so clients can refer to the request.
+func (h *Handler) requestID(ctx *gin.Context) {
+	id := ctx.GetHeader(requestIDHeader)
+	if !isValidRequestID(id) {
+		id = newRequestID()
+	}
+
+	ctx.Set(requestIDKey, id)
+	ctx.Header(requestIDHeader, id)
+	ctx.Next()
+}
+
+// logRequest writes one structured entry per request after it is handled.
+// Error responses are logged as warnings (4xx) or errors (5xx) together with
+// their message.
+func (h *Handler) logRequest(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	status := ctx.Writer.Status()
+	fields := logrus.Fields{
+		"request_id": ctx.GetString(requestIDKey),
+		"method":     ctx.Request.Method,
+		"route":      routeOf(ctx),
+		"path":       ctx.Request.URL.Path,
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"client_ip":  ctx.ClientIP(),
 	}
+	if userID := getUserID(ctx); userID != 0 {
+		fields["user_id"] = userID
+	}
+	if err := ctx.Errors.Last(); err != nil {
+		fields["error"] = err.Error()
+	}
+
+	entry := logrus.WithFields(fields)
+	switch {
+	case status >= http.StatusInternalServerError:
+		entry.Error("Request failed")
+	case status >= http.StatusBadRequest:
+		entry.Warn("Request rejected")
+	default:
+		entry.Info("Request handled")
+	}
+}
+
+// recordMetrics records the latency of the request per route.
+func (h *Handler) recordMetrics(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+
+	h.httpMetrics.ObserveRequest(ctx.Request.Method, routeOf(ctx), ctx.Writer.Status(), time.Since(start))
+}
+
+// routeOf returns the matched route pattern, e.g. /v2/events/:id.
+func routeOf(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+
+	return "unmatched"
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
 }
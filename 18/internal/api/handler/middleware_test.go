@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/metrics"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogging(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	router := NewHandler(services).InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/events/12345", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Request-ID", "req-1")
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+
+	entry := hook.LastEntry()
+	assert.Equal(t, logrus.WarnLevel, entry.Level)
+	assert.Equal(t, "req-1", entry.Data["request_id"])
+	assert.Equal(t, "GET", entry.Data["method"])
+	assert.Equal(t, "/v2/events/:id", entry.Data["route"])
+	assert.Equal(t, "/v2/events/12345", entry.Data["path"])
+	assert.Equal(t, http.StatusNotFound, entry.Data["status"])
+	assert.Equal(t, userID, entry.Data["user_id"])
+	assert.Equal(t, repository.NotFoundError.Error(), entry.Data["error"])
+	assert.Contains(t, entry.Data, "latency_ms")
+
+	// Invalid or missing request IDs are replaced
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/v2/events/12345", nil)
+	req.Header.Set("X-Request-ID", "has spaces")
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 32)
+	assert.NotContains(t, hook.LastEntry().Data, "user_id")
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	services := service.NewService(repository.NewMemoryRepository())
+	router := NewHandler(services, WithMetrics(registry)).InitRoutes()
+
+	token := createAPIKey(t, services, 1)
+
+	for _, id := range []string{"1", "2"} {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v2/events/"+id, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(rec, req)
+	}
+
+	// /metrics needs no API key
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `http_request_duration_seconds_count{method="GET",route="/v2/events/:id",status="404"} 2`)
+}
@@ -0,0 +1,739 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formats are the string formats of the spec the validator checks. Empty
+// strings are valid in every format.
+var formats = map[string]openapi.Format{
+	"date": {
+		Check:   isValidDate,
+		Message: "must be a date (YYYY-MM-DD)",
+	},
+	"time-of-day": {
+		Check:   func(s string) bool { _, err := time.Parse("15:04", s); return err == nil },
+		Message: "must be a time (HH:MM)",
+	},
+	"timezone": {
+		Check:   isValidTimezone,
+		Message: "must be an IANA timezone such as Europe/Moscow",
+	},
+	"rrule": {
+		Check:   isValidRRule,
+		Message: "must be a recurrence rule such as FREQ=WEEKLY;BYDAY=MO",
+	},
+	"duration": {
+		Check:   isValidDuration,
+		Message: "must be a duration of at least 1s such as 30m or 1h30m",
+	},
+	"offset": {
+		Check:   isValidRemindBefore,
+		Message: "must be a non-negative duration such as 15m",
+	},
+	"weekday": {
+		Check:   func(s string) bool { _, err := model.ParseWeekday(s); return err == nil },
+		Message: "must be a day of the week such as monday",
+	},
+	"uri": {
+		Check:   isValidWebhookURL,
+		Message: "must be an http or https URL",
+	},
+}
+
+// getOpenAPI handles GET /openapi.json.
+func (h *Handler) getOpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, h.spec)
+}
+
+// validateRequest checks the parameters and the JSON body of the request
+// against the operation of its route in the spec and responds with 400 and
+// the errors of every invalid field. Handlers behind it only check what the
+// spec can't express, e.g. that a range ends after it starts.
+func (h *Handler) validateRequest(ctx *gin.Context) {
+	op := h.spec.Operation(ctx.Request.Method, specPath(ctx.FullPath()))
+	if op == nil {
+		ctx.Next()
+		return
+	}
+
+	pathParams := make(map[string]string, len(ctx.Params))
+	for _, param := range ctx.Params {
+		pathParams[param.Key] = param.Value
+	}
+
+	errs, err := h.validator.ValidateRequest(op, ctx.Request, pathParams)
+	if err != nil {
+		if isTooLarge(err) {
+			returnTooLarge(ctx)
+		} else {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid request body")
+		}
+		return
+	}
+
+	if len(errs) > 0 {
+		ReturnValidationError(ctx, errs)
+		return
+	}
+
+	ctx.Next()
+}
+
+var routeParam = regexp.MustCompile(`:(\w+)`)
+
+// specPath turns a gin route such as /v2/events/:id into the path template
+// of the spec, /v2/events/{id}.
+func specPath(route string) string {
+	return routeParam.ReplaceAllString(route, "{$1}")
+}
+
+// validateEventCreate validates an event created other than from a request
+// body, e.g. by the import, with the schema of the request body. It returns
+// the message describing the first invalid field, such as "invalid rrule",
+// or an empty string.
+func (h *Handler) validateEventCreate(event model.EventCreate) string {
+	value := map[string]any{
+		"description":   event.Description,
+		"date":          event.Date,
+		"time":          event.Time,
+		"timezone":      event.Timezone,
+		"rrule":         event.RRule,
+		"remind_before": event.RemindBefore,
+		"duration":      event.Duration,
+	}
+	if event.ExDates != nil {
+		exDates := make([]any, 0, len(event.ExDates))
+		for _, date := range event.ExDates {
+			exDates = append(exDates, date)
+		}
+		value["exdates"] = exDates
+	}
+
+	errs := h.validator.ValidateValue(openapi.Ref("EventCreate"), value, "body", "")
+	if len(errs) == 0 {
+		return ""
+	}
+
+	if errs[0].Message == "must not be empty" {
+		return fmt.Sprintf("no %s given", errs[0].Field)
+	}
+
+	return "invalid " + errs[0].Field
+}
+
+func isValidDate(date string) bool {
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
+
+// newSpec describes every route of InitRoutes. TestOpenAPI keeps both in
+// sync.
+func newSpec() *openapi.Document {
+	event := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"id":              integer(1),
+			"description":     str(""),
+			"date":            str("date"),
+			"time":            &openapi.Schema{Type: "string", Description: "HH:MM:SS on the wall clock of the request"},
+			"timezone":        str("timezone"),
+			"starts_at":       str("date-time"),
+			"ends_at":         str("date-time"),
+			"rrule":           str("rrule"),
+			"exdates":         array(str("date")),
+			"occurrence_date": str("date"),
+			"remind_before":   str("offset"),
+			"duration":        str("duration"),
+			"rsvp":            enum(model.RSVPStatuses...),
+			"organizer_id":    integer(1),
+			"version":         integer(1),
+			"deleted_at":      str("date-time"),
+		},
+		Required: []string{"id", "description", "date", "time", "starts_at", "version"},
+	}
+
+	eventFields := func() map[string]*openapi.Schema {
+		return map[string]*openapi.Schema{
+			"description":   {Type: "string"},
+			"date":          str("date"),
+			"time":          str("time-of-day"),
+			"timezone":      withDescription(str("timezone"), "the timezone of the request by default"),
+			"rrule":         str("rrule"),
+			"exdates":       array(str("date")),
+			"remind_before": str("offset"),
+			"duration":      str("duration"),
+		}
+	}
+
+	eventCreate := &openapi.Schema{Type: "object", Properties: eventFields(), Required: []string{"description", "date", "time"}}
+	for _, name := range eventCreate.Required {
+		eventCreate.Properties[name].MinLength = openapi.Ptr(1)
+	}
+
+	eventUpdate := &openapi.Schema{
+		Type:        "object",
+		Description: "Only the given fields are changed. id selects the event in POST /update_event.",
+		Properties:  eventFields(),
+	}
+	eventUpdate.Properties["id"] = integer(0)
+	eventUpdate.Properties["occurrence_date"] = withDescription(str("date"), "changes only the occurrence of a recurring event on the date")
+
+	eventDelete := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"id":              integer(1),
+			"occurrence_date": withDescription(str("date"), "deletes only the occurrence of a recurring event on the date"),
+		},
+		Required: []string{"id"},
+	}
+
+	eventBatch := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"atomic": {Type: "boolean", Description: "applies all operations or none; otherwise every operation is applied on its own"},
+			"operations": {
+				Type:     "array",
+				Items:    openapi.Ref("BatchOperation"),
+				MinItems: openapi.Ptr(1),
+				MaxItems: openapi.Ptr(maxBatchSize),
+			},
+		},
+		Required: []string{"operations"},
+	}
+
+	batchOperation := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"op":      enum(model.BatchCreate, model.BatchUpdate, model.BatchDelete),
+			"version": withDescription(integer(1), "version of the event the update or the deletion is based on, as in If-Match"),
+			"event":   {Type: "object", Description: "EventCreate, EventUpdate (id is required) or EventDelete, by op"},
+		},
+		Required: []string{"op", "event"},
+	}
+
+	errorResponse := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"error":   {Type: "string"},
+			"details": array(openapi.Ref("FieldError")),
+		},
+		Required: []string{"error"},
+	}
+
+	fieldError := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"in":      enum("path", "query", "header", "body"),
+			"field":   {Type: "string", Description: "name of the parameter or dotted path of the body field, e.g. exdates.1"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"in", "message"},
+	}
+
+	interval := object(map[string]*openapi.Schema{"start": str("date-time"), "end": str("date-time")})
+
+	doc := &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info: openapi.Info{
+			Title:   "Calendar API",
+			Version: "2",
+			Description: "Successful responses are wrapped as {\"result\": {...}}, errors are " +
+				"{\"error\": \"...\"} with the invalid fields in details. Dates and times are on the " +
+				"wall clock of the timezone of the request, given by the tz parameter or the " +
+				"X-Timezone header, UTC by default.",
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}},
+		Components: openapi.Components{
+			Schemas: map[string]*openapi.Schema{
+				"Event":          event,
+				"EventMatch":     withProperty(event, "rank", &openapi.Schema{Type: "number"}),
+				"EventCreate":    eventCreate,
+				"EventUpdate":    eventUpdate,
+				"EventDelete":    eventDelete,
+				"EventBatch":     eventBatch,
+				"BatchOperation": batchOperation,
+				"BatchResult": object(map[string]*openapi.Schema{
+					"index":   integer(0),
+					"status":  withDescription(integer(100), "status of the operation as a request of its own"),
+					"event":   withDescription(openapi.Ref("Event"), "the created or updated event"),
+					"error":   str(""),
+					"details": array(openapi.Ref("FieldError")),
+				}),
+				"Attendee": object(map[string]*openapi.Schema{
+					"user_id": integer(1),
+					"status":  enum(model.RSVPStatuses...),
+				}),
+				"AttendeeCreate": requiredObject(map[string]*openapi.Schema{"user_id": integer(1)}),
+				"AttendeeRSVP": requiredObject(map[string]*openapi.Schema{
+					"rsvp": enum(model.RSVPStatuses...),
+				}),
+				"Share": object(map[string]*openapi.Schema{
+					"owner_id":   integer(1),
+					"user_id":    integer(1),
+					"permission": enum(model.Permissions...),
+				}),
+				"ShareUpdate": requiredObject(map[string]*openapi.Schema{
+					"permission": enum(model.Permissions...),
+				}),
+				"Webhook": object(map[string]*openapi.Schema{
+					"id":         integer(1),
+					"url":        str("uri"),
+					"secret":     withDescription(str(""), "only returned on creation"),
+					"created_at": str("date-time"),
+				}),
+				"WebhookCreate": requiredObject(map[string]*openapi.Schema{"url": str("uri")}),
+				"AuditRecord": object(map[string]*openapi.Schema{
+					"id":         integer(1),
+					"event_id":   integer(1),
+					"owner_id":   integer(1),
+					"actor_id":   integer(1),
+					"action":     enum(model.AuditCreate, model.AuditUpdate, model.AuditDelete, model.AuditRestore, model.AuditPurge),
+					"before":     withDescription(openapi.Ref("Event"), "null for created and restored events"),
+					"after":      withDescription(openapi.Ref("Event"), "null for deleted and purged events"),
+					"created_at": str("date-time"),
+				}),
+				"EventChange": object(map[string]*openapi.Schema{
+					"id":          integer(1),
+					"type":        enum(model.ChangeCreated, model.ChangeUpdated, model.ChangeDeleted),
+					"user_id":     integer(1),
+					"event_id":    integer(1),
+					"occurred_at": str("date-time"),
+				}),
+				"Interval":      interval,
+				"ErrorResponse": errorResponse,
+				"FieldError":    fieldError,
+			},
+			SecuritySchemes: map[string]openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	events := map[string]*openapi.Schema{"events": array(openapi.Ref("Event"))}
+	eventResult := map[string]*openapi.Schema{"event": openapi.Ref("Event")}
+	ok := map[string]*openapi.Schema{}
+
+	public := []map[string][]string{{}}
+	v1Date := param("date", "query", "a date of the period", str("date"), true)
+
+	eventID := param("id", "path", "ID of the event", integer(1), true)
+	rangeParams := []openapi.Parameter{
+		param("from", "query", "start of the range, required without period", str("date"), false),
+		param("to", "query", "end of the range (exclusive), required without period", str("date"), false),
+		param("period", "query", "calendar-aligned period containing date instead of from and to", enum(string(model.PeriodDay), string(model.PeriodWeek), string(model.PeriodMonth)), false),
+		param("date", "query", "a date of the period", str("date"), false),
+		param("week_start", "query", "first day of weeks, the configured one by default", str("weekday"), false),
+	}
+	page := []openapi.Parameter{
+		param("limit", "query", "page size", &openapi.Schema{Type: "integer", Minimum: openapi.Ptr(1.0), Maximum: openapi.Ptr(float64(maxPageSize))}, false),
+		param("cursor", "query", "next_cursor of the previous page", str(""), false),
+	}
+	ifMatch := param("If-Match", "header", "version of the event the change is based on, e.g. \"3\"", str(""), false)
+
+	doc.Paths = map[string]openapi.PathItem{
+		"/openapi.json": {"get": {
+			OperationID: "getOpenAPI", Summary: "This document", Security: public,
+			Responses: map[string]openapi.Response{"200": {Description: "OpenAPI document", Content: jsonContent(&openapi.Schema{Type: "object"})}},
+		}},
+		"/metrics": {"get": {
+			OperationID: "getMetrics", Summary: "Prometheus metrics", Security: public,
+			Responses: map[string]openapi.Response{"200": {Description: "Metrics in the Prometheus text format", Content: map[string]openapi.MediaType{"text/plain": {}}}},
+		}},
+		"/healthz": {"get": {
+			OperationID: "healthz", Summary: "Liveness probe", Security: public,
+			Responses: map[string]openapi.Response{"200": result("The process is up", ok)},
+		}},
+		"/readyz": {"get": {
+			OperationID: "readyz", Summary: "Readiness probe", Security: public,
+			Responses: map[string]openapi.Response{
+				"200": result("The instance takes traffic", ok),
+				"503": errorResult(http.StatusServiceUnavailable),
+			},
+		}},
+
+		"/create_event": {"post": {
+			OperationID: "createEvent", Summary: "Create an event (v1)",
+			Parameters:  locationParams(),
+			RequestBody: jsonBody("EventCreate"),
+			Responses:   responses(http.StatusOK, "The event is created", map[string]*openapi.Schema{"id": integer(1)}, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+		}},
+		"/update_event": {"post": {
+			OperationID: "updateEvent", Summary: "Update an event or detach an occurrence (v1)",
+			RequestBody: jsonBody("EventUpdate"),
+			Responses:   responses(http.StatusOK, "The event is updated; id is the detached occurrence", map[string]*openapi.Schema{"id": integer(1)}, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+		}},
+		"/delete_event": {"post": {
+			OperationID: "deleteEvent", Summary: "Delete an event or an occurrence (v1)",
+			RequestBody: jsonBody("EventDelete"),
+			Responses:   responses(http.StatusOK, "The event is deleted", ok, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+		}},
+		"/events_for_day": {"get": {
+			OperationID: "getEventsForDay", Summary: "Events of a day (v1)",
+			Parameters: append(locationParams(), v1Date),
+			Responses:  responses(http.StatusOK, "Events of the day", events, http.StatusGatewayTimeout),
+		}},
+		"/events_for_week": {"get": {
+			OperationID: "getEventsForWeek", Summary: "Events of 7 days from a date (v1)",
+			Description: "The window is the 7 days starting at date, whatever day of the week it is. " +
+				"v1 keeps these rolling windows; GET /v2/events with period=week serves calendar-aligned weeks.",
+			Parameters: append(locationParams(), v1Date),
+			Responses:  responses(http.StatusOK, "Events of the week", events, http.StatusGatewayTimeout),
+		}},
+		"/events_for_month": {"get": {
+			OperationID: "getEventsForMonth", Summary: "Events of 31 days from a date (v1)",
+			Description: "The window is the 31 days starting at date, whatever the length of the month. " +
+				"v1 keeps these rolling windows; GET /v2/events with period=month serves calendar months.",
+			Parameters: append(locationParams(), v1Date),
+			Responses:  responses(http.StatusOK, "Events of the month", events, http.StatusGatewayTimeout),
+		}},
+		"/events/stream": {"get": {
+			OperationID: "streamEvents", Summary: "Stream the changes of events as server-sent events",
+			Parameters: []openapi.Parameter{
+				param("user_id", "query", "ID of the owner of a calendar shared with the caller, the caller by default", integer(1), false),
+				param("Last-Event-ID", "header", "ID of the last change received, to get the ones missed since", integer(1), false),
+			},
+			Responses: withErrors(map[string]openapi.Response{
+				"200": {
+					Description: "Events named created, updated or deleted with an EventChange as data, or reset when changes were lost and the events have to be reloaded",
+					Content:     map[string]openapi.MediaType{"text/event-stream": {Schema: openapi.Ref("EventChange")}},
+				},
+			}, http.StatusForbidden),
+		}},
+		"/export.ics": {"get": {
+			OperationID: "exportICS", Summary: "Export events as iCalendar",
+			Parameters: append(locationParams(),
+				param("from", "query", "start of the range", str("date"), true),
+				param("to", "query", "end of the range (exclusive)", str("date"), true)),
+			Responses: withErrors(map[string]openapi.Response{
+				"200": {Description: "iCalendar file", Content: map[string]openapi.MediaType{"text/calendar": {}}},
+			}, http.StatusGatewayTimeout),
+		}},
+		"/import_ics": {"post": {
+			OperationID: "importICS", Summary: "Import events from iCalendar",
+			Parameters: locationParams(),
+			RequestBody: &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+				"text/calendar": {},
+				"multipart/form-data": {Schema: object(map[string]*openapi.Schema{
+					"file": {Type: "string", Format: "binary"},
+				})},
+			}},
+			Responses: responses(http.StatusOK, "Created events and the VEVENTs that failed", map[string]*openapi.Schema{
+				"created": array(integer(1)),
+				"errors": array(object(map[string]*openapi.Schema{
+					"index": integer(0),
+					"uid":   str(""),
+					"error": str(""),
+				})),
+			}, http.StatusRequestEntityTooLarge),
+		}},
+
+		"/v2/events": {
+			"post": {
+				OperationID: "createEventV2", Summary: "Create an event",
+				Parameters:  append(locationParams(), calendarParam()),
+				RequestBody: jsonBody("EventCreate"),
+				Responses:   withETag(responses(http.StatusCreated, "The created event", eventResult, http.StatusForbidden, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusGatewayTimeout)),
+			},
+			"get": {
+				OperationID: "getEventsV2", Summary: "Events and occurrences of a range, ordered by start",
+				Parameters: append(append(append(locationParams(), calendarParam()), rangeParams...), page...),
+				Responses: responses(http.StatusOK, "A page of events", map[string]*openapi.Schema{
+					"from":        str("date"),
+					"to":          str("date"),
+					"events":      array(openapi.Ref("Event")),
+					"next_cursor": str(""),
+				}, http.StatusForbidden, http.StatusGatewayTimeout),
+			},
+		},
+		"/v2/events/search": {"get": {
+			OperationID: "searchEventsV2", Summary: "Full-text search of events, the most relevant first",
+			Parameters: append(locationParams(), calendarParam(),
+				withMinLength(param("q", "query", "words to search for as prefixes", str(""), true)),
+				param("from", "query", "start of the range", str("date"), false),
+				param("to", "query", "end of the range (exclusive)", str("date"), false),
+				page[0],
+				param("offset", "query", "next_offset of the previous page", integer(0), false)),
+			Responses: responses(http.StatusOK, "A page of matches", map[string]*openapi.Schema{
+				"events":      array(openapi.Ref("EventMatch")),
+				"next_offset": integer(1),
+			}, http.StatusForbidden, http.StatusGatewayTimeout),
+		}},
+		"/v2/events/batch": {"post": {
+			OperationID: "batchEventsV2", Summary: "Create, update and delete events in one request",
+			Parameters:  append(locationParams(), calendarParam()),
+			RequestBody: jsonBody("EventBatch"),
+			Responses: responses(http.StatusOK, "The result of every operation in order; atomic batches fail with the error of the failed operation instead",
+				map[string]*openapi.Schema{"results": array(openapi.Ref("BatchResult"))},
+				http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge, http.StatusGatewayTimeout),
+		}},
+		"/v2/events/{id}": {
+			"get": {
+				OperationID: "getEventV2", Summary: "An event; recurring events are returned as series",
+				Parameters: append(locationParams(), calendarParam(), eventID),
+				Responses:  withETag(responses(http.StatusOK, "The event", eventResult, http.StatusForbidden, http.StatusNotFound, http.StatusGatewayTimeout)),
+			},
+			"patch": {
+				OperationID: "updateEventV2", Summary: "Change the given fields or detach an occurrence",
+				Parameters:  append(locationParams(), calendarParam(), eventID, ifMatch),
+				RequestBody: jsonBody("EventUpdate"),
+				Responses: withETag(responses(http.StatusOK, "The updated event; 201 with the event detached from the series", eventResult,
+					http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge, http.StatusGatewayTimeout)),
+			},
+			"delete": {
+				OperationID: "deleteEventV2", Summary: "Move an event to the trash or delete an occurrence",
+				Parameters: append(locationParams(), calendarParam(), eventID, ifMatch,
+					param("occurrence_date", "query", "deletes only the occurrence on the date", str("date"), false)),
+				Responses: noContent("The event is deleted", http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusGatewayTimeout),
+			},
+		},
+		"/v2/events/{id}/attendees": {
+			"get": {
+				OperationID: "getAttendeesV2", Summary: "Attendees of an event",
+				Parameters: []openapi.Parameter{calendarParam(), eventID},
+				Responses:  responses(http.StatusOK, "The attendees", map[string]*openapi.Schema{"attendees": array(openapi.Ref("Attendee"))}, http.StatusForbidden, http.StatusNotFound),
+			},
+			"post": {
+				OperationID: "inviteAttendeeV2", Summary: "Invite a user to an event",
+				Parameters:  []openapi.Parameter{calendarParam(), eventID},
+				RequestBody: jsonBody("AttendeeCreate"),
+				Responses: responses(http.StatusCreated, "The invitation", map[string]*openapi.Schema{"attendee": openapi.Ref("Attendee")},
+					http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge),
+			},
+		},
+		"/v2/events/{id}/attendees/{user_id}": {"delete": {
+			OperationID: "removeAttendeeV2", Summary: "Remove an attendee",
+			Parameters: []openapi.Parameter{calendarParam(), eventID, param("user_id", "path", "ID of the attendee", integer(1), true)},
+			Responses:  noContent("The attendee is removed", http.StatusForbidden, http.StatusNotFound),
+		}},
+		"/v2/events/{id}/rsvp": {"put": {
+			OperationID: "respondToInviteV2", Summary: "Answer an invitation",
+			Parameters:  []openapi.Parameter{eventID},
+			RequestBody: jsonBody("AttendeeRSVP"),
+			Responses: responses(http.StatusOK, "The answer", map[string]*openapi.Schema{"rsvp": str("")},
+				http.StatusNotFound, http.StatusRequestEntityTooLarge),
+		}},
+		"/v2/events/{id}/history": {"get": {
+			OperationID: "getEventHistoryV2", Summary: "Changes of an event, the latest first",
+			Parameters: append([]openapi.Parameter{calendarParam(), eventID}, page...),
+			Responses:  historyResponses(),
+		}},
+		"/v2/trash": {"get": {
+			OperationID: "getTrashV2", Summary: "Deleted events that can be restored, most recently deleted first",
+			Parameters: append(locationParams(), calendarParam()),
+			Responses:  responses(http.StatusOK, "The trash", events, http.StatusForbidden, http.StatusGatewayTimeout),
+		}},
+		"/v2/trash/{id}/restore": {"post": {
+			OperationID: "restoreEventV2", Summary: "Restore a deleted event",
+			Parameters: append(locationParams(), calendarParam(), eventID),
+			Responses:  withETag(responses(http.StatusOK, "The restored event", eventResult, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusGatewayTimeout)),
+		}},
+		"/v2/history": {"get": {
+			OperationID: "getUserHistoryV2", Summary: "Changes of the caller's events and the changes the caller made in shared calendars",
+			Parameters: page,
+			Responses:  historyResponses(),
+		}},
+		"/v2/free_busy": {"get": {
+			OperationID: "freeBusyV2", Summary: "Busy and free intervals of a range",
+			Parameters: append(append(locationParams(), calendarParam()), rangeParams...),
+			Responses: responses(http.StatusOK, "The intervals", map[string]*openapi.Schema{
+				"busy": array(openapi.Ref("Interval")),
+				"free": array(openapi.Ref("Interval")),
+			}, http.StatusForbidden, http.StatusGatewayTimeout),
+		}},
+		"/v2/shares": {"get": {
+			OperationID: "getSharesV2", Summary: "Calendars the caller shares and the ones shared with the caller",
+			Responses: responses(http.StatusOK, "The shares", map[string]*openapi.Schema{"shares": array(openapi.Ref("Share"))}),
+		}},
+		"/v2/shares/{user_id}": {
+			"put": {
+				OperationID: "shareCalendarV2", Summary: "Share the caller's calendar with a user",
+				Parameters:  []openapi.Parameter{param("user_id", "path", "ID of the user", integer(1), true)},
+				RequestBody: jsonBody("ShareUpdate"),
+				Responses:   responses(http.StatusOK, "The share", map[string]*openapi.Schema{"share": openapi.Ref("Share")}, http.StatusRequestEntityTooLarge),
+			},
+			"delete": {
+				OperationID: "unshareCalendarV2", Summary: "Stop sharing the caller's calendar with a user",
+				Parameters: []openapi.Parameter{param("user_id", "path", "ID of the user", integer(1), true)},
+				Responses:  noContent("The calendar is no longer shared", http.StatusNotFound),
+			},
+		},
+		"/v2/webhooks": {
+			"get": {
+				OperationID: "getWebhooksV2", Summary: "Webhooks of the caller",
+				Responses: responses(http.StatusOK, "The webhooks", map[string]*openapi.Schema{"webhooks": array(openapi.Ref("Webhook"))}),
+			},
+			"post": {
+				OperationID: "createWebhookV2", Summary: "Post the changes of the caller's events to a URL",
+				RequestBody: jsonBody("WebhookCreate"),
+				Responses:   responses(http.StatusCreated, "The webhook with its secret", map[string]*openapi.Schema{"webhook": openapi.Ref("Webhook")}, http.StatusRequestEntityTooLarge),
+			},
+		},
+		"/v2/webhooks/{id}": {"delete": {
+			OperationID: "deleteWebhookV2", Summary: "Delete a webhook",
+			Parameters: []openapi.Parameter{param("id", "path", "ID of the webhook", integer(1), true)},
+			Responses:  noContent("The webhook is deleted", http.StatusNotFound),
+		}},
+	}
+
+	return doc
+}
+
+func locationParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		param("tz", "query", "timezone of the request, UTC by default", str("timezone"), false),
+		param("X-Timezone", "header", "timezone of the request if tz is not given", str("timezone"), false),
+	}
+}
+
+func calendarParam() openapi.Parameter {
+	return param("calendar", "query", "ID of the owner of a calendar shared with the caller, the caller's own calendar by default", integer(1), false)
+}
+
+func historyResponses() map[string]openapi.Response {
+	return responses(http.StatusOK, "A page of audit records", map[string]*openapi.Schema{
+		"records":     array(openapi.Ref("AuditRecord")),
+		"next_cursor": str(""),
+	}, http.StatusForbidden)
+}
+
+func param(name, in, description string, schema *openapi.Schema, required bool) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: in, Description: description, Required: required, Schema: schema}
+}
+
+func withMinLength(param openapi.Parameter) openapi.Parameter {
+	schema := *param.Schema
+	schema.MinLength = openapi.Ptr(1)
+	param.Schema = &schema
+
+	return param
+}
+
+func str(format string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Format: format}
+}
+
+func integer(minimum float64) *openapi.Schema {
+	return &openapi.Schema{Type: "integer", Minimum: &minimum}
+}
+
+func enum(values ...string) *openapi.Schema {
+	return &openapi.Schema{Type: "string", Enum: values}
+}
+
+func array(items *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{Type: "array", Items: items}
+}
+
+func object(properties map[string]*openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{Type: "object", Properties: properties}
+}
+
+// requiredObject is an object all of whose properties are required.
+func requiredObject(properties map[string]*openapi.Schema) *openapi.Schema {
+	schema := object(properties)
+	for name := range properties {
+		schema.Required = append(schema.Required, name)
+	}
+
+	return schema
+}
+
+func withDescription(schema *openapi.Schema, description string) *openapi.Schema {
+	described := *schema
+	described.Description = description
+
+	return &described
+}
+
+func withProperty(schema *openapi.Schema, name string, property *openapi.Schema) *openapi.Schema {
+	extended := *schema
+	extended.Properties = make(map[string]*openapi.Schema, len(schema.Properties)+1)
+	for key, value := range schema.Properties {
+		extended.Properties[key] = value
+	}
+	extended.Properties[name] = property
+
+	return &extended
+}
+
+func jsonBody(name string) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Ref(name))}
+}
+
+func jsonContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+// responses describes the successful response together with the errors of
+// every authenticated route and the given ones.
+func responses(status int, description string, fields map[string]*openapi.Schema, errorStatuses ...int) map[string]openapi.Response {
+	return withErrors(map[string]openapi.Response{strconv.Itoa(status): result(description, fields)}, errorStatuses...)
+}
+
+// result describes the resultResponse envelope, whose status is always "ok".
+func result(description string, fields map[string]*openapi.Schema) openapi.Response {
+	properties := map[string]*openapi.Schema{"status": enum("ok")}
+	for name, schema := range fields {
+		properties[name] = schema
+	}
+
+	envelope := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"result": {Type: "object", Properties: properties, Required: []string{"status"}},
+		},
+		Required: []string{"result"},
+	}
+
+	return openapi.Response{Description: description, Content: jsonContent(envelope)}
+}
+
+// errorResult describes the errorResponse envelope.
+func errorResult(status int) openapi.Response {
+	return openapi.Response{Description: http.StatusText(status), Content: jsonContent(openapi.Ref("ErrorResponse"))}
+}
+
+func noContent(description string, errorStatuses ...int) map[string]openapi.Response {
+	return withErrors(map[string]openapi.Response{"204": {Description: description}}, errorStatuses...)
+}
+
+func withErrors(responses map[string]openapi.Response, errorStatuses ...int) map[string]openapi.Response {
+	statuses := []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError}
+	statuses = append(statuses, errorStatuses...)
+
+	for _, status := range statuses {
+		response := errorResult(status)
+		if status == http.StatusTooManyRequests {
+			response.Headers = map[string]openapi.Header{
+				"Retry-After": {Description: "seconds until the next request is allowed", Schema: integer(1)},
+			}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+
+	return responses
+}
+
+func withETag(responses map[string]openapi.Response) map[string]openapi.Response {
+	for status, response := range responses {
+		if strings.HasPrefix(status, "2") {
+			response.Headers = map[string]openapi.Header{
+				"ETag": {Description: "version of the event for If-Match", Schema: str("")},
+			}
+			responses[status] = response
+		}
+	}
+
+	return responses
+}
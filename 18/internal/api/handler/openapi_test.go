@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPI(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	handlers := NewHandler(services)
+	router := handlers.InitRoutes()
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var doc openapi.Document
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	// Every route is described and every description is routed
+	routes := make(map[string]bool)
+	for _, route := range router.Routes() {
+		path := specPath(route.Path)
+		routes[route.Method+" "+path] = true
+		assert.NotNil(t, doc.Operation(route.Method, path), "%s %s is not described", route.Method, route.Path)
+	}
+	for path, item := range doc.Paths {
+		for method := range item {
+			assert.True(t, routes[strings.ToUpper(method)+" "+path], "%s %s is not routed", method, path)
+		}
+	}
+
+	// Every reference resolves
+	refs := strings.Count(rec.Body.String(), `"$ref":"#/components/schemas/`)
+	assert.NotZero(t, refs)
+	for name := range doc.Components.Schemas {
+		assert.NotNil(t, doc.Resolve(openapi.Ref(name)))
+	}
+	for _, ref := range strings.Split(rec.Body.String(), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	router := NewHandler(services).InitRoutes()
+
+	token := createAPIKey(t, services, 1)
+
+	testCases := []struct {
+		name            string
+		method          string
+		url             string
+		body            string
+		expectedCode    int
+		expectedDetails []openapi.FieldError
+	}{
+		{
+			name:         "valid",
+			method:       "POST",
+			url:          "/v2/events",
+			body:         `{"description":"a","date":"2026-02-02","time":"10:00","exdates":["2026-02-03"]}`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid fields",
+			method:       "POST",
+			url:          "/v2/events?tz=Mars/Olympus",
+			body:         `{"description":"","date":"02.02.2026","rrule":"FREQ=HOURLY","exdates":["2026-02-03","tomorrow"]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedDetails: []openapi.FieldError{
+				{In: "query", Field: "tz", Message: "must be an IANA timezone such as Europe/Moscow"},
+				{In: "body", Field: "time", Message: "is required"},
+				{In: "body", Field: "date", Message: "must be a date (YYYY-MM-DD)"},
+				{In: "body", Field: "description", Message: "must not be empty"},
+				{In: "body", Field: "exdates.1", Message: "must be a date (YYYY-MM-DD)"},
+				{In: "body", Field: "rrule", Message: "must be a recurrence rule such as FREQ=WEEKLY;BYDAY=MO"},
+			},
+		},
+		{
+			name:         "invalid path and query parameters",
+			method:       "GET",
+			url:          "/v2/events/abc/history?limit=5000",
+			expectedCode: http.StatusBadRequest,
+			expectedDetails: []openapi.FieldError{
+				{In: "path", Field: "id", Message: "must be an integer"},
+				{In: "query", Field: "limit", Message: "must be at most 1000"},
+			},
+		},
+		{
+			name:         "missing query parameter (v1)",
+			method:       "GET",
+			url:          "/events_for_week",
+			expectedCode: http.StatusBadRequest,
+			expectedDetails: []openapi.FieldError{
+				{In: "query", Field: "date", Message: "is required"},
+			},
+		},
+		{
+			name:         "enum",
+			method:       "PUT",
+			url:          "/v2/shares/2",
+			body:         `{"permission":"admin"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedDetails: []openapi.FieldError{
+				{In: "body", Field: "permission", Message: "must be one of read, write"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			var response errorResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedDetails, response.Details)
+		})
+	}
+}
@@ -1,25 +1,69 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
 )
 
+// StatusClientClosedRequest is the status nginx logs for requests whose
+// client went away before the response; the client never sees it.
+const StatusClientClosedRequest = 499
+
 type resultResponse struct {
 	Result map[string]any `json:"result"`
 }
 
+// errorResponse carries the errors of the invalid fields of the request in
+// Details, if the request failed validation.
 type errorResponse struct {
-	Error string `json:"error"`
+	Error   string               `json:"error"`
+	Details []openapi.FieldError `json:"details,omitempty"`
 }
 
+// ReturnErrorResponse aborts the request with the error. The message is
+// logged with the request by logRequest.
 func ReturnErrorResponse(ctx *gin.Context, statusCode int, message string) {
-	logrus.Error(message)
-	ctx.AbortWithStatusJSON(statusCode, errorResponse{message})
+	ctx.Error(errors.New(message))
+	ctx.AbortWithStatusJSON(statusCode, errorResponse{Error: message})
+}
+
+// ReturnInternalError aborts the request with 500, unless a query of the
+// request was cancelled or ran past its deadline: that is reported with 499
+// or 504.
+func ReturnInternalError(ctx *gin.Context, err error) {
+	status, message := internalError(err)
+	ReturnErrorResponse(ctx, status, message)
+}
+
+// internalError returns the status and the message ReturnInternalError
+// responds with.
+func internalError(err error) (int, string) {
+	switch {
+	case errors.Is(err, repository.CanceledError):
+		return StatusClientClosedRequest, "request cancelled"
+	case errors.Is(err, repository.TimeoutError):
+		return http.StatusGatewayTimeout, "query timed out"
+	default:
+		return http.StatusInternalServerError, "internal server error"
+	}
+}
+
+// ReturnValidationError aborts the request with 400 and the invalid fields.
+func ReturnValidationError(ctx *gin.Context, errs []openapi.FieldError) {
+	for _, err := range errs {
+		ctx.Error(err)
+	}
+	ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse{Error: "invalid request", Details: errs})
 }
 
 func ReturnResultResponse(ctx *gin.Context, result map[string]any) {
-	ctx.JSON(http.StatusOK, resultResponse{result})
+	ReturnStatusResponse(ctx, http.StatusOK, result)
+}
+
+func ReturnStatusResponse(ctx *gin.Context, statusCode int, result map[string]any) {
+	ctx.JSON(statusCode, resultResponse{result})
 }
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSearchLimit is the page size of GET /v2/events/search without limit.
+const defaultSearchLimit = 20
+
+// searchEventsV2 handles GET /v2/events/search?q= and returns user's events
+// whose descriptions contain every word of q (as a word prefix), the most
+// relevant first. The optional from and to dates in the timezone of the
+// request narrow the search; pages continue with next_offset.
+func (h *Handler) searchEventsV2(ctx *gin.Context) {
+	query := ctx.Query("q")
+	if len(model.SearchTerms(query)) == 0 {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	search := model.EventSearch{Query: query}
+
+	if from := ctx.Query("from"); from != "" {
+		parsedFrom, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid from")
+			return
+		}
+		search.From = parsedFrom
+	}
+
+	if to := ctx.Query("to"); to != "" {
+		parsedTo, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid to")
+			return
+		} else if !search.From.IsZero() && !parsedTo.After(search.From) {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "to must be after from")
+			return
+		}
+		search.To = parsedTo
+	}
+
+	if search.Limit, ok = limitQuery(ctx, defaultSearchLimit); !ok {
+		return
+	}
+
+	if offset := ctx.Query("offset"); offset != "" {
+		parsedOffset, err := strconv.Atoi(offset)
+		if err != nil || parsedOffset < 0 {
+			ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		search.Offset = parsedOffset
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	page, err := h.services.Search(ctx.Request.Context(), userID, search, loc)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	result := gin.H{"status": "ok", "events": page.Events}
+	if page.NextOffset != 0 {
+		result["next_offset"] = page.NextOffset
+	}
+
+	ReturnResultResponse(ctx, result)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type searchResponse struct {
+	Result struct {
+		Events     []model.EventMatch `json:"events"`
+		NextOffset int                `json:"next_offset"`
+	} `json:"result"`
+}
+
+func TestSearchEventsV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	repos.Event.Create(ctx, userID, model.Event{Description: "dentist appointment", Date: "2026-02-04", Time: "09:00"})
+	repos.Event.Create(ctx, userID, model.Event{Description: "dentist", Date: "2026-03-04", Time: "09:00"})
+	repos.Event.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-04", Time: "10:00"})
+	repos.Event.Create(ctx, 12345, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+
+	testCases := []struct {
+		name               string
+		params             string
+		expectedCode       int
+		expectedEvents     []string
+		expectedNextOffset int
+	}{
+		{
+			name:           "valid",
+			params:         "?q=dentist",
+			expectedCode:   http.StatusOK,
+			expectedEvents: []string{"dentist", "dentist appointment"},
+		},
+		{
+			name:           "valid (prefix)",
+			params:         "?q=APPOINT",
+			expectedCode:   http.StatusOK,
+			expectedEvents: []string{"dentist appointment"},
+		},
+		{
+			name:           "valid (date filters)",
+			params:         "?q=dentist&from=2026-02-01&to=2026-03-01",
+			expectedCode:   http.StatusOK,
+			expectedEvents: []string{"dentist appointment"},
+		},
+		{
+			name:               "valid (first page)",
+			params:             "?q=dentist&limit=1",
+			expectedCode:       http.StatusOK,
+			expectedEvents:     []string{"dentist"},
+			expectedNextOffset: 1,
+		},
+		{
+			name:           "valid (last page)",
+			params:         "?q=dentist&limit=1&offset=1",
+			expectedCode:   http.StatusOK,
+			expectedEvents: []string{"dentist appointment"},
+		},
+		{
+			name:           "valid (no matches)",
+			params:         "?q=gym",
+			expectedCode:   http.StatusOK,
+			expectedEvents: []string{},
+		},
+		{
+			name:         "invalid (no query)",
+			params:       "?q=%20-",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid from",
+			params:       "?q=dentist&from=qwerty",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (to before from)",
+			params:       "?q=dentist&from=2026-03-01&to=2026-02-01",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid offset",
+			params:       "?q=dentist&offset=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v2/events/search"+tc.params, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				var response searchResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+				descriptions := make([]string, 0, len(response.Result.Events))
+				for _, match := range response.Result.Events {
+					descriptions = append(descriptions, match.Description)
+					assert.Greater(t, match.Rank, 0.0)
+				}
+				assert.Equal(t, tc.expectedEvents, descriptions)
+				assert.Equal(t, tc.expectedNextOffset, response.Result.NextOffset)
+			}
+		})
+	}
+}
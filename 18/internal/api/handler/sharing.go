@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarOwner returns the user whose calendar the request works on: the
+// owner given by the "calendar" query parameter or the caller. It responds
+// with 403 unless the calendar is shared with the caller with the required
+// permission.
+func (h *Handler) calendarOwner(ctx *gin.Context, permission string) (int, bool) {
+	return h.calendarOwnerOf(ctx, "calendar", permission)
+}
+
+// calendarOwnerOf works as calendarOwner with the owner given by the query
+// parameter named param.
+func (h *Handler) calendarOwnerOf(ctx *gin.Context, param, permission string) (int, bool) {
+	userID := getUserID(ctx)
+
+	calendar := ctx.Query(param)
+	if calendar == "" {
+		return userID, true
+	}
+
+	ownerID, err := strconv.Atoi(calendar)
+	if err != nil || ownerID <= 0 {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid "+param)
+		return 0, false
+	}
+
+	granted, err := h.services.CalendarPermission(ctx.Request.Context(), ownerID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ShareNotFoundError) {
+			ReturnErrorResponse(ctx, http.StatusForbidden, err.Error())
+		} else {
+			ReturnInternalError(ctx, err)
+		}
+		return 0, false
+	}
+
+	if permission == model.PermissionWrite && granted != model.PermissionWrite {
+		ReturnErrorResponse(ctx, http.StatusForbidden, "calendar is shared with the user read-only")
+		return 0, false
+	}
+
+	return ownerID, true
+}
+
+// getAttendeesV2 handles GET /v2/events/{id}/attendees.
+func (h *Handler) getAttendeesV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	attendees, err := h.services.GetAttendees(ctx.Request.Context(), userID, eventID)
+	if err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "attendees": attendees})
+}
+
+// inviteAttendeeV2 handles POST /v2/events/{id}/attendees and responds with
+// 201 and the attendee, whose invitation needs an answer.
+func (h *Handler) inviteAttendeeV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var attendee model.AttendeeCreate
+	if !bindJSON(ctx, &attendee) {
+		return
+	}
+
+	ownerID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Invite(ctx.Request.Context(), ownerID, eventID, attendee.UserID); err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v2/events/%d/attendees/%d", eventID, attendee.UserID))
+	ReturnStatusResponse(ctx, http.StatusCreated, gin.H{
+		"status":   "ok",
+		"attendee": model.Attendee{EventID: eventID, UserID: attendee.UserID, Status: model.RSVPNeedsAction},
+	})
+}
+
+// removeAttendeeV2 handles DELETE /v2/events/{id}/attendees/{user_id}.
+func (h *Handler) removeAttendeeV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	ownerID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if err := h.services.Uninvite(ctx.Request.Context(), ownerID, eventID, userID); err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// respondToInviteV2 handles PUT /v2/events/{id}/rsvp, the answer of the
+// caller to the invitation. Declined events disappear from the caller's
+// event lists but can still be accepted later.
+func (h *Handler) respondToInviteV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var rsvp model.AttendeeRSVP
+	if !bindJSON(ctx, &rsvp) {
+		return
+	}
+
+	if err := h.services.RespondToInvite(ctx.Request.Context(), getUserID(ctx), eventID, rsvp.RSVP); err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "rsvp": rsvp.RSVP})
+}
+
+// getSharesV2 handles GET /v2/shares and lists the calendars the caller
+// shares and the ones shared with the caller.
+func (h *Handler) getSharesV2(ctx *gin.Context) {
+	shares, err := h.services.GetShares(ctx.Request.Context(), getUserID(ctx))
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "shares": shares})
+}
+
+// shareCalendarV2 handles PUT /v2/shares/{user_id}, which shares the
+// caller's calendar with the user or changes the permission of the user.
+func (h *Handler) shareCalendarV2(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var share model.ShareUpdate
+	if !bindJSON(ctx, &share) {
+		return
+	}
+
+	ownerID := getUserID(ctx)
+	if err := h.services.ShareCalendar(ctx.Request.Context(), ownerID, userID, share.Permission); err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{
+		"status": "ok",
+		"share":  model.Share{OwnerID: ownerID, UserID: userID, Permission: share.Permission},
+	})
+}
+
+// unshareCalendarV2 handles DELETE /v2/shares/{user_id}.
+func (h *Handler) unshareCalendarV2(ctx *gin.Context) {
+	userID, ok := userIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.services.UnshareCalendar(ctx.Request.Context(), getUserID(ctx), userID); err != nil {
+		returnSharingError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func userIDParam(ctx *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil || userID <= 0 {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid user id")
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// returnSharingError maps attendee and share errors to v2 status codes:
+// missing events, invitations and shares are 404 and repeated invitations
+// are 409.
+func returnSharingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.NotFoundError), errors.Is(err, repository.AttendeeNotFoundError),
+		errors.Is(err, repository.ShareNotFoundError):
+		ReturnErrorResponse(ctx, http.StatusNotFound, err.Error())
+	case errors.Is(err, repository.AttendeeExistsError):
+		ReturnErrorResponse(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, service.SelfInviteError), errors.Is(err, service.SelfShareError):
+		ReturnErrorResponse(ctx, http.StatusBadRequest, err.Error())
+	default:
+		ReturnInternalError(ctx, err)
+	}
+}
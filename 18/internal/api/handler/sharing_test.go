@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttendeesV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	ownerID, attendeeID := 1, 2
+	ownerToken := createAPIKey(t, services, ownerID)
+	attendeeToken := createAPIKey(t, services, attendeeID)
+	eventID, _ := repos.Event.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+
+	testCases := []struct {
+		name         string
+		token        string
+		method       string
+		url          string
+		data         any
+		expectedCode int
+	}{
+		{
+			name:         "invite",
+			token:        ownerToken,
+			method:       "POST",
+			url:          "/v2/events/1/attendees",
+			data:         model.AttendeeCreate{UserID: attendeeID},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invite again",
+			token:        ownerToken,
+			method:       "POST",
+			url:          "/v2/events/1/attendees",
+			data:         model.AttendeeCreate{UserID: attendeeID},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "invite organizer",
+			token:        ownerToken,
+			method:       "POST",
+			url:          "/v2/events/1/attendees",
+			data:         model.AttendeeCreate{UserID: ownerID},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invite to other user's event",
+			token:        attendeeToken,
+			method:       "POST",
+			url:          "/v2/events/1/attendees",
+			data:         model.AttendeeCreate{UserID: 3},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid user_id",
+			token:        ownerToken,
+			method:       "POST",
+			url:          "/v2/events/1/attendees",
+			data:         model.AttendeeCreate{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "attendees of invited event",
+			token:        attendeeToken,
+			method:       "GET",
+			url:          "/v2/events/1/attendees",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "attendee can't update",
+			token:        attendeeToken,
+			method:       "PATCH",
+			url:          "/v2/events/1",
+			data:         model.EventUpdate{Description: "mine"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid rsvp",
+			token:        attendeeToken,
+			method:       "PUT",
+			url:          "/v2/events/1/rsvp",
+			data:         model.AttendeeRSVP{RSVP: "maybe"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "rsvp without invitation",
+			token:        ownerToken,
+			method:       "PUT",
+			url:          "/v2/events/1/rsvp",
+			data:         model.AttendeeRSVP{RSVP: model.RSVPAccepted},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "rsvp",
+			token:        attendeeToken,
+			method:       "PUT",
+			url:          "/v2/events/1/rsvp",
+			data:         model.AttendeeRSVP{RSVP: model.RSVPTentative},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	type eventsResponse struct {
+		Result struct {
+			Events []model.Event `json:"events"`
+		} `json:"result"`
+	}
+
+	// Invited events show up in the attendee's v1 lists
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/events_for_day?date=2026-02-04", nil)
+	req.Header.Set("Authorization", "Bearer "+attendeeToken)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response eventsResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 1, len(response.Result.Events))
+	assert.Equal(t, eventID, response.Result.Events[0].ID)
+	assert.Equal(t, model.RSVPTentative, response.Result.Events[0].RSVP)
+	assert.Equal(t, ownerID, response.Result.Events[0].OrganizerID)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/v2/events/1/attendees/2", nil)
+	req.Header.Set("Authorization", "Bearer "+ownerToken)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/events_for_day?date=2026-02-04", nil)
+	req.Header.Set("Authorization", "Bearer "+attendeeToken)
+	router.ServeHTTP(rec, req)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Result.Events)
+}
+
+func TestSharedCalendarsV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	ownerID, readerID, writerID := 1, 2, 3
+	ownerToken := createAPIKey(t, services, ownerID)
+	readerToken := createAPIKey(t, services, readerID)
+	writerToken := createAPIKey(t, services, writerID)
+	repos.Event.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+
+	newEvent := model.EventCreate{Description: "review", Date: "2026-02-05", Time: "10:00"}
+
+	testCases := []struct {
+		name         string
+		token        string
+		method       string
+		url          string
+		data         any
+		expectedCode int
+	}{
+		{
+			name:         "read before sharing",
+			token:        readerToken,
+			method:       "GET",
+			url:          "/v2/events?from=2026-02-01&to=2026-03-01&calendar=1",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "share read",
+			token:        ownerToken,
+			method:       "PUT",
+			url:          "/v2/shares/2",
+			data:         model.ShareUpdate{Permission: model.PermissionRead},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "share write",
+			token:        ownerToken,
+			method:       "PUT",
+			url:          "/v2/shares/3",
+			data:         model.ShareUpdate{Permission: model.PermissionWrite},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "share with owner",
+			token:        ownerToken,
+			method:       "PUT",
+			url:          "/v2/shares/1",
+			data:         model.ShareUpdate{Permission: model.PermissionWrite},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid permission",
+			token:        ownerToken,
+			method:       "PUT",
+			url:          "/v2/shares/2",
+			data:         model.ShareUpdate{Permission: "admin"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "read",
+			token:        readerToken,
+			method:       "GET",
+			url:          "/v2/events/1?calendar=1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "read-only create",
+			token:        readerToken,
+			method:       "POST",
+			url:          "/v2/events?calendar=1",
+			data:         newEvent,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "read-only delete",
+			token:        readerToken,
+			method:       "DELETE",
+			url:          "/v2/events/1?calendar=1",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "write create",
+			token:        writerToken,
+			method:       "POST",
+			url:          "/v2/events?calendar=1",
+			data:         newEvent,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "write update",
+			token:        writerToken,
+			method:       "PATCH",
+			url:          "/v2/events/1?calendar=1",
+			data:         model.EventUpdate{Description: "planning (moved)"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid calendar",
+			token:        writerToken,
+			method:       "GET",
+			url:          "/v2/events/1?calendar=me",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unshare",
+			token:        ownerToken,
+			method:       "DELETE",
+			url:          "/v2/shares/3",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "unshare again",
+			token:        ownerToken,
+			method:       "DELETE",
+			url:          "/v2/shares/3",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "write after unsharing",
+			token:        writerToken,
+			method:       "POST",
+			url:          "/v2/events?calendar=1",
+			data:         newEvent,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	// Events created through the shared calendar belong to its owner
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/events?from=2026-02-01&to=2026-03-01&calendar=1", nil)
+	req.Header.Set("Authorization", "Bearer "+readerToken)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response eventResponseV2
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []string{"planning (moved)", "review"}, []string{response.Result.Events[0].Description, response.Result.Events[1].Description})
+
+	_, err := repos.Event.GetByID(ctx, ownerID, response.Result.Events[1].ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat is how often idle streams get a comment, so that proxies
+// don't close them.
+const streamHeartbeat = 30 * time.Second
+
+// streamEvents handles GET /events/stream. It pushes the changes of the
+// events of user_id, the caller by default, and of the events user_id is
+// invited to as server-sent events named after the type of the change until
+// the client goes away or the server drains. Clients that reconnect with
+// Last-Event-ID get the changes they missed, or a "reset" event if some are
+// lost and the events have to be reloaded.
+func (h *Handler) streamEvents(ctx *gin.Context) {
+	userID, ok := h.calendarOwnerOf(ctx, "user_id", model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	// validateRequest has checked the header
+	lastID, _ := strconv.Atoi(ctx.GetHeader("Last-Event-ID"))
+
+	sub := h.services.Subscribe(userID, lastID)
+	defer sub.Close()
+
+	// Streams outlive the write timeout of the server. Not every writer
+	// supports deadlines, e.g. the ones of tests.
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	ctx.Header("Content-Type", sse.ContentType)
+	ctx.Header("Cache-Control", "no-cache")
+	// Keeps nginx from buffering the stream
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+
+	if sub.Gap {
+		ctx.Render(-1, sse.Event{Id: strconv.Itoa(sub.LastID), Event: "reset", Data: "changes were lost, reload the events"})
+	}
+	for _, change := range sub.Missed {
+		ctx.Render(-1, changeEvent(change))
+	}
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case change, ok := <-sub.C:
+			if !ok {
+				// The client fell behind; it resumes when it reconnects
+				return
+			}
+			ctx.Render(-1, changeEvent(change))
+		case <-heartbeat.C:
+			ctx.Writer.WriteString(": heartbeat\n\n")
+		case <-h.drained:
+			return
+		case <-ctx.Request.Context().Done():
+			return
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+func changeEvent(change model.EventChange) sse.Event {
+	return sse.Event{Id: strconv.Itoa(change.ID), Event: change.Type, Data: change}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sseEvent is an event read from a stream.
+type sseEvent struct {
+	id     string
+	event  string
+	change model.EventChange
+}
+
+// readEvent reads the next event of the stream, skipping comments.
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return event
+		}
+
+		line = strings.TrimRight(line, "\n")
+		field, value, _ := strings.Cut(line, ":")
+		switch field {
+		case "":
+			if event.event != "" {
+				return event
+			}
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			if event.event != "reset" {
+				assert.NoError(t, json.Unmarshal([]byte(value), &event.change))
+			}
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	server := httptest.NewServer(handlers.InitRoutes())
+	defer server.Close()
+
+	ownerID, viewerID, strangerID := 1, 2, 3
+	ownerToken := createAPIKey(t, services, ownerID)
+	viewerToken := createAPIKey(t, services, viewerID)
+	strangerToken := createAPIKey(t, services, strangerID)
+	assert.NoError(t, services.ShareCalendar(ctx, ownerID, viewerID, model.PermissionRead))
+
+	stream := func(token, query, lastEventID string) *http.Response {
+		req, _ := http.NewRequest("GET", server.URL+"/events/stream"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		return resp
+	}
+
+	resp := stream(viewerToken, "?user_id=1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream;charset=utf-8", resp.Header.Get("Content-Type"))
+
+	eventID, err := services.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+	assert.NoError(t, err)
+
+	reader := bufio.NewReader(resp.Body)
+	created := readEvent(t, reader)
+	assert.Equal(t, "1", created.id)
+	assert.Equal(t, model.ChangeCreated, created.event)
+	assert.Equal(t, eventID, created.change.EventID)
+	assert.Equal(t, ownerID, created.change.UserID)
+	resp.Body.Close()
+
+	// Changes made while the client was away are sent on reconnect
+	assert.NoError(t, services.Update(ctx, ownerID, eventID, model.Event{Description: "planning 2"}))
+	assert.NoError(t, services.Delete(ctx, ownerID, eventID, 0))
+
+	resp = stream(ownerToken, "", created.id)
+	reader = bufio.NewReader(resp.Body)
+	assert.Equal(t, model.ChangeUpdated, readEvent(t, reader).event)
+	deleted := readEvent(t, reader)
+	assert.Equal(t, "3", deleted.id)
+	assert.Equal(t, model.ChangeDeleted, deleted.event)
+	resp.Body.Close()
+
+	// Attendees get the changes of the events they are invited to
+	inviteeID := 4
+	inviteeToken := createAPIKey(t, services, inviteeID)
+	invitedID, err := services.Create(ctx, ownerID, model.Event{Description: "review", Date: "2026-02-05", Time: "10:00"})
+	assert.NoError(t, err)
+	assert.NoError(t, services.Invite(ctx, ownerID, invitedID, inviteeID))
+
+	resp = stream(inviteeToken, "", "")
+	assert.NoError(t, services.Update(ctx, ownerID, invitedID, model.Event{Description: "review 2"}))
+	updated := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, model.ChangeUpdated, updated.event)
+	assert.Equal(t, invitedID, updated.change.EventID)
+	assert.Equal(t, inviteeID, updated.change.UserID)
+	resp.Body.Close()
+
+	// IDs of another process can't be resumed from
+	resp = stream(ownerToken, "", "99")
+	reset := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "reset", reset.event)
+	assert.Equal(t, "6", reset.id)
+
+	// Draining ends the stream
+	handlers.Drain()
+	_, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	testCases := []struct {
+		name         string
+		token        string
+		query        string
+		lastEventID  string
+		expectedCode int
+	}{
+		{
+			name:         "invalid (calendar not shared)",
+			token:        strangerToken,
+			query:        "?user_id=1",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "invalid user_id",
+			token:        ownerToken,
+			query:        "?user_id=abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid Last-Event-ID",
+			token:        ownerToken,
+			lastEventID:  "abc",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := stream(tc.token, tc.query, tc.lastEventID)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.expectedCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"wbtech_l2/18/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getTrashV2 handles GET /v2/trash and returns the deleted events that can
+// still be restored, most recently deleted first.
+func (h *Handler) getTrashV2(ctx *gin.Context) {
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionRead)
+	if !ok {
+		return
+	}
+
+	events, err := h.services.GetDeleted(ctx.Request.Context(), userID, loc)
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "events": events})
+}
+
+// restoreEventV2 handles POST /v2/trash/{id}/restore and returns the
+// restored event.
+func (h *Handler) restoreEventV2(ctx *gin.Context) {
+	eventID, ok := eventIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	loc, ok := requestLocation(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := h.calendarOwner(ctx, model.PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if err := h.services.As(getUserID(ctx)).Restore(ctx.Request.Context(), userID, eventID); err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	event, err := h.services.Get(ctx.Request.Context(), userID, eventID, loc)
+	if err != nil {
+		returnEventErrorV2(ctx, err)
+		return
+	}
+
+	setETag(ctx, event)
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "event": event})
+}
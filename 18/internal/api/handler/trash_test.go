@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrashV2(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	eventID, _ := repos.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	otherEventID, _ := repos.Event.Create(ctx, 12345, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	assert.NoError(t, repos.Event.Delete(ctx, 12345, otherEventID, 0))
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("DELETE", fmt.Sprintf("/v2/events/%d", eventID))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = send("GET", fmt.Sprintf("/v2/events/%d", eventID))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = send("GET", "/v2/events?from=2026-02-06&to=2026-02-07")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response eventResponseV2
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Result.Events)
+
+	rec = send("GET", "/v2/trash")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	response = eventResponseV2{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	if assert.Len(t, response.Result.Events, 1) {
+		assert.Equal(t, eventID, response.Result.Events[0].ID)
+		assert.NotNil(t, response.Result.Events[0].DeletedAt)
+	}
+
+	testCases := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			path:         fmt.Sprintf("/v2/trash/%d/restore", eventID),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid (restoring same event twice)",
+			path:         fmt.Sprintf("/v2/trash/%d/restore", eventID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid (event of another user)",
+			path:         fmt.Sprintf("/v2/trash/%d/restore", otherEventID),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			path:         "/v2/trash/abc/restore",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := send("POST", tc.path)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec = send("GET", fmt.Sprintf("/v2/events/%d", eventID))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+}
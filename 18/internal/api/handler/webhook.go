@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wbtech_l2/18/internal/api/openapi"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getWebhooksV2 handles GET /v2/webhooks. Secrets are not listed.
+func (h *Handler) getWebhooksV2(ctx *gin.Context) {
+	webhooks, err := h.services.GetWebhooks(ctx.Request.Context(), getUserID(ctx))
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	ReturnResultResponse(ctx, gin.H{"status": "ok", "webhooks": webhooks})
+}
+
+// createWebhookV2 handles POST /v2/webhooks and responds with 201 and the
+// webhook including its secret. Every change of the caller's events is then
+// posted to url, signed with the secret. URLs of hosts that don't resolve to
+// public addresses are rejected, so that the server can't be made to post to
+// its own network.
+func (h *Handler) createWebhookV2(ctx *gin.Context) {
+	var webhookCreate model.WebhookCreate
+	if !bindJSON(ctx, &webhookCreate) {
+		return
+	}
+
+	if err := webhook.CheckURL(ctx.Request.Context(), webhookCreate.URL); err != nil {
+		ReturnValidationError(ctx, []openapi.FieldError{{In: "body", Field: "url", Message: "must resolve to public addresses"}})
+		return
+	}
+
+	created, err := h.services.CreateWebhook(ctx.Request.Context(), getUserID(ctx), webhookCreate.URL)
+	if err != nil {
+		ReturnInternalError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v2/webhooks/%d", created.ID))
+	ReturnStatusResponse(ctx, http.StatusCreated, gin.H{"status": "ok", "webhook": created})
+}
+
+// deleteWebhookV2 handles DELETE /v2/webhooks/{id}. Payloads that were not
+// delivered yet are dropped.
+func (h *Handler) deleteWebhookV2(ctx *gin.Context) {
+	webhookID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || webhookID <= 0 {
+		ReturnErrorResponse(ctx, http.StatusBadRequest, "invalid webhook id")
+		return
+	}
+
+	if err = h.services.DeleteWebhook(ctx.Request.Context(), getUserID(ctx), webhookID); err != nil {
+		if errors.Is(err, repository.WebhookNotFoundError) {
+			ReturnErrorResponse(ctx, http.StatusNotFound, err.Error())
+		} else {
+			ReturnInternalError(ctx, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func isValidWebhookURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookResponse struct {
+	Result struct {
+		Webhook  model.Webhook   `json:"webhook"`
+		Webhooks []model.Webhook `json:"webhooks"`
+	} `json:"result"`
+}
+
+func TestWebhooksV2(t *testing.T) {
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+	handlers := NewHandler(services)
+
+	router := handlers.InitRoutes()
+
+	userID := 1
+	token := createAPIKey(t, services, userID)
+	otherToken := createAPIKey(t, services, 12345)
+
+	testCases := []struct {
+		name         string
+		token        string
+		method       string
+		url          string
+		data         any
+		expectedCode int
+	}{
+		{
+			name:         "create",
+			token:        token,
+			method:       "POST",
+			url:          "/v2/webhooks",
+			data:         model.WebhookCreate{URL: "https://93.184.215.14/hook"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid (loopback address)",
+			token:        token,
+			method:       "POST",
+			url:          "/v2/webhooks",
+			data:         model.WebhookCreate{URL: "http://127.0.0.1:8080/hook"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid (metadata service)",
+			token:        token,
+			method:       "POST",
+			url:          "/v2/webhooks",
+			data:         model.WebhookCreate{URL: "http://169.254.169.254/latest/meta-data"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid url",
+			token:        token,
+			method:       "POST",
+			url:          "/v2/webhooks",
+			data:         model.WebhookCreate{URL: "example.com/hook"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid scheme",
+			token:        token,
+			method:       "POST",
+			url:          "/v2/webhooks",
+			data:         model.WebhookCreate{URL: "ftp://example.com/hook"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "delete other user's webhook",
+			token:        otherToken,
+			method:       "DELETE",
+			url:          "/v2/webhooks/1",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			token:        token,
+			method:       "DELETE",
+			url:          "/v2/webhooks/first",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsonData, err := json.Marshal(tc.data)
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.url, bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.name == "create" {
+				var response webhookResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.True(t, strings.HasPrefix(response.Result.Webhook.Secret, "whsec_"))
+				assert.Equal(t, "/v2/webhooks/1", rec.Header().Get("Location"))
+			}
+		})
+	}
+
+	// The secret is shown only once
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v2/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response webhookResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 1, len(response.Result.Webhooks))
+	assert.Equal(t, "https://93.184.215.14/hook", response.Result.Webhooks[0].URL)
+	assert.Empty(t, response.Result.Webhooks[0].Secret)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/v2/webhooks/1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
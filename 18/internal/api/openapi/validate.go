@@ -0,0 +1,285 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes an invalid field of a request. In is "path",
+// "query", "header" or "body"; Field is the name of the parameter or the
+// dotted path of the body field, e.g. "exdates.1".
+type FieldError struct {
+	In      string `json:"in"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.In, e.Field, e.Message)
+}
+
+// Format checks a string of the format. Empty strings are not checked, so
+// that optional fields can be left empty; required ones have a MinLength.
+type Format struct {
+	Check func(string) bool
+	// Message describes the valid values, e.g. "must be a date (YYYY-MM-DD)".
+	Message string
+}
+
+type Validator struct {
+	doc     *Document
+	formats map[string]Format
+}
+
+func NewValidator(doc *Document, formats map[string]Format) *Validator {
+	return &Validator{doc: doc, formats: formats}
+}
+
+// ValidateRequest checks the parameters and the JSON body of the request to
+// the operation. pathParams holds the values of the path parameters by name.
+// The body is read and replaced, so that handlers can read it again.
+func (v *Validator) ValidateRequest(op *Operation, req *http.Request, pathParams map[string]string) ([]FieldError, error) {
+	var errs []FieldError
+
+	query := req.URL.Query()
+	for _, param := range op.Parameters {
+		var (
+			value string
+			given bool
+		)
+		switch param.In {
+		case "path":
+			value, given = pathParams[param.Name]
+		case "query":
+			given = query.Has(param.Name)
+			value = query.Get(param.Name)
+		case "header":
+			value = req.Header.Get(param.Name)
+			given = value != ""
+		}
+
+		if !given || value == "" {
+			if param.Required {
+				errs = append(errs, FieldError{In: param.In, Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		errs = append(errs, v.validateParam(param, value)...)
+	}
+
+	if op.RequestBody == nil {
+		return errs, nil
+	}
+
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return errs, nil
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			errs = append(errs, FieldError{In: "body", Message: "is required"})
+		}
+		return errs, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err = decoder.Decode(&value); err != nil {
+		return append(errs, FieldError{In: "body", Message: "must be valid JSON"}), nil
+	}
+
+	return append(errs, v.ValidateValue(media.Schema, value, "body", "")...), nil
+}
+
+// validateParam checks the raw value of a parameter, which is parsed
+// according to the type of its schema first.
+func (v *Validator) validateParam(param Parameter, raw string) []FieldError {
+	schema := v.doc.Resolve(param.Schema)
+
+	var value any = raw
+	switch schema.Type {
+	case "integer", "number":
+		value = json.Number(raw)
+	case "boolean":
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []FieldError{{In: param.In, Field: param.Name, Message: "must be a boolean"}}
+		}
+		value = parsed
+	}
+
+	return v.ValidateValue(schema, value, param.In, param.Name)
+}
+
+// ValidateValue checks a value decoded from JSON with UseNumber against the
+// schema and returns the errors of all invalid fields.
+func (v *Validator) ValidateValue(schema *Schema, value any, in, field string) []FieldError {
+	schema = v.doc.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+
+	fail := func(message string) []FieldError {
+		return []FieldError{{In: in, Field: field, Message: message}}
+	}
+
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return fail("must not be null")
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return fail("must be an object")
+		}
+		return v.validateObject(schema, object, in, field)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return fail("must be an array")
+		}
+		if schema.MinItems != nil && len(array) < *schema.MinItems {
+			return fail(fmt.Sprintf("must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			return fail(fmt.Sprintf("must have at most %d items", *schema.MaxItems))
+		}
+		var errs []FieldError
+		for i, item := range array {
+			errs = append(errs, v.ValidateValue(schema.Items, item, in, join(field, strconv.Itoa(i)))...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		return v.validateString(schema, s, fail)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return fail("must be a number")
+		}
+		return validateNumber(schema, number, fail)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateObject(schema *Schema, object map[string]any, in, field string) []FieldError {
+	var errs []FieldError
+
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			errs = append(errs, FieldError{In: in, Field: join(field, name), Message: "is required"})
+		}
+	}
+
+	// Sorted, so that the errors come in a stable order
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if value, ok := object[name]; ok {
+			errs = append(errs, v.ValidateValue(schema.Properties[name], value, in, join(field, name))...)
+		}
+	}
+
+	return errs
+}
+
+func (v *Validator) validateString(schema *Schema, s string, fail func(string) []FieldError) []FieldError {
+	if schema.MinLength != nil && len(s) < *schema.MinLength {
+		if *schema.MinLength == 1 {
+			return fail("must not be empty")
+		}
+		return fail(fmt.Sprintf("must be at least %d characters long", *schema.MinLength))
+	}
+
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, s) {
+		return fail("must be one of " + strings.Join(schema.Enum, ", "))
+	}
+
+	if format, ok := v.formats[schema.Format]; ok && s != "" && !format.Check(s) {
+		return fail(format.Message)
+	}
+
+	return nil
+}
+
+func validateNumber(schema *Schema, number json.Number, fail func(string) []FieldError) []FieldError {
+	if schema.Type == "integer" {
+		if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+			return fail("must be an integer")
+		}
+	}
+
+	value, err := number.Float64()
+	if err != nil {
+		return fail("must be a number")
+	}
+
+	if schema.Minimum != nil && value < *schema.Minimum {
+		return fail(fmt.Sprintf("must be at least %s", formatBound(*schema.Minimum)))
+	}
+	if schema.Maximum != nil && value > *schema.Maximum {
+		return fail(fmt.Sprintf("must be at most %s", formatBound(*schema.Maximum)))
+	}
+
+	return nil
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+
+	return field + "." + name
+}
+
+// readBody reads the body of the request and puts it back. Errors of the
+// body, e.g. *http.MaxBytesError, are returned as is.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest(t *testing.T) {
+	doc := &Document{
+		Components: Components{Schemas: map[string]*Schema{
+			"Item": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":  {Type: "string", MinLength: Ptr(1)},
+					"kind":  {Type: "string", Enum: []string{"a", "b"}},
+					"count": {Type: "integer", Minimum: Ptr(1.0)},
+					"tags":  {Type: "array", Items: &Schema{Type: "string", Format: "tag"}, MaxItems: Ptr(3)},
+				},
+				Required: []string{"name"},
+			},
+		}},
+	}
+	op := &Operation{
+		Parameters: []Parameter{
+			{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "integer", Minimum: Ptr(1.0)}},
+			{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Maximum: Ptr(10.0)}},
+			{Name: "q", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+		},
+		RequestBody: &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: Ref("Item")}}},
+	}
+	validator := NewValidator(doc, map[string]Format{
+		"tag": {Check: func(s string) bool { return s[0] == '#' }, Message: "must start with #"},
+	})
+
+	testCases := []struct {
+		name     string
+		url      string
+		id       string
+		body     string
+		expected []FieldError
+	}{
+		{
+			name: "valid",
+			url:  "/items/1?q=x&limit=10",
+			id:   "1",
+			body: `{"name":"x","kind":"a","count":2,"tags":["#a",""]}`,
+		},
+		{
+			name: "invalid parameters",
+			url:  "/items/0?limit=11",
+			id:   "0",
+			body: `{"name":"x"}`,
+			expected: []FieldError{
+				{In: "path", Field: "id", Message: "must be at least 1"},
+				{In: "query", Field: "limit", Message: "must be at most 10"},
+				{In: "query", Field: "q", Message: "is required"},
+			},
+		},
+		{
+			name: "invalid fields",
+			url:  "/items/1?q=x&limit=abc",
+			id:   "1",
+			body: `{"kind":"c","count":1.5,"tags":["#a","b"]}`,
+			expected: []FieldError{
+				{In: "query", Field: "limit", Message: "must be an integer"},
+				{In: "body", Field: "name", Message: "is required"},
+				{In: "body", Field: "count", Message: "must be an integer"},
+				{In: "body", Field: "kind", Message: "must be one of a, b"},
+				{In: "body", Field: "tags.1", Message: "must start with #"},
+			},
+		},
+		{
+			name: "wrong types",
+			url:  "/items/1?q=x",
+			id:   "1",
+			body: `{"name":"","count":"1","tags":"#a"}`,
+			expected: []FieldError{
+				{In: "body", Field: "count", Message: "must be a number"},
+				{In: "body", Field: "name", Message: "must not be empty"},
+				{In: "body", Field: "tags", Message: "must be an array"},
+			},
+		},
+		{
+			name:     "too many items",
+			url:      "/items/1?q=x",
+			id:       "1",
+			body:     `{"name":"x","tags":["#a","#b","#c","#d"]}`,
+			expected: []FieldError{{In: "body", Field: "tags", Message: "must have at most 3 items"}},
+		},
+		{
+			name:     "missing body",
+			url:      "/items/1?q=x",
+			id:       "1",
+			expected: []FieldError{{In: "body", Message: "is required"}},
+		},
+		{
+			name:     "invalid json",
+			url:      "/items/1?q=x",
+			id:       "1",
+			body:     `{"name":`,
+			expected: []FieldError{{In: "body", Message: "must be valid JSON"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", tc.url, bytes.NewBufferString(tc.body))
+
+			errs, err := validator.ValidateRequest(op, req, map[string]string{"id": tc.id})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, errs)
+		})
+	}
+}
@@ -3,41 +3,154 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 	"wbtech_l2/18/internal/api/handler"
 	"wbtech_l2/18/internal/api/server"
+	"wbtech_l2/18/internal/cache"
+	"wbtech_l2/18/internal/metrics"
+	"wbtech_l2/18/internal/migrate"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/ratelimit"
+	"wbtech_l2/18/internal/reminder"
 	"wbtech_l2/18/internal/repository"
 	"wbtech_l2/18/internal/service"
+	"wbtech_l2/18/internal/trash"
+	"wbtech_l2/18/internal/webhook"
+	"wbtech_l2/18/migrations"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
+// defaultShutdownTimeout bounds draining of in-flight requests on shutdown
+// when server.shutdown_timeout is not set.
+const defaultShutdownTimeout = 15 * time.Second
+
 func Run() {
-	logrus.Print("Initializing DB...")
-	db, err := repository.NewPostgresDB(repository.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     os.Getenv("POSTGRES_PORT"),
-		Username: os.Getenv("POSTGRES_USER"),
-		Password: os.Getenv("POSTGRES_PASS"),
-		DBName:   os.Getenv("POSTGRES_DB"),
-		SSLMode:  viper.GetString("db.ssl_mode"),
-	})
-	if err != nil {
-		logrus.Fatalf("Error initializing DB: %s", err.Error())
+	db, repos := initRepository()
+
+	if db != nil && viper.GetBool("db.auto_migrate") {
+		logrus.Print("Applying migrations...")
+		if err := migrateUp(db); err != nil {
+			logrus.Fatalf("Error applying migrations: %s", err.Error())
+		}
 	}
 
 	logrus.Print("Initializing components...")
-	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+
+	registry := metrics.NewRegistry()
+	repos.Event = repository.NewInstrumentedEvent(repos.Event, metrics.NewQueryMetrics(registry))
+	if db != nil {
+		metrics.RegisterDBStats(registry, db.Stats)
+	}
+	if viper.GetBool("cache.enabled") {
+		// Wraps the instrumentation, so that only misses reach the query
+		// metrics
+		cached := repository.NewCachedEvent(repos.Event, repos.Attendee, newCacheStore(), metrics.NewCacheMetrics(registry))
+		repos.Event, repos.Attendee = cached, cached
+	}
+
+	var serviceOpts []service.EventOption
+	if viper.GetBool("calendar.reject_conflicts") {
+		serviceOpts = append(serviceOpts, service.WithRejectConflicts())
+	}
+	services := service.NewService(repos, serviceOpts...)
+
+	handlerOpts := []handler.Option{handler.WithMetrics(registry)}
+	if db != nil {
+		handlerOpts = append(handlerOpts, handler.WithReadinessCheck(db.PingContext))
+	}
+	if firstDay := viper.GetString("calendar.first_day_of_week"); firstDay != "" {
+		weekday, err := model.ParseWeekday(firstDay)
+		if err != nil {
+			logrus.Fatalf("Error reading calendar config: %s", err.Error())
+		}
+		handlerOpts = append(handlerOpts, handler.WithFirstDayOfWeek(weekday))
+	}
+	handlerOpts = append(handlerOpts, handler.WithBodyLimits(viper.GetInt64("server.max_body_bytes"), viper.GetInt64("server.max_import_bytes")))
+	if viper.GetBool("rate_limit.enabled") {
+		handlerOpts = append(handlerOpts, handler.WithRateLimit(handler.RateLimitConfig{
+			Store:   newRateLimitStore(),
+			PerIP:   ratelimit.Limit{Rate: viper.GetFloat64("rate_limit.per_ip.rate"), Burst: viper.GetInt("rate_limit.per_ip.burst")},
+			PerUser: ratelimit.Limit{Rate: viper.GetFloat64("rate_limit.per_user.rate"), Burst: viper.GetInt("rate_limit.per_user.burst")},
+		}))
+	}
+	handlers := handler.NewHandler(services, handlerOpts...)
+
+	router := handlers.InitRoutes()
+	// Without the setting gin trusts X-Forwarded-For from every peer
+	if viper.IsSet("server.trusted_proxies") {
+		if err := router.SetTrustedProxies(viper.GetStringSlice("server.trusted_proxies")); err != nil {
+			logrus.Fatalf("Error reading server config: %s", err.Error())
+		}
+	}
+
+	for _, apiKey := range viper.GetStringSlice("auth.api_keys") {
+		if err := registerAPIKey(context.Background(), services, apiKey); err != nil {
+			logrus.Fatalf("Error registering API key from config: %s", err.Error())
+		}
+	}
+
+	var notifier reminder.Notifier
+	switch kind := viper.GetString("reminder.notifier"); kind {
+	case "log", "":
+		notifier = reminder.NewLogNotifier()
+	case "stdout":
+		notifier = reminder.NewStdoutNotifier()
+	case "webhook":
+		notifier = reminder.NewWebhookNotifier(viper.GetString("reminder.webhook_url"), nil)
+	default:
+		logrus.Fatalf("Unknown reminder notifier: %s", kind)
+	}
+
+	scheduler := reminder.NewScheduler(services.Reminder, notifier, reminder.Config{
+		Workers:      viper.GetInt("reminder.workers"),
+		BatchSize:    viper.GetInt("reminder.batch_size"),
+		PollInterval: viper.GetDuration("reminder.poll_interval"),
+		Lease:        viper.GetDuration("reminder.lease"),
+		SendTimeout:  viper.GetDuration("reminder.send_timeout"),
+		RetryDelay:   viper.GetDuration("reminder.retry_delay"),
+		MaxRetry:     viper.GetDuration("reminder.max_retry"),
+	})
+	scheduler.Start()
+
+	dispatcher := webhook.NewDispatcher(services.Outbox, nil, webhook.Config{
+		Workers:      viper.GetInt("webhook.workers"),
+		BatchSize:    viper.GetInt("webhook.batch_size"),
+		PollInterval: viper.GetDuration("webhook.poll_interval"),
+		Lease:        viper.GetDuration("webhook.lease"),
+		SendTimeout:  viper.GetDuration("webhook.send_timeout"),
+		RetryDelay:   viper.GetDuration("webhook.retry_delay"),
+		MaxRetry:     viper.GetDuration("webhook.max_retry"),
+		MaxAttempts:  viper.GetInt("webhook.max_attempts"),
+	})
+	dispatcher.Start()
+
+	purger := trash.NewPurger(services.Trash, trash.Config{
+		Retention: viper.GetDuration("trash.retention"),
+		Interval:  viper.GetDuration("trash.purge_interval"),
+		BatchSize: viper.GetInt("trash.batch_size"),
+	})
+	purger.Start()
 
 	srv := new(server.Server)
 	go func() {
-		if err = srv.Run(viper.GetString("port"), handlers.InitRoutes()); err != nil && !errors.Is(http.ErrServerClosed, err) {
+		if err := srv.Run(server.Config{
+			Port:              viper.GetString("port"),
+			ReadTimeout:       viper.GetDuration("server.read_timeout"),
+			ReadHeaderTimeout: viper.GetDuration("server.read_header_timeout"),
+			WriteTimeout:      viper.GetDuration("server.write_timeout"),
+			IdleTimeout:       viper.GetDuration("server.idle_timeout"),
+			MaxHeaderBytes:    viper.GetInt("server.max_header_bytes"),
+		}, router); err != nil && !errors.Is(http.ErrServerClosed, err) {
 			logrus.Fatalf("Error occured while running http-server: %s", err.Error())
 		}
 	}()
@@ -50,13 +163,236 @@ func Run() {
 
 	logrus.Print("App is shutting down.")
 
-	if err = srv.Shutdown(context.Background()); err != nil {
-		logrus.Fatalf("Error occured while shutting down server: %s", err.Error())
+	// Readiness goes off first, so that load balancers stop routing requests
+	// here while the server still serves the ones already on their way.
+	handlers.Drain()
+	time.Sleep(viper.GetDuration("server.drain_delay"))
+
+	shutdownTimeout := viper.GetDuration("server.shutdown_timeout")
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-	if err = db.Close(); err != nil {
-		logrus.Fatalf("Error occured while closing DB: %s", err.Error())
+	if err := srv.Shutdown(ctx); err != nil {
+		logrus.Errorf("Error occured while shutting down server: %s", err.Error())
+	}
+
+	scheduler.Stop()
+	dispatcher.Stop()
+	purger.Stop()
+
+	if db != nil {
+		if err := db.Close(); err != nil {
+			logrus.Fatalf("Error occured while closing DB: %s", err.Error())
+		}
 	}
 
 	logrus.Print("App is stopped.")
 }
+
+// RunAPIKey manages API keys of the configured storage:
+//
+//	apikey create <user_id>
+//	apikey revoke <key_id>
+func RunAPIKey(args []string) {
+	if len(args) != 2 {
+		logrus.Fatal("Usage: apikey create <user_id> | apikey revoke <key_id>")
+	}
+
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		logrus.Fatalf("Invalid ID: %s", args[1])
+	}
+
+	db, repos := initRepository()
+	if db != nil {
+		defer db.Close()
+	}
+
+	var serviceOpts []service.EventOption
+	if viper.GetBool("calendar.reject_conflicts") {
+		serviceOpts = append(serviceOpts, service.WithRejectConflicts())
+	}
+	services := service.NewService(repos, serviceOpts...)
+
+	switch args[0] {
+	case "create":
+		keyID, key, err := services.CreateAPIKey(context.Background(), id)
+		if err != nil {
+			logrus.Fatalf("Error creating API key: %s", err.Error())
+		}
+		// The key cannot be recovered later, so it goes to stdout rather than
+		// to the log.
+		fmt.Printf("id: %d\nkey: %s\n", keyID, key)
+	case "revoke":
+		if err = services.RevokeAPIKey(context.Background(), id); err != nil {
+			logrus.Fatalf("Error revoking API key: %s", err.Error())
+		}
+		logrus.Printf("API key %d is revoked.", id)
+	default:
+		logrus.Fatalf("Unknown apikey command: %s", args[0])
+	}
+}
+
+// RunMigrate manages the schema of the postgres storage with the migrations
+// embedded into the binary:
+//
+//	migrate up
+//	migrate down [steps]
+//	migrate status
+func RunMigrate(args []string) {
+	const usage = "Usage: migrate up | migrate down [steps] | migrate status"
+	if len(args) == 0 || len(args) > 2 {
+		logrus.Fatal(usage)
+	}
+
+	db := initDB()
+	defer db.Close()
+
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
+	if err != nil {
+		logrus.Fatalf("Error loading migrations: %s", err.Error())
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			logrus.Printf("Applied migration %d_%s.", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatalf("Error applying migrations: %s", err.Error())
+		}
+		if len(applied) == 0 {
+			logrus.Print("No pending migrations.")
+		}
+	case args[0] == "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				logrus.Fatalf("Invalid number of steps: %s", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(steps)
+		for _, m := range reverted {
+			logrus.Printf("Reverted migration %d_%s.", m.Version, m.Name)
+		}
+		if err != nil {
+			logrus.Fatalf("Error reverting migrations: %s", err.Error())
+		}
+	case args[0] == "status" && len(args) == 1:
+		statuses, err := migrator.Status()
+		if err != nil {
+			logrus.Fatalf("Error reading migration status: %s", err.Error())
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%06d_%s\t%s\n", s.Version, s.Name, state)
+		}
+	default:
+		logrus.Fatal(usage)
+	}
+}
+
+func migrateUp(db *sqlx.DB) error {
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		logrus.Printf("Applied migration %d_%s.", m.Version, m.Name)
+	}
+
+	return err
+}
+
+// newRateLimitStore returns the store of rate limit buckets. Only the
+// in-memory store is built in, so limits apply per instance.
+func newRateLimitStore() ratelimit.Store {
+	switch store := viper.GetString("rate_limit.store"); store {
+	case "memory", "":
+		return ratelimit.NewMemoryStore()
+	default:
+		logrus.Fatalf("Unknown rate limit store: %s", store)
+		return nil
+	}
+}
+
+// newCacheStore returns the store of cached calendar queries. Only the
+// in-memory store is built in, so every instance caches on its own and only
+// sees its own writes: keep cache.ttl short when running more than one.
+func newCacheStore() cache.Store {
+	switch store := viper.GetString("cache.store"); store {
+	case "memory", "":
+		return cache.NewMemoryStore(viper.GetInt("cache.capacity"), viper.GetDuration("cache.ttl"))
+	default:
+		logrus.Fatalf("Unknown cache store: %s", store)
+		return nil
+	}
+}
+
+func initRepository() (*sqlx.DB, *repository.Repository) {
+	var (
+		db    *sqlx.DB
+		repos *repository.Repository
+	)
+
+	switch storage := viper.GetString("storage"); storage {
+	case "memory":
+		logrus.Print("Using in-memory storage...")
+		repos = repository.NewMemoryRepository()
+	case "postgres", "":
+		db = initDB()
+		repos = repository.NewRepository(db, repository.WithQueryTimeout(viper.GetDuration("db.query_timeout")))
+	default:
+		logrus.Fatalf("Unknown storage: %s", storage)
+	}
+
+	return db, repos
+}
+
+func initDB() *sqlx.DB {
+	logrus.Print("Initializing DB...")
+	db, err := repository.NewPostgresDB(repository.Config{
+		Host:     viper.GetString("db.host"),
+		Port:     os.Getenv("POSTGRES_PORT"),
+		Username: os.Getenv("POSTGRES_USER"),
+		Password: os.Getenv("POSTGRES_PASS"),
+		DBName:   os.Getenv("POSTGRES_DB"),
+		SSLMode:  viper.GetString("db.ssl_mode"),
+	})
+	if err != nil {
+		logrus.Fatalf("Error initializing DB: %s", err.Error())
+	}
+
+	return db
+}
+
+// registerAPIKey adds a key given in the config as "<user_id>:<key>". Keys
+// that are already registered are skipped.
+func registerAPIKey(ctx context.Context, services *service.Service, apiKey string) error {
+	stringUserID, key, ok := strings.Cut(apiKey, ":")
+	if !ok || key == "" {
+		return errors.New("expected <user_id>:<key>")
+	}
+
+	userID, err := strconv.Atoi(stringUserID)
+	if err != nil {
+		return fmt.Errorf("invalid user_id %q", stringUserID)
+	}
+
+	if _, err = services.Authenticate(ctx, key); err == nil {
+		return nil
+	}
+
+	_, err = services.RegisterAPIKey(ctx, userID, key)
+	return err
+}
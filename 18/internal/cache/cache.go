@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps values by key, each tagged with the data it was computed
+// from. Invalidate drops every value carrying one of the tags. MemoryStore
+// serves a single instance; instances behind a load balancer share a store
+// in e.g. Redis, keeping the keys of each tag in a set. Values may be
+// dropped at any time, so a miss is never an error.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, tags []string) error
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+const (
+	defaultCapacity = 10000
+	defaultTTL      = time.Minute
+)
+
+type entry struct {
+	key     string
+	value   []byte
+	tags    []string
+	expires time.Time
+}
+
+// MemoryStore keeps at most capacity values in memory for ttl each, evicting
+// the least recently used ones when it is full.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	// lru holds *entry, the most recently used first
+	lru     *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]struct{}
+	now     func() time.Time
+}
+
+// NewMemoryStore keeps 10000 values for a minute unless capacity and ttl are
+// positive.
+func NewMemoryStore(capacity int, ttl time.Duration) *MemoryStore {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	return &MemoryStore{
+		capacity: capacity,
+		ttl:      ttl,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := elem.Value.(*entry)
+	if !s.now().Before(e.expires) {
+		s.remove(elem)
+		return nil, false, nil
+	}
+
+	s.lru.MoveToFront(elem)
+	return e.value, true, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+
+	e := &entry{key: key, value: value, tags: append([]string{}, tags...), expires: s.now().Add(s.ttl)}
+	s.entries[key] = s.lru.PushFront(e)
+	for _, tag := range e.tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Invalidate(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			s.remove(s.entries[key])
+		}
+	}
+
+	return nil
+}
+
+// remove drops the entry of elem from the list and from the index of each
+// of its tags.
+func (s *MemoryStore) remove(elem *list.Element) {
+	e := s.lru.Remove(elem).(*entry)
+	delete(s.entries, e.key)
+
+	for _, tag := range e.tags {
+		delete(s.tags[tag], e.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
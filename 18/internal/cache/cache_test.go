@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2, time.Minute)
+	now := time.Date(2026, 2, 2, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	assert.NoError(t, store.Set(ctx, "a", []byte("1"), []string{"x", "y"}))
+	assert.NoError(t, store.Set(ctx, "b", []byte("2"), []string{"y"}))
+
+	value, ok, err := store.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	// b is the least recently used value
+	assert.NoError(t, store.Set(ctx, "c", []byte("3"), []string{"z"}))
+	_, ok, _ = store.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// Only the values with the tag are dropped
+	assert.NoError(t, store.Invalidate(ctx, "x", "unknown"))
+	_, ok, _ = store.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.NotContains(t, store.tags, "y")
+
+	// Setting a key again replaces its tags
+	assert.NoError(t, store.Set(ctx, "c", []byte("4"), []string{"y"}))
+	assert.NoError(t, store.Invalidate(ctx, "z"))
+	value, ok, _ = store.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("4"), value)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get(ctx, "c")
+	assert.False(t, ok)
+	assert.Empty(t, store.entries)
+	assert.Empty(t, store.tags)
+}
@@ -0,0 +1,459 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	maxLineLength  = 75
+)
+
+// Item is a VEVENT read from a calendar. Date and Time are in the calendar
+// service format ("2006-01-02" and "15:04") on the wall clock of Timezone;
+// Error is set when the VEVENT could not be converted.
+type Item struct {
+	UID          string
+	Description  string
+	Date         string
+	Time         string
+	Timezone     string
+	RRule        string
+	ExDates      []string
+	RemindBefore string
+	Duration     string
+	Error        string
+}
+
+// Encode writes events as a VCALENDAR. Start and exception dates are written
+// with the IANA name of the event's timezone as TZID, or in UTC form for UTC
+// events, so recurring events keep their wall clock time across DST changes.
+func Encode(w io.Writer, events []model.Event, now time.Time) error {
+	writer := &lineWriter{w: bufio.NewWriter(w)}
+
+	writer.line("BEGIN:VCALENDAR")
+	writer.line("VERSION:2.0")
+	writer.line("PRODID:-//wbtech_l2//calendar//EN")
+	writer.line("CALSCALE:GREGORIAN")
+
+	for _, event := range events {
+		loc, err := model.LoadLocation(event.Timezone)
+		if err != nil {
+			return err
+		}
+		start := event.StartsAt.In(loc)
+
+		writer.line("BEGIN:VEVENT")
+		writer.line(fmt.Sprintf("UID:%d@wbtech_l2.calendar", event.ID))
+		writer.line("DTSTAMP:" + now.UTC().Format(dateTimeLayout) + "Z")
+		writer.line("DTSTART" + formatDateTime(start))
+		writer.line("SUMMARY:" + escapeText(event.Description))
+
+		if event.Duration != "" {
+			duration, err := time.ParseDuration(event.Duration)
+			if err != nil {
+				return err
+			}
+			writer.line("DURATION:" + formatDuration(duration))
+		}
+
+		if event.RRule != "" {
+			writer.line("RRULE:" + strings.TrimPrefix(event.RRule, "RRULE:"))
+		}
+
+		for _, exDate := range event.ExDates {
+			parsed, err := time.ParseInLocation("2006-01-02", exDate, loc)
+			if err != nil {
+				return err
+			}
+			occurrence := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+			writer.line("EXDATE" + formatDateTime(occurrence))
+		}
+
+		if event.RemindBefore != "" {
+			remindBefore, err := time.ParseDuration(event.RemindBefore)
+			if err != nil {
+				return err
+			}
+
+			writer.line("BEGIN:VALARM")
+			writer.line("ACTION:DISPLAY")
+			writer.line("DESCRIPTION:" + escapeText(event.Description))
+			writer.line("TRIGGER:-" + formatDuration(remindBefore))
+			writer.line("END:VALARM")
+		}
+
+		writer.line("END:VEVENT")
+	}
+
+	writer.line("END:VCALENDAR")
+
+	if writer.err != nil {
+		return writer.err
+	}
+
+	return writer.w.Flush()
+}
+
+// Decode reads the VEVENTs of a calendar. A VEVENT that cannot be converted
+// is returned with Error set, so the rest of the calendar can still be used.
+// Events keep the timezone of DTSTART given by TZID or in UTC form; floating
+// and all-day events are in loc. The duration is taken from DURATION or
+// DTEND.
+func Decode(r io.Reader, loc *time.Location) ([]Item, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0)
+	var (
+		item    *Item
+		start   time.Time
+		end     *rawDateTime
+		exDates []rawDateTime
+		inAlarm bool
+	)
+
+	for _, line := range lines {
+		name, params, value, err := parseLine(line)
+		if err != nil {
+			if item != nil && item.Error == "" {
+				item.Error = err.Error()
+			}
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			item, start, end, exDates = &Item{}, time.Time{}, nil, nil
+		case name == "END" && value == "VEVENT" && item != nil:
+			if item.Error == "" && item.Date == "" {
+				item.Error = "no DTSTART given"
+			}
+			// Exception dates are dates in the timezone of the event, which
+			// is known only after the whole VEVENT is read.
+			for _, exDate := range exDates {
+				if item.Error != "" {
+					break
+				}
+				parsed, err := parseDateTime(exDate.params, exDate.value, start.Location())
+				if err != nil {
+					item.setError("invalid EXDATE: %s", err)
+					break
+				}
+				item.ExDates = append(item.ExDates, parsed.In(start.Location()).Format("2006-01-02"))
+			}
+			// Floating DTEND is on the wall clock of DTSTART
+			if end != nil && item.Error == "" && item.Duration == "" {
+				parsed, err := parseDateTime(end.params, end.value, start.Location())
+				if err != nil {
+					item.setError("invalid DTEND: %s", err)
+				} else if parsed.After(start) {
+					item.Duration = parsed.Sub(start).String()
+				}
+			}
+			items = append(items, *item)
+			item = nil
+		case item == nil:
+		case name == "BEGIN" && value == "VALARM":
+			inAlarm = true
+		case name == "END" && value == "VALARM":
+			inAlarm = false
+		case inAlarm:
+			if name == "TRIGGER" && item.RemindBefore == "" {
+				remindBefore, err := parseTrigger(params, value)
+				if err != nil {
+					item.setError("invalid TRIGGER: %s", err)
+					continue
+				}
+				item.RemindBefore = remindBefore.String()
+			}
+		case name == "UID":
+			item.UID = value
+		case name == "SUMMARY":
+			item.Description = unescapeText(value)
+		case name == "DESCRIPTION" && item.Description == "":
+			item.Description = unescapeText(value)
+		case name == "DTSTART":
+			start, err = parseDateTime(params, value, loc)
+			if err != nil {
+				item.setError("invalid DTSTART: %s", err)
+				continue
+			}
+			item.Date = start.Format("2006-01-02")
+			item.Time = start.Format("15:04")
+			item.Timezone = start.Location().String()
+		case name == "DURATION":
+			duration, err := parseDuration(value)
+			if err != nil || duration < 0 {
+				item.setError("invalid DURATION: %s", fmt.Errorf("invalid duration %q", value))
+				continue
+			}
+			if duration > 0 {
+				item.Duration = duration.String()
+			}
+		case name == "DTEND":
+			end = &rawDateTime{params: params, value: value}
+		case name == "RRULE":
+			item.RRule = value
+		case name == "EXDATE":
+			for _, exDate := range strings.Split(value, ",") {
+				exDates = append(exDates, rawDateTime{params: params, value: exDate})
+			}
+		}
+	}
+
+	return items, nil
+}
+
+type rawDateTime struct {
+	params map[string]string
+	value  string
+}
+
+func (i *Item) setError(format string, err error) {
+	if i.Error == "" {
+		i.Error = fmt.Sprintf(format, err.Error())
+	}
+}
+
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line folded to 75 octets and terminated with CRLF.
+func (l *lineWriter) line(s string) {
+	if l.err != nil {
+		return
+	}
+
+	// Continuation lines start with a space, which counts towards the limit.
+	for limit := maxLineLength; len(s) > limit; limit = maxLineLength - 1 {
+		cut := limit
+		// Do not split multibyte UTF-8 sequences.
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, l.err = l.w.WriteString(s[:cut] + "\r\n "); l.err != nil {
+			return
+		}
+		s = s[cut:]
+	}
+
+	_, l.err = l.w.WriteString(s + "\r\n")
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseLine splits "NAME;PARAM=VALUE:value" into its parts.
+func parseLine(line string) (string, map[string]string, string, error) {
+	head, value, ok := cutUnquoted(line, ':')
+	if !ok {
+		return "", nil, "", fmt.Errorf("invalid content line %q", line)
+	}
+
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, paramValue, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(paramValue, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value, nil
+}
+
+// cutUnquoted is strings.Cut that ignores sep inside double quotes, which
+// parameter values such as TZID may contain.
+func cutUnquoted(s string, sep byte) (string, string, bool) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			return s[:i], s[i+1:], true
+		}
+	}
+
+	return s, "", false
+}
+
+// formatDateTime returns the parameters and the value of a DATE-TIME property,
+// e.g. ";TZID=Europe/Moscow:20260204T120000" or ":20260204T120000Z".
+func formatDateTime(t time.Time) string {
+	if t.Location() == time.UTC {
+		return ":" + t.Format(dateTimeLayout) + "Z"
+	}
+
+	return ";TZID=" + t.Location().String() + ":" + t.Format(dateTimeLayout)
+}
+
+// parseDateTime returns the time in the timezone given by TZID, in UTC for
+// the UTC form and in loc for floating times and dates.
+func parseDateTime(params map[string]string, value string, loc *time.Location) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, value, loc)
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayout+"Z", value)
+	}
+
+	if tzid, ok := params["TZID"]; ok {
+		zone, err := model.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.ParseInLocation(dateTimeLayout, value, zone)
+	}
+
+	return time.ParseInLocation(dateTimeLayout, value, loc)
+}
+
+// parseTrigger accepts the relative TRIGGER form, e.g. "-PT15M". Only
+// triggers before the start of the event are supported.
+func parseTrigger(params map[string]string, value string) (time.Duration, error) {
+	if params["VALUE"] == "DATE-TIME" || params["RELATED"] == "END" {
+		return 0, fmt.Errorf("only triggers relative to the start are supported")
+	}
+
+	offset, err := parseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if offset > 0 {
+		return 0, fmt.Errorf("triggers after the start are not supported")
+	}
+
+	return -offset, nil
+}
+
+// parseDuration parses the RFC 5545 DURATION value, e.g. "-P1DT2H30M".
+func parseDuration(value string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign, value = -1, value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	value = value[1:]
+
+	var (
+		duration time.Duration
+		number   string
+		inTime   bool
+	)
+
+	units := map[bool]map[byte]time.Duration{
+		false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+		true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T' && !inTime && number == "":
+			inTime = true
+		default:
+			unit, ok := units[inTime][c]
+			if !ok || number == "" {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, err
+			}
+			duration += time.Duration(n) * unit
+			number = ""
+		}
+	}
+
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * duration, nil
+}
+
+// formatDuration writes d as an RFC 5545 DURATION without sign.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	b.WriteString("P")
+
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+
+	if d > 0 {
+		b.WriteString("T")
+		if hours := d / time.Hour; hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+			d -= hours * time.Hour
+		}
+		if minutes := d / time.Minute; minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+			d -= minutes * time.Minute
+		}
+		if seconds := d / time.Second; seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+
+	return b.String()
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
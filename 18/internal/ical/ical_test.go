@@ -0,0 +1,234 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	events := []model.Event{
+		{
+			ID:          1,
+			Description: "dentist; bring the card, please",
+			Timezone:    "UTC",
+			StartsAt:    time.Date(2026, 2, 4, 14, 55, 0, 0, time.UTC),
+			Duration:    "45m0s",
+		},
+		{
+			ID:           2,
+			Description:  strings.Repeat("long description ", 10),
+			Timezone:     "America/New_York",
+			StartsAt:     time.Date(2026, 2, 2, 10, 0, 0, 0, newYork),
+			RRule:        "FREQ=WEEKLY;BYDAY=MO",
+			ExDates:      []string{"2026-02-09"},
+			RemindBefore: "1h30m0s",
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Encode(&buf, events, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+	}
+	assert.Contains(t, buf.String(), "SUMMARY:dentist\\; bring the card\\, please\r\n")
+	assert.Contains(t, buf.String(), "DTSTART:20260204T145500Z\r\n")
+	assert.Contains(t, buf.String(), "DTSTART;TZID=America/New_York:20260202T100000\r\n")
+	assert.Contains(t, buf.String(), "EXDATE;TZID=America/New_York:20260209T100000\r\n")
+	assert.Contains(t, buf.String(), "TRIGGER:-PT1H30M\r\n")
+	assert.Contains(t, buf.String(), "DURATION:PT45M\r\n")
+
+	items, err := Decode(&buf, time.FixedZone("MSK", 3*60*60))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(items))
+
+	assert.Equal(t, Item{
+		UID:         "1@wbtech_l2.calendar",
+		Description: "dentist; bring the card, please",
+		Date:        "2026-02-04",
+		Time:        "14:55",
+		Timezone:    "UTC",
+		Duration:    "45m0s",
+	}, items[0])
+
+	assert.Equal(t, Item{
+		UID:          "2@wbtech_l2.calendar",
+		Description:  events[1].Description,
+		Date:         "2026-02-02",
+		Time:         "10:00",
+		Timezone:     "America/New_York",
+		RRule:        "FREQ=WEEKLY;BYDAY=MO",
+		ExDates:      []string{"2026-02-09"},
+		RemindBefore: "1h30m0s",
+	}, items[1])
+}
+
+func TestDecode(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:utc",
+		"DTSTART:20260204T120000Z",
+		"SUMMARY:in UTC",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:tzid",
+		`DTSTART;TZID="Asia/Tokyo":20260204T120000`,
+		"DESCRIPTION:in Tokyo",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:exdate-in-utc",
+		"DTSTART;TZID=America/New_York:20260302T090000",
+		"RRULE:FREQ=WEEKLY",
+		"EXDATE:20260310T010000Z",
+		"SUMMARY:stand-up",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"DTSTART;VALUE=DATE:20260205",
+		"SUMMARY:all day",
+		"BEGIN:VALARM",
+		"TRIGGER:-P1D",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:folded",
+		"DTSTART:20260206T090000",
+		"SUMMARY:folded",
+		"  summary",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:no-start",
+		"SUMMARY:no start",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad-start",
+		"DTSTART:tomorrow",
+		"SUMMARY:bad start",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad-trigger",
+		"DTSTART:20260206T090000",
+		"BEGIN:VALARM",
+		"TRIGGER:PT15M",
+		"END:VALARM",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	items, err := Decode(strings.NewReader(calendar), moscow)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(items))
+
+	assert.Equal(t, "2026-02-04", items[0].Date)
+	assert.Equal(t, "12:00", items[0].Time)
+	assert.Equal(t, "UTC", items[0].Timezone)
+	assert.Equal(t, "in UTC", items[0].Description)
+
+	assert.Equal(t, "12:00", items[1].Time)
+	assert.Equal(t, "Asia/Tokyo", items[1].Timezone)
+	assert.Equal(t, "in Tokyo", items[1].Description)
+
+	// 01:00 UTC is the previous evening in New York
+	assert.Equal(t, "America/New_York", items[2].Timezone)
+	assert.Equal(t, []string{"2026-03-09"}, items[2].ExDates)
+
+	assert.Equal(t, "2026-02-05", items[3].Date)
+	assert.Equal(t, "00:00", items[3].Time)
+	assert.Equal(t, "Europe/Moscow", items[3].Timezone)
+	assert.Equal(t, "24h0m0s", items[3].RemindBefore)
+
+	assert.Equal(t, "folded summary", items[4].Description)
+	assert.Empty(t, items[4].Error)
+
+	assert.Equal(t, "no DTSTART given", items[5].Error)
+	assert.Contains(t, items[6].Error, "invalid DTSTART")
+	assert.Contains(t, items[7].Error, "invalid TRIGGER")
+}
+
+func TestDecodeEventDuration(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:duration",
+		"DTSTART:20260204T120000Z",
+		"DURATION:PT1H30M",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:end-before-start",
+		"DTEND;TZID=Europe/Moscow:20260204T150000",
+		"DTSTART:20260204T110000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:floating-end",
+		"DTSTART;TZID=Asia/Tokyo:20260204T120000",
+		"DTEND:20260204T124500",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad-duration",
+		"DTSTART:20260204T120000Z",
+		"DURATION:-PT1H",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:bad-end",
+		"DTSTART:20260204T120000Z",
+		"DTEND:never",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	items, err := Decode(strings.NewReader(calendar), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(items))
+
+	assert.Equal(t, "1h30m0s", items[0].Duration)
+	assert.Equal(t, "1h0m0s", items[1].Duration)
+	assert.Equal(t, "45m0s", items[2].Duration)
+	assert.Contains(t, items[3].Error, "invalid DURATION")
+	assert.Contains(t, items[4].Error, "invalid DTEND")
+}
+
+func TestDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		duration time.Duration
+		isValid  bool
+	}{
+		{value: "PT15M", duration: 15 * time.Minute, isValid: true},
+		{value: "-PT1H30M", duration: -90 * time.Minute, isValid: true},
+		{value: "P1DT12H", duration: 36 * time.Hour, isValid: true},
+		{value: "+P2W", duration: 14 * 24 * time.Hour, isValid: true},
+		{value: "PT", isValid: false},
+		{value: "15M", isValid: false},
+		{value: "P1H", isValid: false},
+		{value: "PT15", isValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			duration, err := parseDuration(tc.value)
+			if !tc.isValid {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.duration, duration)
+		})
+	}
+
+	assert.Equal(t, "PT0S", formatDuration(0))
+	assert.Equal(t, "P1DT2H3M4S", formatDuration(26*time.Hour+3*time.Minute+4*time.Second))
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+// HTTPMetrics records the latency of requests per route.
+type HTTPMetrics struct {
+	duration *Histogram
+}
+
+func NewHTTPMetrics(r *Registry) *HTTPMetrics {
+	return &HTTPMetrics{
+		duration: NewHistogram(r, "http_request_duration_seconds", "Latency of HTTP requests.", DefaultBuckets, "method", "route", "status"),
+	}
+}
+
+// ObserveRequest records a request. route is the route pattern, not the
+// path, so that every event ID doesn't make a series of its own.
+func (m *HTTPMetrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.duration.Observe(duration.Seconds(), method, route, strconv.Itoa(status))
+}
+
+// QueryMetrics records the latency of repository calls.
+type QueryMetrics struct {
+	duration *Histogram
+}
+
+func NewQueryMetrics(r *Registry) *QueryMetrics {
+	return &QueryMetrics{
+		duration: NewHistogram(r, "repository_query_duration_seconds", "Latency of repository calls.", DefaultBuckets, "method", "result"),
+	}
+}
+
+func (m *QueryMetrics) ObserveQuery(method string, duration time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	m.duration.Observe(duration.Seconds(), method, result)
+}
+
+// RegisterDBStats exposes the connection pool statistics of sql.DB, e.g.
+// RegisterDBStats(r, db.Stats).
+func RegisterDBStats(r *Registry, stats func() sql.DBStats) {
+	NewGaugeFunc(r, "db_max_open_connections", "Maximum number of open connections to the database.", func() float64 {
+		return float64(stats().MaxOpenConnections)
+	})
+	NewGaugeFunc(r, "db_open_connections", "Number of established connections, both in use and idle.", func() float64 {
+		return float64(stats().OpenConnections)
+	})
+	NewGaugeFunc(r, "db_in_use_connections", "Number of connections currently in use.", func() float64 {
+		return float64(stats().InUse)
+	})
+	NewGaugeFunc(r, "db_idle_connections", "Number of idle connections.", func() float64 {
+		return float64(stats().Idle)
+	})
+	NewCounterFunc(r, "db_wait_count_total", "Total number of connections waited for.", func() float64 {
+		return float64(stats().WaitCount)
+	})
+	NewCounterFunc(r, "db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", func() float64 {
+		return stats().WaitDuration.Seconds()
+	})
+	NewCounterFunc(r, "db_max_idle_closed_total", "Total number of connections closed due to the idle limit.", func() float64 {
+		return float64(stats().MaxIdleClosed)
+	})
+	NewCounterFunc(r, "db_max_lifetime_closed_total", "Total number of connections closed due to the lifetime limit.", func() float64 {
+		return float64(stats().MaxLifetimeClosed)
+	})
+}
+
+// CacheMetrics counts the requests to a cache by method and result: hit,
+// miss or error.
+type CacheMetrics struct {
+	requests *Counter
+}
+
+func NewCacheMetrics(r *Registry) *CacheMetrics {
+	return &CacheMetrics{
+		requests: NewCounter(r, "cache_requests_total", "Requests to the cache of calendar queries.", "method", "result"),
+	}
+}
+
+func (m *CacheMetrics) ObserveCache(method, result string) {
+	m.requests.Inc(method, result)
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Counter counts events, one series per combination of label values.
+type Counter struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter registers a counter with the given label names.
+func NewCounter(r *Registry, name, help string, labels ...string) *Counter {
+	c := &Counter{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		series:     make(map[string]*counterSeries),
+	}
+
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the series of labelValues, given in the order of the label
+// names.
+func (c *Counter) Inc(labelValues ...string) {
+	if len(labelValues) != len(c.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", c.metricName, len(c.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	series, ok := c.series[key]
+	if !ok {
+		series = &counterSeries{labelValues: append([]string{}, labelValues...)}
+		c.series[key] = series
+	}
+
+	series.value++
+}
+
+func (c *Counter) name() string {
+	return c.metricName
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	writeHeader(w, c.metricName, c.help, "counter")
+
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, formatLabels(c.labels, series.labelValues), formatFloat(series.value))
+	}
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// valueFunc is a gauge or counter whose value is read on every scrape.
+type valueFunc struct {
+	metricName string
+	help       string
+	metricType string
+	value      func() float64
+}
+
+// NewGaugeFunc registers a gauge reading its value from value.
+func NewGaugeFunc(r *Registry, name, help string, value func() float64) {
+	r.register(&valueFunc{metricName: name, help: help, metricType: "gauge", value: value})
+}
+
+// NewCounterFunc registers a counter reading its value from value, which
+// must never decrease.
+func NewCounterFunc(r *Registry, name, help string, value func() float64) {
+	r.register(&valueFunc{metricName: name, help: help, metricType: "counter", value: value})
+}
+
+func (f *valueFunc) name() string {
+	return f.metricName
+}
+
+func (f *valueFunc) write(w *bufio.Writer) {
+	writeHeader(w, f.metricName, f.help, f.metricType)
+	fmt.Fprintf(w, "%s %s\n", f.metricName, formatFloat(f.value()))
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds in seconds used for latencies.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations in cumulative buckets, one series per
+// combination of label values.
+type Histogram struct {
+	metricName string
+	help       string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+// NewHistogram registers a histogram with the given label names.
+func NewHistogram(r *Registry, name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		metricName: name,
+		help:       help,
+		labels:     labels,
+		buckets:    append([]float64{}, buckets...),
+		series:     make(map[string]*histogramSeries),
+	}
+	sort.Float64s(h.buckets)
+
+	r.register(h)
+	return h
+}
+
+// Observe adds value to the series of labelValues, given in the order of
+// the label names.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", h.metricName, len(h.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{
+			labelValues: append([]string{}, labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = series
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *Histogram) name() string {
+	return h.metricName
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.metricName, h.help, "histogram")
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+
+	for _, key := range keys {
+		series := h.series[key]
+
+		// The last bucket is +Inf and holds every observation
+		for i := 0; i <= len(h.buckets); i++ {
+			count, le := series.count, "+Inf"
+			if i < len(h.buckets) {
+				count, le = series.counts[i], formatFloat(h.buckets[i])
+			}
+
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, formatLabels(bucketLabels, append(append([]string{}, series.labelValues...), le)), count)
+		}
+
+		labels := formatLabels(h.labels, series.labelValues)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, labels, formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, labels, series.count)
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	registry := NewRegistry()
+	histogram := NewHistogram(registry, "request_seconds", "Latency.", []float64{1, 0.1}, "route")
+
+	histogram.Observe(0.05, "/events")
+	histogram.Observe(0.5, "/events")
+	histogram.Observe(5, "/events")
+	histogram.Observe(0.1, `/a"b`)
+
+	var buf bytes.Buffer
+	_, err := registry.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP request_seconds Latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/a\"b",le="0.1"} 1
+request_seconds_bucket{route="/a\"b",le="1"} 1
+request_seconds_bucket{route="/a\"b",le="+Inf"} 1
+request_seconds_sum{route="/a\"b"} 0.1
+request_seconds_count{route="/a\"b"} 1
+request_seconds_bucket{route="/events",le="0.1"} 1
+request_seconds_bucket{route="/events",le="1"} 2
+request_seconds_bucket{route="/events",le="+Inf"} 3
+request_seconds_sum{route="/events"} 5.55
+request_seconds_count{route="/events"} 3
+`, buf.String())
+
+	assert.Panics(t, func() { histogram.Observe(1) })
+	assert.Panics(t, func() { NewHistogram(registry, "request_seconds", "Again.", DefaultBuckets) })
+}
+
+func TestCounter(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounter(registry, "lookups_total", "Lookups.", "method", "result")
+
+	counter.Inc("GetEventsForDay", "miss")
+	counter.Inc("GetEventsForDay", "hit")
+	counter.Inc("GetEventsForDay", "hit")
+
+	var buf bytes.Buffer
+	_, err := registry.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP lookups_total Lookups.
+# TYPE lookups_total counter
+lookups_total{method="GetEventsForDay",result="hit"} 2
+lookups_total{method="GetEventsForDay",result="miss"} 1
+`, buf.String())
+
+	assert.Panics(t, func() { counter.Inc("GetEventsForDay") })
+}
+
+func TestHandler(t *testing.T) {
+	registry := NewRegistry()
+	NewQueryMetrics(registry).ObserveQuery("GetByID", 20*time.Millisecond, errors.New("not found"))
+	RegisterDBStats(registry, func() sql.DBStats {
+		return sql.DBStats{OpenConnections: 3, InUse: 1, Idle: 2, WaitDuration: 1500 * time.Millisecond}
+	})
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE db_open_connections gauge\ndb_open_connections 3\n")
+	assert.Contains(t, body, "# TYPE db_wait_duration_seconds_total counter\ndb_wait_duration_seconds_total 1.5\n")
+	assert.Contains(t, body, `repository_query_duration_seconds_bucket{method="GetByID",result="error",le="0.025"} 1`)
+	assert.Contains(t, body, `repository_query_duration_seconds_count{method="GetByID",result="error"} 1`)
+
+	// Families are ordered by name
+	assert.Less(t, bytes.Index(rec.Body.Bytes(), []byte("db_idle_connections")), bytes.Index(rec.Body.Bytes(), []byte("db_open_connections")))
+}
@@ -0,0 +1,266 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var DirtyError = errors.New("database is dirty: a migration failed half-way and has to be fixed manually")
+
+// versionTable is the table of golang-migrate, so that the runner continues
+// from the version of databases migrated with the migrate/migrate container.
+// It holds a single row with the current version.
+const versionTable = "schema_migrations"
+
+// lockID is the key of the advisory lock that serializes runners, e.g. of
+// several app instances starting at once.
+const lockID = 7205183012
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Load reads migrations named <version>_<name>.up.sql and
+// <version>_<name>.down.sql from the root of fsys, ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %d_%s and %d_%s share a version", version, m.Name, version, match[2])
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies migrations to a Postgres database. Every migration runs
+// in its own transaction together with the version update, so a failed
+// migration leaves the database at the previous version.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sqlx.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(func(conn *sqlx.Conn, version int64) error {
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			if err := m.apply(conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns them, latest
+// first.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.locked(func(conn *sqlx.Conn, version int64) error {
+		i := len(m.migrations) - 1
+		for i >= 0 && m.migrations[i].Version > version {
+			i--
+		}
+		if version > 0 && (i < 0 || m.migrations[i].Version != version) {
+			return fmt.Errorf("database is at unknown version %d", version)
+		}
+
+		for ; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := m.apply(conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists all known migrations and whether they are applied.
+func (m *Migrator) Status() ([]Status, error) {
+	statuses := make([]Status, 0, len(m.migrations))
+
+	err := m.locked(func(conn *sqlx.Conn, version int64) error {
+		for _, migration := range m.migrations {
+			statuses = append(statuses, Status{
+				Version: migration.Version,
+				Name:    migration.Name,
+				Applied: migration.Version <= version,
+			})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// locked runs fn on a connection holding the advisory lock, with the current
+// version of the database.
+func (m *Migrator) locked(fn func(conn *sqlx.Conn, version int64) error) error {
+	ctx := context.Background()
+
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)", versionTable)
+	if _, err = conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	var versions []struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	query = fmt.Sprintf("SELECT version, dirty FROM %s", versionTable)
+	if err = conn.SelectContext(ctx, &versions, query); err != nil {
+		return err
+	}
+
+	var version int64
+	if len(versions) > 0 {
+		if versions[0].Dirty {
+			return DirtyError
+		}
+		version = versions[0].Version
+	}
+
+	return fn(conn, version)
+}
+
+// apply runs script and sets the version of the database in one transaction.
+func (m *Migrator) apply(conn *sqlx.Conn, script string, version int64) error {
+	ctx := context.Background()
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return rollback(tx, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("TRUNCATE %s", versionTable)); err != nil {
+		return rollback(tx, err)
+	}
+
+	// golang-migrate keeps no row once everything is reverted
+	if version > 0 {
+		query := fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES ($1, false)", versionTable)
+		if _, err = tx.ExecContext(ctx, query, version); err != nil {
+			return rollback(tx, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func rollback(tx *sqlx.Tx, err error) error {
+	if txErr := tx.Rollback(); txErr != nil {
+		return txErr
+	}
+
+	return err
+}
@@ -0,0 +1,54 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+	"wbtech_l2/18/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000002_second.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN b INT;")},
+		"000002_second.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN b;")},
+		"000001_init.up.sql":     {Data: []byte("CREATE TABLE t (a INT);")},
+		"000001_init.down.sql":   {Data: []byte("DROP TABLE t;")},
+		"create_db.sql":          {Data: []byte("CREATE DATABASE db;")},
+		"README.md":              {Data: []byte("ignored")},
+	}
+
+	loaded, err := Load(fsys)
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE t (a INT);", Down: "DROP TABLE t;"},
+		{Version: 2, Name: "second", Up: "ALTER TABLE t ADD COLUMN b INT;", Down: "ALTER TABLE t DROP COLUMN b;"},
+	}, loaded)
+
+	// Missing up script
+	_, err = Load(fstest.MapFS{"000001_init.down.sql": {Data: []byte("DROP TABLE t;")}})
+	assert.Error(t, err)
+
+	// Two migrations with one version
+	_, err = Load(fstest.MapFS{
+		"000001_init.up.sql":  {Data: []byte("CREATE TABLE t (a INT);")},
+		"000001_other.up.sql": {Data: []byte("CREATE TABLE u (a INT);")},
+	})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{"000000_zero.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	assert.NoError(t, err)
+	if !assert.NotEmpty(t, loaded) {
+		return
+	}
+
+	for i, m := range loaded {
+		assert.Equal(t, int64(i+1), m.Version, "versions are consecutive")
+		assert.NotEmpty(t, m.Down, "%d_%s has a down script", m.Version, m.Name)
+	}
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions of audit records.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	// AuditPurge is recorded when an event is removed from the trash for
+	// good, as made by the owner.
+	AuditPurge = "purge"
+)
+
+// AuditRecord is a change of an event made by ActorID, which differs from
+// OwnerID for changes in shared calendars. Before and After are the event as
+// JSON and null for events that didn't exist before or after the change.
+type AuditRecord struct {
+	ID        int             `json:"id"`
+	EventID   int             `json:"event_id"`
+	OwnerID   int             `json:"owner_id"`
+	ActorID   int             `json:"actor_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditRange selects audit records with IDs below Before (all if zero), the
+// latest first; Limit 0 means no limit.
+type AuditRange struct {
+	Before int
+	Limit  int
+}
+
+// AuditPage is a page of history. NextCursor is empty on the last page.
+type AuditPage struct {
+	Records    []AuditRecord `json:"records"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// NewAuditRecord encodes the states of the event around the change. before
+// and after are nil for events that didn't exist.
+func NewAuditRecord(action string, ownerID, actorID, eventID int, before, after *Event) (AuditRecord, error) {
+	record := AuditRecord{EventID: eventID, OwnerID: ownerID, ActorID: actorID, Action: action}
+
+	var err error
+	if record.Before, err = json.Marshal(before); err != nil {
+		return AuditRecord{}, err
+	}
+	if record.After, err = json.Marshal(after); err != nil {
+		return AuditRecord{}, err
+	}
+
+	return record, nil
+}
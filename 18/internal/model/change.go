@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Types of event changes.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// EventChange tells the subscribers of UserID that the event changed. IDs
+// grow with every change published, so that subscribers can resume after the
+// last change they got.
+type EventChange struct {
+	ID         int       `json:"id"`
+	Type       string    `json:"type"`
+	UserID     int       `json:"user_id"`
+	EventID    int       `json:"event_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -1,29 +1,136 @@
 package model
 
-import "time"
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
 
+	"github.com/lib/pq"
+)
+
+// Event starts at StartsAt. Date and Time are the same instant on the wall
+// clock of some time zone: the event's own Timezone when the event comes from
+// the repository and the caller's one after Render.
 type Event struct {
-	ID          int    `json:"id" db:"id"`
-	Description string `json:"description" db:"description"`
-	Date        string `json:"date" db:"date"`
-	Time        string `json:"time" db:"time"`
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"-" db:"user_id"`
+	Description string    `json:"description" db:"description"`
+	Date        string    `json:"date" db:"date"`
+	Time        string    `json:"time" db:"time"`
+	Timezone    string    `json:"timezone,omitempty" db:"timezone"`
+	StartsAt    time.Time `json:"starts_at" db:"starts_at"`
+	RRule       string    `json:"rrule,omitempty" db:"rrule"`
+	ExDates     []string  `json:"exdates,omitempty" db:"exdates"`
+	// OccurrenceDate is set on occurrences of recurring events. It is the
+	// date of the occurrence in the event's Timezone.
+	OccurrenceDate string `json:"occurrence_date,omitempty" db:"-"`
+	// RemindBefore is a duration such as "15m" or "1h30m"; empty means the
+	// event has no reminder.
+	RemindBefore string `json:"remind_before,omitempty" db:"remind_before"`
+	// Duration is a duration such as "30m" or "1h30m"; empty means the event
+	// is a point in time that never makes the user busy.
+	Duration string `json:"duration,omitempty" db:"duration"`
+	// EndsAt is set by Render on events with a Duration.
+	EndsAt *time.Time `json:"ends_at,omitempty" db:"-"`
+	// RSVP is the answer of the user the event was read for when the user
+	// is invited to the event, see RSVP states.
+	RSVP string `json:"rsvp,omitempty" db:"rsvp"`
+	// OrganizerID is set on events of other users the event was read for.
+	OrganizerID int `json:"organizer_id,omitempty" db:"-"`
+	// Version grows with every change of the event. On updates a non-zero
+	// Version is the version the change is based on.
+	Version int `json:"version" db:"version"`
+	// DeletedAt is set on events in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"-"`
+}
+
+// Render sets Date and Time to the wall clock of StartsAt in loc.
+func (e *Event) Render(loc *time.Location) {
+	local := e.StartsAt.In(loc)
+	e.Date = local.Format("2006-01-02")
+	e.Time = local.Format("15:04:05")
+
+	e.EndsAt = nil
+	if end := e.End(); end.After(e.StartsAt) {
+		e.EndsAt = &end
+	}
+}
+
+// End returns StartsAt moved by Duration, which is StartsAt itself for
+// events without a valid duration.
+func (e Event) End() time.Time {
+	duration, err := time.ParseDuration(e.Duration)
+	if err != nil || duration < 0 {
+		return e.StartsAt
+	}
+
+	return e.StartsAt.Add(duration)
 }
 
 type EventFromDB struct {
-	ID          int       `json:"id" db:"id"`
-	Description string    `json:"description" db:"description"`
-	Date        time.Time `json:"date" db:"date"`
-	Time        time.Time `json:"time" db:"time"`
+	ID           int            `json:"id" db:"id"`
+	UserID       int            `json:"user_id" db:"user_id"`
+	Description  string         `json:"description" db:"description"`
+	StartsAt     time.Time      `json:"starts_at" db:"starts_at"`
+	Timezone     string         `json:"timezone" db:"timezone"`
+	RRule        string         `json:"rrule" db:"rrule"`
+	ExDates      pq.StringArray `json:"exdates" db:"exdates"`
+	RemindBefore sql.NullInt64  `json:"remind_before" db:"remind_before"`
+	Duration     sql.NullInt64  `json:"duration" db:"duration"`
+	RSVP         string         `json:"rsvp" db:"rsvp"`
+	Version      int            `json:"version" db:"version"`
+	DeletedAt    sql.NullTime   `json:"deleted_at" db:"deleted_at"`
 }
 
 type EventCreate struct {
-	UserID      int    `json:"user_id" db:"user_id"`
-	Description string `json:"description" db:"description"`
-	Date        string `json:"date" db:"date"`
-	Time        string `json:"time" db:"time"`
+	Description  string   `json:"description" db:"description"`
+	Date         string   `json:"date" db:"date"`
+	Time         string   `json:"time" db:"time"`
+	Timezone     string   `json:"timezone,omitempty" db:"timezone"`
+	RRule        string   `json:"rrule,omitempty" db:"rrule"`
+	ExDates      []string `json:"exdates,omitempty" db:"exdates"`
+	RemindBefore string   `json:"remind_before,omitempty" db:"remind_before"`
+	Duration     string   `json:"duration,omitempty" db:"duration"`
+}
+
+// EventUpdate changes the whole event (or series) when OccurrenceDate is
+// empty and only the occurrence on OccurrenceDate otherwise.
+type EventUpdate struct {
+	ID             int      `json:"id" db:"id"`
+	Description    string   `json:"description" db:"description"`
+	Date           string   `json:"date" db:"date"`
+	Time           string   `json:"time" db:"time"`
+	Timezone       string   `json:"timezone,omitempty" db:"timezone"`
+	RRule          string   `json:"rrule,omitempty" db:"rrule"`
+	ExDates        []string `json:"exdates,omitempty" db:"exdates"`
+	RemindBefore   string   `json:"remind_before,omitempty" db:"remind_before"`
+	Duration       string   `json:"duration,omitempty" db:"duration"`
+	OccurrenceDate string   `json:"occurrence_date,omitempty"`
 }
 
 type EventDelete struct {
-	ID     int `json:"id" db:"id"`
-	UserID int `json:"user_id" db:"user_id"`
+	ID             int    `json:"id" db:"id"`
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+}
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// EventBatch applies Operations in order. Atomic batches are applied all or
+// nothing; otherwise every operation is applied on its own.
+type EventBatch struct {
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation holds the EventCreate, the EventUpdate or the EventDelete
+// of Op in Event. Version is the version of the event the update or the
+// deletion is based on, as in If-Match.
+type BatchOperation struct {
+	Op      string          `json:"op"`
+	Version int             `json:"version,omitempty"`
+	Event   json.RawMessage `json:"event"`
 }
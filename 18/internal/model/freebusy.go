@@ -0,0 +1,82 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is the time between Start (inclusive) and End (exclusive).
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// FreeBusy splits a range into the intervals taken by events and the free
+// ones between them.
+type FreeBusy struct {
+	Busy []Interval `json:"busy"`
+	Free []Interval `json:"free"`
+}
+
+// NewFreeBusy clips busy intervals to [from, to), merges the ones that
+// overlap or touch and returns them together with the gaps between them.
+func NewFreeBusy(busy []Interval, from, to time.Time) FreeBusy {
+	clipped := make([]Interval, 0, len(busy))
+	for _, interval := range busy {
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.End.After(to) {
+			interval.End = to
+		}
+		if interval.Start.Before(interval.End) {
+			clipped = append(clipped, interval)
+		}
+	}
+
+	sort.Slice(clipped, func(i, j int) bool {
+		return clipped[i].Start.Before(clipped[j].Start)
+	})
+
+	result := FreeBusy{Busy: make([]Interval, 0), Free: make([]Interval, 0)}
+	for _, interval := range clipped {
+		last := len(result.Busy) - 1
+		if last >= 0 && !interval.Start.After(result.Busy[last].End) {
+			if interval.End.After(result.Busy[last].End) {
+				result.Busy[last].End = interval.End
+			}
+			continue
+		}
+		result.Busy = append(result.Busy, interval)
+	}
+
+	free := from
+	for _, interval := range result.Busy {
+		if free.Before(interval.Start) {
+			result.Free = append(result.Free, Interval{Start: free, End: interval.Start})
+		}
+		free = interval.End
+	}
+	if free.Before(to) {
+		result.Free = append(result.Free, Interval{Start: free, End: to})
+	}
+
+	return result
+}
+
+// In returns the intervals with their bounds in loc.
+func (fb FreeBusy) In(loc *time.Location) FreeBusy {
+	in := func(intervals []Interval) []Interval {
+		result := make([]Interval, 0, len(intervals))
+		for _, interval := range intervals {
+			result = append(result, Interval{Start: interval.Start.In(loc), End: interval.End.In(loc)})
+		}
+		return result
+	}
+
+	return FreeBusy{Busy: in(fb.Busy), Free: in(fb.Free)}
+}
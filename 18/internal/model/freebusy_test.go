@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFreeBusy(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 2, 4, hour, minute, 0, 0, time.UTC)
+	}
+
+	freeBusy := NewFreeBusy([]Interval{
+		{Start: at(11, 0), End: at(12, 0)},
+		{Start: at(7, 0), End: at(9, 30)},
+		// Overlapping and touching intervals are merged
+		{Start: at(11, 30), End: at(12, 30)},
+		{Start: at(12, 30), End: at(13, 0)},
+		{Start: at(15, 0), End: at(15, 30)},
+		// Empty and out of range intervals are dropped
+		{Start: at(16, 0), End: at(16, 0)},
+		{Start: at(20, 0), End: at(21, 0)},
+	}, at(9, 0), at(18, 0))
+
+	assert.Equal(t, []Interval{
+		{Start: at(9, 0), End: at(9, 30)},
+		{Start: at(11, 0), End: at(13, 0)},
+		{Start: at(15, 0), End: at(15, 30)},
+	}, freeBusy.Busy)
+
+	assert.Equal(t, []Interval{
+		{Start: at(9, 30), End: at(11, 0)},
+		{Start: at(13, 0), End: at(15, 0)},
+		{Start: at(15, 30), End: at(18, 0)},
+	}, freeBusy.Free)
+
+	empty := NewFreeBusy(nil, at(9, 0), at(18, 0))
+	assert.Empty(t, empty.Busy)
+	assert.Equal(t, []Interval{{Start: at(9, 0), End: at(18, 0)}}, empty.Free)
+
+	full := NewFreeBusy([]Interval{{Start: at(8, 0), End: at(19, 0)}}, at(9, 0), at(18, 0))
+	assert.Equal(t, []Interval{{Start: at(9, 0), End: at(18, 0)}}, full.Busy)
+	assert.Empty(t, full.Free)
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var InvalidCursorError = errors.New("invalid cursor")
+
+// EventRange selects events starting in [From, To) ordered by start and ID.
+// A non-zero After skips the events up to and including the cursor; Limit 0
+// means no limit.
+type EventRange struct {
+	From  time.Time
+	To    time.Time
+	After Cursor
+	Limit int
+}
+
+// EventPage is a page of a range query. NextCursor is empty on the last page.
+type EventPage struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Cursor is the position of an event (or an occurrence) in a range query.
+type Cursor struct {
+	StartsAt time.Time
+	ID       int
+}
+
+func CursorOf(event Event) Cursor {
+	return Cursor{StartsAt: event.StartsAt, ID: event.ID}
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == 0 && c.StartsAt.IsZero()
+}
+
+// Before reports whether the event is at or before the cursor.
+func (c Cursor) Before(event Event) bool {
+	if !event.StartsAt.Equal(c.StartsAt) {
+		return c.StartsAt.Before(event.StartsAt)
+	}
+	return c.ID < event.ID
+}
+
+// String encodes the cursor as an opaque URL-safe token.
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.StartsAt.UnixNano(), c.ID)))
+}
+
+func ParseCursor(s string) (Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, InvalidCursorError
+	}
+
+	nanos, id, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return Cursor{}, InvalidCursorError
+	}
+
+	parsedNanos, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, InvalidCursorError
+	}
+
+	parsedID, err := strconv.Atoi(id)
+	if err != nil || parsedID <= 0 {
+		return Cursor{}, InvalidCursorError
+	}
+
+	return Cursor{StartsAt: time.Unix(0, parsedNanos).UTC(), ID: parsedID}, nil
+}
+
+// Period is a calendar-aligned window containing a date.
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// Bounds returns the period containing date: the day itself, the week that
+// starts on weekStart or the calendar month. The bounds are midnights in the
+// location of date, so days across DST changes are not 24 hours long.
+func (p Period) Bounds(date time.Time, weekStart time.Weekday) (time.Time, time.Time, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	switch p {
+	case PeriodDay:
+		return day, day.AddDate(0, 0, 1), nil
+	case PeriodWeek:
+		first := day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+		return first, first.AddDate(0, 0, 7), nil
+	case PeriodMonth:
+		first := day.AddDate(0, 0, 1-day.Day())
+		return first, first.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q", p)
+	}
+}
+
+// ParseWeekday accepts English weekday names in any case, e.g. "monday".
+func ParseWeekday(s string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), s) {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
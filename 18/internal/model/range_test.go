@@ -0,0 +1,93 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// 2026-03-11 is a Wednesday
+	date := time.Date(2026, 3, 11, 15, 30, 0, 0, newYork)
+
+	testCases := []struct {
+		name      string
+		period    Period
+		weekStart time.Weekday
+		from      time.Time
+		to        time.Time
+	}{
+		{
+			name:   "day",
+			period: PeriodDay,
+			from:   time.Date(2026, 3, 11, 0, 0, 0, 0, newYork),
+			to:     time.Date(2026, 3, 12, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "week from monday",
+			period:    PeriodWeek,
+			weekStart: time.Monday,
+			from:      time.Date(2026, 3, 9, 0, 0, 0, 0, newYork),
+			to:        time.Date(2026, 3, 16, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "week from sunday (across DST change)",
+			period:    PeriodWeek,
+			weekStart: time.Sunday,
+			from:      time.Date(2026, 3, 8, 0, 0, 0, 0, newYork),
+			to:        time.Date(2026, 3, 15, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "week from wednesday",
+			period:    PeriodWeek,
+			weekStart: time.Wednesday,
+			from:      time.Date(2026, 3, 11, 0, 0, 0, 0, newYork),
+			to:        time.Date(2026, 3, 18, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:   "month",
+			period: PeriodMonth,
+			from:   time.Date(2026, 3, 1, 0, 0, 0, 0, newYork),
+			to:     time.Date(2026, 4, 1, 0, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to, err := tc.period.Bounds(date, tc.weekStart)
+			assert.NoError(t, err)
+			assert.True(t, tc.from.Equal(from), from)
+			assert.True(t, tc.to.Equal(to), to)
+		})
+	}
+
+	// February of a non-leap year
+	from, to, err := PeriodMonth.Bounds(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), time.Monday)
+	assert.NoError(t, err)
+	assert.Equal(t, 28*24*time.Hour, to.Sub(from))
+
+	_, _, err = Period("year").Bounds(date, time.Monday)
+	assert.Error(t, err)
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{StartsAt: time.Date(2026, 2, 4, 9, 0, 0, 0, time.UTC), ID: 42}
+
+	parsed, err := ParseCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+
+	for _, invalid := range []string{"", "qwerty", "MTIz", cursor.String() + "="} {
+		_, err = ParseCursor(invalid)
+		assert.Equal(t, InvalidCursorError, err, invalid)
+	}
+
+	assert.True(t, cursor.Before(Event{ID: 43, StartsAt: cursor.StartsAt}))
+	assert.True(t, cursor.Before(Event{ID: 1, StartsAt: cursor.StartsAt.Add(time.Second)}))
+	assert.False(t, cursor.Before(Event{ID: 42, StartsAt: cursor.StartsAt}))
+	assert.False(t, cursor.Before(Event{ID: 43, StartsAt: cursor.StartsAt.Add(-time.Second)}))
+}
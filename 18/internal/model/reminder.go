@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Reminder is a pending notification about the occurrence of an event that
+// starts at EventAt. It is due at RemindAt.
+type Reminder struct {
+	ID          int       `json:"id" db:"id"`
+	EventID     int       `json:"event_id" db:"event_id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Description string    `json:"description" db:"description"`
+	EventAt     time.Time `json:"event_at" db:"event_at"`
+	RemindAt    time.Time `json:"remind_at" db:"remind_at"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+}
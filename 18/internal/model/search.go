@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EventSearch is a full-text search over descriptions of user's events. Zero
+// From or To leave the range open; recurring events are matched by the start
+// of the series only, so they are never filtered out by From.
+type EventSearch struct {
+	Query  string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// EventMatch is an event found by a search together with its relevance.
+type EventMatch struct {
+	Event
+	Rank float64 `json:"rank"`
+}
+
+// SearchPage is a page of search results. NextOffset is zero on the last page.
+type SearchPage struct {
+	Events     []EventMatch `json:"events"`
+	NextOffset int          `json:"next_offset,omitempty"`
+}
+
+// SearchTerms splits the query into lower-case words. Everything except
+// letters and digits separates words, so the terms are safe to use in a
+// postgres tsquery.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
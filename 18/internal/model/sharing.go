@@ -0,0 +1,48 @@
+package model
+
+// RSVP states of an attendee. Invitations start as RSVPNeedsAction.
+const (
+	RSVPNeedsAction = "needs-action"
+	RSVPAccepted    = "accepted"
+	RSVPDeclined    = "declined"
+	RSVPTentative   = "tentative"
+)
+
+// Calendar permissions. Every user has a single calendar with their own
+// events; PermissionWrite includes PermissionRead.
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// Attendee is a user invited to an event.
+type Attendee struct {
+	EventID int    `json:"-" db:"event_id"`
+	UserID  int    `json:"user_id" db:"user_id"`
+	Status  string `json:"status" db:"status"`
+}
+
+// Share gives UserID access to the calendar of OwnerID.
+type Share struct {
+	OwnerID    int    `json:"owner_id" db:"owner_id"`
+	UserID     int    `json:"user_id" db:"user_id"`
+	Permission string `json:"permission" db:"permission"`
+}
+
+type AttendeeCreate struct {
+	UserID int `json:"user_id"`
+}
+
+type AttendeeRSVP struct {
+	RSVP string `json:"rsvp"`
+}
+
+type ShareUpdate struct {
+	Permission string `json:"permission"`
+}
+
+// RSVPStatuses are the valid RSVP states.
+var RSVPStatuses = []string{RSVPNeedsAction, RSVPAccepted, RSVPDeclined, RSVPTentative}
+
+// Permissions are the valid calendar permissions.
+var Permissions = []string{PermissionRead, PermissionWrite}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTimezone is used for events and requests that do not specify one.
+const DefaultTimezone = "UTC"
+
+var locations sync.Map
+
+// LoadLocation is time.LoadLocation that caches loaded locations. An empty
+// name means DefaultTimezone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locations.Store(name, loc)
+
+	return loc, nil
+}
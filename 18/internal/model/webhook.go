@@ -0,0 +1,62 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of webhook payloads.
+const (
+	WebhookEventCreated = "event.created"
+	WebhookEventUpdated = "event.updated"
+	WebhookEventDeleted = "event.deleted"
+	// WebhookEventRestored is sent when an event is restored from the trash.
+	WebhookEventRestored = "event.restored"
+	// WebhookEventPurged is sent when an event is removed from the trash for
+	// good.
+	WebhookEventPurged = "event.purged"
+)
+
+// Webhook is a URL that receives the changes of the user's events. Secret
+// signs the payloads and is shown only when the webhook is created.
+type Webhook struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"-" db:"user_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type WebhookCreate struct {
+	URL string `json:"url"`
+}
+
+// WebhookPayload is the body posted to webhooks. Event is the event after
+// the change, or before it for deleted events.
+type WebhookPayload struct {
+	Type       string    `json:"type"`
+	UserID     int       `json:"user_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Event      Event     `json:"event"`
+}
+
+// NewWebhookPayload encodes the payload about the change of the event.
+func NewWebhookPayload(payloadType string, event Event, occurredAt time.Time) ([]byte, error) {
+	return json.Marshal(WebhookPayload{
+		Type:       payloadType,
+		UserID:     event.UserID,
+		OccurredAt: occurredAt.UTC(),
+		Event:      event,
+	})
+}
+
+// WebhookDelivery is a payload waiting in the outbox to be posted to a
+// webhook.
+type WebhookDelivery struct {
+	ID        int    `json:"id" db:"id"`
+	WebhookID int    `json:"webhook_id" db:"webhook_id"`
+	URL       string `json:"url" db:"url"`
+	Secret    string `json:"-" db:"secret"`
+	Payload   []byte `json:"payload" db:"payload"`
+	Attempts  int    `json:"attempts" db:"attempts"`
+}
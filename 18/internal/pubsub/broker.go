@@ -0,0 +1,124 @@
+package pubsub
+
+import (
+	"sync"
+	"wbtech_l2/18/internal/model"
+)
+
+const (
+	defaultHistorySize = 1000
+	// bufferSize bounds the changes waiting for a subscriber. Subscribers
+	// that fall further behind are closed and have to resume.
+	bufferSize = 64
+)
+
+// Broker delivers the changes of events to the subscribers of their owner
+// within the process. The last historySize changes are kept, so that
+// subscribers that reconnect get the changes they missed in between.
+// Instances behind a load balancer don't see the changes of each other.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      int
+	history     []model.EventChange
+	historySize int
+	subscribers map[int]map[*Subscription]struct{}
+}
+
+// NewBroker keeps the last historySize changes, 1000 if it is not positive.
+func NewBroker(historySize int) *Broker {
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+
+	return &Broker{historySize: historySize, subscribers: make(map[int]map[*Subscription]struct{})}
+}
+
+// Subscription gets the changes of a user on C until it is closed. Missed
+// holds the changes published after the one the subscriber resumed from. Gap
+// is true if some of them are no longer kept, e.g. after a restart: the
+// subscriber has to reload the events instead and resume after LastID, the
+// last change published before it subscribed.
+type Subscription struct {
+	C      <-chan model.EventChange
+	Missed []model.EventChange
+	Gap    bool
+	LastID int
+
+	broker *Broker
+	userID int
+	ch     chan model.EventChange
+}
+
+// Publish gives the change the next ID and sends it to the subscribers of
+// its user. It never blocks: subscribers that don't keep up are closed.
+func (b *Broker) Publish(change model.EventChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	change.ID = b.lastID
+
+	b.history = append(b.history, change)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers[change.UserID] {
+		select {
+		case sub.ch <- change:
+		default:
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe subscribes to the changes of userID published after the change
+// with lastID, or to the new ones if lastID is 0.
+func (b *Broker) Subscribe(userID, lastID int) *Subscription {
+	ch := make(chan model.EventChange, bufferSize)
+	sub := &Subscription{C: ch, broker: b, userID: userID, ch: ch}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub.LastID = b.lastID
+	// IDs start over when the process restarts
+	sub.Gap = lastID > b.lastID || lastID > 0 && len(b.history) > 0 && b.history[0].ID > lastID+1
+
+	if lastID > 0 && !sub.Gap {
+		for _, change := range b.history {
+			if change.ID > lastID && change.UserID == userID {
+				sub.Missed = append(sub.Missed, change)
+			}
+		}
+	}
+
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Close stops the subscription and closes C. It can be called more than
+// once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.unsubscribe(s)
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	subs, ok := b.subscribers[sub.userID]
+	if _, subscribed := subs[sub]; !ok || !subscribed {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+	close(sub.ch)
+}
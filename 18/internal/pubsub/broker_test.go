@@ -0,0 +1,93 @@
+package pubsub
+
+import (
+	"testing"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker(3)
+
+	sub := broker.Subscribe(1, 0)
+	other := broker.Subscribe(2, 0)
+	assert.Empty(t, sub.Missed)
+	assert.False(t, sub.Gap)
+
+	broker.Publish(model.EventChange{Type: model.ChangeCreated, UserID: 1, EventID: 10})
+	broker.Publish(model.EventChange{Type: model.ChangeCreated, UserID: 2, EventID: 20})
+
+	change := <-sub.C
+	assert.Equal(t, 1, change.ID)
+	assert.Equal(t, 10, change.EventID)
+	assert.Equal(t, 2, (<-other.C).ID)
+
+	// Closing twice is fine
+	sub.Close()
+	sub.Close()
+	_, open := <-sub.C
+	assert.False(t, open)
+
+	broker.Publish(model.EventChange{Type: model.ChangeUpdated, UserID: 1, EventID: 10})
+	broker.Publish(model.EventChange{Type: model.ChangeUpdated, UserID: 1, EventID: 10})
+	broker.Publish(model.EventChange{Type: model.ChangeDeleted, UserID: 1, EventID: 10})
+
+	testCases := []struct {
+		name           string
+		lastID         int
+		expectedMissed []int
+		expectedGap    bool
+	}{
+		{
+			name:           "resumed",
+			lastID:         2,
+			expectedMissed: []int{3, 4, 5},
+		},
+		{
+			name:   "resumed after the last change",
+			lastID: 5,
+		},
+		{
+			name:        "changes dropped from history",
+			lastID:      1,
+			expectedGap: true,
+		},
+		{
+			name:        "resumed after restart",
+			lastID:      99,
+			expectedGap: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resumed := broker.Subscribe(1, tc.lastID)
+			defer resumed.Close()
+
+			var missed []int
+			for _, change := range resumed.Missed {
+				missed = append(missed, change.ID)
+			}
+			assert.Equal(t, tc.expectedMissed, missed)
+			assert.Equal(t, tc.expectedGap, resumed.Gap)
+			assert.Equal(t, 5, resumed.LastID)
+		})
+	}
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := NewBroker(0)
+	sub := broker.Subscribe(1, 0)
+
+	for range bufferSize + 1 {
+		broker.Publish(model.EventChange{Type: model.ChangeCreated, UserID: 1})
+	}
+
+	// The buffered changes are delivered before C is closed
+	received := 0
+	for range sub.C {
+		received++
+	}
+	assert.Equal(t, bufferSize, received)
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit of a token bucket: Burst tokens at most, refilled at Rate tokens per
+// second. Every request takes one token. A zero Rate disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Store keeps the buckets of clients by key. MemoryStore serves a single
+// instance; instances behind a load balancer share a store in e.g. Redis.
+// Take returns whether a request of key is allowed and, if it isn't, how
+// long until the next token.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+}
+
+// bucket remembers its limit, so that it can be swept with the right rate.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rate    float64
+	burst   float64
+}
+
+func (b bucket) refill(now time.Time) float64 {
+	return math.Min(b.burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+}
+
+// MemoryStore keeps the buckets in memory. Buckets that have refilled are
+// dropped every sweepInterval, so that clients seen once don't accumulate.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	if limit.Rate <= 0 {
+		return true, 0, nil
+	}
+
+	burst := float64(max(limit.Burst, 1))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.swept) >= sweepInterval {
+		s.sweep(now)
+		s.swept = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = bucket{tokens: burst, updated: now}
+	}
+
+	b.rate, b.burst = limit.Rate, burst
+	b.tokens = b.refill(now)
+	b.updated = now
+
+	if b.tokens < 1 {
+		s.buckets[key] = b
+		wait := time.Duration(math.Ceil((1 - b.tokens) / limit.Rate * float64(time.Second)))
+		return false, wait, nil
+	}
+
+	b.tokens--
+	s.buckets[key] = b
+
+	return true, 0, nil
+}
+
+// sweep drops the buckets that are full at now, which is the state a missing
+// bucket starts in.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.refill(now) >= b.burst {
+			delete(s.buckets, key)
+		}
+	}
+}
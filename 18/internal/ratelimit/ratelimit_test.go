@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2026, 2, 2, 10, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := store.Take(ctx, "a", limit)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	allowed, wait, err := store.Take(ctx, "a", limit)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other keys have buckets of their own
+	allowed, _, _ = store.Take(ctx, "b", limit)
+	assert.True(t, allowed)
+
+	now = now.Add(250 * time.Millisecond)
+	allowed, wait, _ = store.Take(ctx, "a", limit)
+	assert.False(t, allowed)
+	assert.Equal(t, 250*time.Millisecond, wait)
+
+	now = now.Add(250 * time.Millisecond)
+	allowed, _, _ = store.Take(ctx, "a", limit)
+	assert.True(t, allowed)
+
+	allowed, _, _ = store.Take(ctx, "a", Limit{})
+	assert.True(t, allowed)
+
+	// Refilled buckets are swept
+	now = now.Add(sweepInterval)
+	store.Take(ctx, "c", limit)
+	assert.Equal(t, 1, len(store.buckets))
+}
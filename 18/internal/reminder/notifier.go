@@ -0,0 +1,97 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, reminder model.Reminder) error
+}
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, reminder model.Reminder) error {
+	logrus.WithFields(logrus.Fields{
+		"event_id":    reminder.EventID,
+		"user_id":     reminder.UserID,
+		"description": reminder.Description,
+		"event_at":    reminder.EventAt,
+	}).Info("Reminder")
+
+	return nil
+}
+
+// WriterNotifier writes every reminder to w as a JSON line.
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+func NewStdoutNotifier() *WriterNotifier {
+	return NewWriterNotifier(os.Stdout)
+}
+
+func (n *WriterNotifier) Notify(_ context.Context, reminder model.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return json.NewEncoder(n.w).Encode(reminder)
+}
+
+// WebhookNotifier posts every reminder as JSON to url. Any status other than
+// 2xx is treated as a failed delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder model.Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
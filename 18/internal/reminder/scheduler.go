@@ -0,0 +1,156 @@
+package reminder
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	Workers      int
+	BatchSize    int
+	PollInterval time.Duration
+	// Lease is how long a claimed reminder stays invisible to other pollers.
+	// A reminder that is neither sent nor released in time is delivered
+	// again, so it must be longer than SendTimeout.
+	Lease       time.Duration
+	SendTimeout time.Duration
+	RetryDelay  time.Duration
+	MaxRetry    time.Duration
+}
+
+// Scheduler polls due reminders and delivers them through the notifier with
+// a pool of workers. Delivery is at-least-once: a reminder is marked as sent
+// only after the notifier succeeded.
+type Scheduler struct {
+	service  service.Reminder
+	notifier Notifier
+	cfg      Config
+
+	queue  chan model.Reminder
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(service service.Reminder, notifier Notifier, cfg Config) *Scheduler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = cfg.Workers * 10
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 10 * time.Second
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = 10 * time.Second
+	}
+	if cfg.Lease <= cfg.SendTimeout {
+		cfg.Lease = 6 * cfg.SendTimeout
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 30 * time.Second
+	}
+	if cfg.MaxRetry <= 0 {
+		cfg.MaxRetry = time.Hour
+	}
+
+	return &Scheduler{
+		service:  service,
+		notifier: notifier,
+		cfg:      cfg,
+		queue:    make(chan model.Reminder, cfg.BatchSize),
+	}
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.poll(ctx)
+
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+}
+
+// Stop stops polling and waits for the workers to deliver the reminders that
+// were already claimed.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) poll(ctx context.Context) {
+	defer s.wg.Done()
+	defer close(s.queue)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Stop cancels a claim in flight, which is not an error
+		reminders, err := s.service.ClaimDue(ctx, s.cfg.BatchSize, s.cfg.Lease)
+		if err != nil && ctx.Err() == nil {
+			logrus.Errorf("Error claiming due reminders: %s", err.Error())
+		}
+
+		for _, reminder := range reminders {
+			s.queue <- reminder
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) work() {
+	defer s.wg.Done()
+
+	for reminder := range s.queue {
+		s.deliver(reminder)
+	}
+}
+
+func (s *Scheduler) deliver(reminder model.Reminder) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.SendTimeout)
+	defer cancel()
+
+	notifyErr := s.notifier.Notify(ctx, reminder)
+
+	// The notification may have used up the timeout of ctx
+	ctx, cancel = context.WithTimeout(context.Background(), s.cfg.SendTimeout)
+	defer cancel()
+
+	if notifyErr != nil {
+		logrus.Errorf("Error sending reminder %d (attempt %d): %s", reminder.ID, reminder.Attempts, notifyErr.Error())
+
+		if err := s.service.Retry(ctx, reminder, time.Now().Add(s.backoff(reminder.Attempts))); err != nil {
+			logrus.Errorf("Error releasing reminder %d: %s", reminder.ID, err.Error())
+		}
+		return
+	}
+
+	if err := s.service.Complete(ctx, reminder); err != nil {
+		logrus.Errorf("Error completing reminder %d: %s", reminder.ID, err.Error())
+	}
+}
+
+// backoff doubles the retry delay with every failed attempt up to MaxRetry.
+func (s *Scheduler) backoff(attempts int) time.Duration {
+	delay := s.cfg.RetryDelay
+	for i := 1; i < attempts && delay < s.cfg.MaxRetry; i++ {
+		delay *= 2
+	}
+
+	return min(delay, s.cfg.MaxRetry)
+}
@@ -0,0 +1,148 @@
+package reminder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyNotifier fails the first failures deliveries.
+type flakyNotifier struct {
+	mu        sync.Mutex
+	failures  int
+	delivered []model.Reminder
+}
+
+func (n *flakyNotifier) Notify(_ context.Context, reminder model.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("notifier is down")
+	}
+
+	n.delivered = append(n.delivered, reminder)
+	return nil
+}
+
+func (n *flakyNotifier) deliveredCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.delivered)
+}
+
+func createDueEvent(t *testing.T, services *service.Service, description string) int {
+	ctx := context.Background()
+	t.Helper()
+
+	start := time.Now().Add(time.Hour)
+	id, err := services.Create(ctx, 1, model.Event{
+		Description:  description,
+		Date:         start.Format("2006-01-02"),
+		Time:         start.Format("15:04:05"),
+		RemindBefore: "2h",
+	})
+	assert.NoError(t, err)
+
+	return id
+}
+
+func TestSchedulerDeliversWithRetries(t *testing.T) {
+	ctx := context.Background()
+	services := service.NewService(repository.NewMemoryRepository())
+	eventID1 := createDueEvent(t, services, "dentist")
+	eventID2 := createDueEvent(t, services, "stand-up")
+
+	notifier := &flakyNotifier{failures: 2}
+	scheduler := NewScheduler(services.Reminder, notifier, Config{
+		Workers:      2,
+		PollInterval: 5 * time.Millisecond,
+		RetryDelay:   5 * time.Millisecond,
+	})
+	scheduler.Start()
+
+	assert.Eventually(t, func() bool { return notifier.deliveredCount() == 2 }, 2*time.Second, 5*time.Millisecond)
+	scheduler.Stop()
+
+	eventIDs := []int{notifier.delivered[0].EventID, notifier.delivered[1].EventID}
+	assert.ElementsMatch(t, []int{eventID1, eventID2}, eventIDs)
+
+	// Sent reminders are not delivered again.
+	reminders, err := services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func TestSchedulerStop(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	notifier := &flakyNotifier{}
+	scheduler := NewScheduler(services.Reminder, notifier, Config{PollInterval: time.Hour})
+	scheduler.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	scheduler := NewScheduler(nil, nil, Config{RetryDelay: time.Second, MaxRetry: 5 * time.Second})
+
+	assert.Equal(t, time.Second, scheduler.backoff(1))
+	assert.Equal(t, 2*time.Second, scheduler.backoff(2))
+	assert.Equal(t, 4*time.Second, scheduler.backoff(3))
+	assert.Equal(t, 5*time.Second, scheduler.backoff(4))
+	assert.Equal(t, 5*time.Second, scheduler.backoff(100))
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received model.Reminder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil || received.EventID == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	notifier := NewWebhookNotifier(srv.URL, srv.Client())
+
+	err := notifier.Notify(context.Background(), model.Reminder{ID: 1, EventID: 2, Description: "dentist"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dentist", received.Description)
+
+	err = notifier.Notify(context.Background(), model.Reminder{ID: 1})
+	assert.Error(t, err)
+}
+
+func TestWriterNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	notifier := NewWriterNotifier(&buf)
+
+	assert.NoError(t, notifier.Notify(context.Background(), model.Reminder{ID: 1, EventID: 2}))
+
+	var written model.Reminder
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &written))
+	assert.Equal(t, 2, written.EventID)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+type APIKeyMemoryRepository struct {
+	mu     sync.RWMutex
+	lastID int
+	keys   map[int]memoryAPIKey
+}
+
+type memoryAPIKey struct {
+	userID  int
+	keyHash string
+	revoked bool
+}
+
+func NewAPIKeyMemory() *APIKeyMemoryRepository {
+	return &APIKeyMemoryRepository{keys: make(map[int]memoryAPIKey)}
+}
+
+func (r *APIKeyMemoryRepository) CreateAPIKey(ctx context.Context, userID int, keyHash string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// key_hash is UNIQUE in postgres
+	for _, stored := range r.keys {
+		if stored.keyHash == keyHash {
+			return 0, errors.New("api key already exists")
+		}
+	}
+
+	r.lastID++
+	r.keys[r.lastID] = memoryAPIKey{userID: userID, keyHash: keyHash}
+
+	return r.lastID, nil
+}
+
+func (r *APIKeyMemoryRepository) GetUserIDByAPIKey(ctx context.Context, keyHash string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.keys {
+		if stored.keyHash == keyHash && !stored.revoked {
+			return stored.userID, nil
+		}
+	}
+
+	return 0, APIKeyNotFoundError
+}
+
+func (r *APIKeyMemoryRepository) RevokeAPIKey(ctx context.Context, keyID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.keys[keyID]
+	if !ok || stored.revoked {
+		return APIKeyNotFoundError
+	}
+
+	stored.revoked = true
+	r.keys[keyID] = stored
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var APIKeyNotFoundError = errors.New("api key not found")
+
+type APIKeyPostgresRepository struct {
+	db *sqlx.DB
+	queryDeadline
+}
+
+func NewAPIKeyPostgres(db *sqlx.DB) *APIKeyPostgresRepository {
+	return &APIKeyPostgresRepository{db: db}
+}
+
+func (r *APIKeyPostgresRepository) CreateAPIKey(ctx context.Context, userID int, keyHash string) (_ int, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	var id int
+
+	query := fmt.Sprintf("INSERT INTO %s (user_id, key_hash) VALUES ($1, $2) RETURNING id;", apiKeysTable)
+	if err := r.db.QueryRowContext(ctx, query, userID, keyHash).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *APIKeyPostgresRepository) GetUserIDByAPIKey(ctx context.Context, keyHash string) (_ int, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	var userID int
+
+	query := fmt.Sprintf("SELECT k.user_id FROM %s k WHERE k.key_hash = $1 AND k.revoked_at IS NULL;", apiKeysTable)
+	if err := r.db.GetContext(ctx, &userID, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, APIKeyNotFoundError
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (r *APIKeyPostgresRepository) RevokeAPIKey(ctx context.Context, keyID int) (err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	query := fmt.Sprintf("UPDATE %s SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL;", apiKeysTable)
+	affected, err := r.db.ExecContext(ctx, query, keyID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return APIKeyNotFoundError
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(apiKeysTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	keyID, err1 := repo.APIKey.CreateAPIKey(ctx, userID, "hash")
+	_, err2 := repo.APIKey.CreateAPIKey(ctx, userID, "hash")
+
+	assert.NoError(t, err1)
+	assert.Error(t, err2)
+
+	foundUserID, err3 := repo.APIKey.GetUserIDByAPIKey(ctx, "hash")
+	_, err4 := repo.APIKey.GetUserIDByAPIKey(ctx, "unknown")
+
+	assert.NoError(t, err3)
+	assert.Equal(t, userID, foundUserID)
+	assert.Equal(t, APIKeyNotFoundError, err4)
+
+	err5 := repo.APIKey.RevokeAPIKey(ctx, keyID)
+	err6 := repo.APIKey.RevokeAPIKey(ctx, keyID)
+	_, err7 := repo.APIKey.GetUserIDByAPIKey(ctx, "hash")
+
+	assert.NoError(t, err5)
+	assert.Equal(t, APIKeyNotFoundError, err6)
+	assert.Equal(t, APIKeyNotFoundError, err7)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	AttendeeNotFoundError = errors.New("attendee not found")
+	AttendeeExistsError   = errors.New("user is already invited")
+)
+
+type AttendeePostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewAttendeePostgres(db *sqlx.DB) *AttendeePostgresRepository {
+	return &AttendeePostgresRepository{db: db}
+}
+
+func (r *AttendeePostgresRepository) AddAttendee(ctx context.Context, ownerID, eventID, userID int) (err error) {
+	defer reportContext(ctx, &err)
+
+	var id int
+
+	query := fmt.Sprintf("INSERT INTO %s (event_id, user_id, status) SELECT e.id, $3, $4 FROM %s e WHERE e.id = $1 AND e.user_id = $2 AND e.deleted_at IS NULL ON CONFLICT (event_id, user_id) DO NOTHING RETURNING event_id;", attendeesTable, eventsTable)
+	err = r.db.QueryRowContext(ctx, query, eventID, ownerID, userID, model.RSVPNeedsAction).Scan(&id)
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Nothing is inserted either for a missing event or for an existing
+	// invitation
+	var exists bool
+	query = fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s e WHERE e.id = $1 AND e.user_id = $2 AND e.deleted_at IS NULL);", eventsTable)
+	if err = r.db.GetContext(ctx, &exists, query, eventID, ownerID); err != nil {
+		return err
+	}
+
+	if exists {
+		return AttendeeExistsError
+	}
+
+	return NotFoundError
+}
+
+func (r *AttendeePostgresRepository) RemoveAttendee(ctx context.Context, ownerID, eventID, userID int) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("DELETE FROM %s a USING %s e WHERE a.event_id = e.id AND e.id = $1 AND e.user_id = $2 AND e.deleted_at IS NULL AND a.user_id = $3;", attendeesTable, eventsTable)
+	affected, err := r.db.ExecContext(ctx, query, eventID, ownerID, userID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return AttendeeNotFoundError
+	}
+
+	return nil
+}
+
+func (r *AttendeePostgresRepository) GetAttendees(ctx context.Context, eventID int) (_ []model.Attendee, err error) {
+	defer reportContext(ctx, &err)
+
+	attendees := make([]model.Attendee, 0)
+
+	query := fmt.Sprintf("SELECT a.event_id, a.user_id, a.status FROM %s a WHERE a.event_id = $1 ORDER BY a.user_id;", attendeesTable)
+	if err := r.db.SelectContext(ctx, &attendees, query, eventID); err != nil {
+		return nil, err
+	}
+
+	return attendees, nil
+}
+
+func (r *AttendeePostgresRepository) SetRSVP(ctx context.Context, userID, eventID int, status string) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("UPDATE %s a SET status = $1 FROM %s e WHERE a.event_id = e.id AND e.deleted_at IS NULL AND a.event_id = $2 AND a.user_id = $3;", attendeesTable, eventsTable)
+	affected, err := r.db.ExecContext(ctx, query, status, eventID, userID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return AttendeeNotFoundError
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttendees(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable, attendeesTable)
+
+	repo := NewRepository(db)
+
+	ownerID, attendeeID := 1, 2
+	eventID, _ := repo.Event.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, ownerID, model.Event{Description: "private", Date: "2026-02-04", Time: "11:00"})
+	repo.Event.Create(ctx, attendeeID, model.Event{Description: "own", Date: "2026-02-04", Time: "12:00"})
+
+	err1 := repo.Attendee.AddAttendee(ctx, ownerID, eventID, attendeeID)
+	err2 := repo.Attendee.AddAttendee(ctx, ownerID, eventID, attendeeID)
+	err3 := repo.Attendee.AddAttendee(ctx, attendeeID, eventID, 3)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, AttendeeExistsError, err2)
+	assert.Equal(t, NotFoundError, err3)
+
+	events, err := repo.Event.GetEventsForDay(ctx, attendeeID, "2026-02-04", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"planning", "own"}, descriptions(events))
+	assert.Equal(t, model.RSVPNeedsAction, events[0].RSVP)
+	assert.Equal(t, ownerID, events[0].OrganizerID)
+
+	assert.Equal(t, NotFoundError, repo.Event.Update(ctx, attendeeID, eventID, model.Event{Description: "mine"}))
+	assert.Equal(t, NotFoundError, repo.Event.Delete(ctx, attendeeID, eventID, 0))
+
+	assert.NoError(t, repo.Attendee.SetRSVP(ctx, attendeeID, eventID, model.RSVPDeclined))
+	assert.Equal(t, AttendeeNotFoundError, repo.Attendee.SetRSVP(ctx, 3, eventID, model.RSVPAccepted))
+
+	events, err = repo.Event.GetEventsForDay(ctx, attendeeID, "2026-02-04", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"own"}, descriptions(events))
+
+	event, err := repo.Event.GetByID(ctx, attendeeID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RSVPDeclined, event.RSVP)
+
+	attendees, err := repo.Attendee.GetAttendees(ctx, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Attendee{{EventID: eventID, UserID: attendeeID, Status: model.RSVPDeclined}}, attendees)
+
+	err4 := repo.Attendee.RemoveAttendee(ctx, attendeeID, eventID, attendeeID)
+	err5 := repo.Attendee.RemoveAttendee(ctx, ownerID, eventID, attendeeID)
+	_, err6 := repo.Event.GetByID(ctx, attendeeID, eventID)
+
+	assert.Equal(t, AttendeeNotFoundError, err4)
+	assert.NoError(t, err5)
+	assert.Equal(t, NotFoundError, err6)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+type AuditMemoryRepository struct {
+	mu      sync.RWMutex
+	records []model.AuditRecord
+}
+
+func NewAuditMemory() *AuditMemoryRepository {
+	return &AuditMemoryRepository{}
+}
+
+func (r *AuditMemoryRepository) GetEventHistory(ctx context.Context, ownerID, eventID int, auditRange model.AuditRange) ([]model.AuditRecord, error) {
+	return r.selectRecords(func(record model.AuditRecord) bool {
+		return record.OwnerID == ownerID && record.EventID == eventID
+	}, auditRange), nil
+}
+
+func (r *AuditMemoryRepository) GetUserHistory(ctx context.Context, userID int, auditRange model.AuditRange) ([]model.AuditRecord, error) {
+	return r.selectRecords(func(record model.AuditRecord) bool {
+		return record.OwnerID == userID || record.ActorID == userID
+	}, auditRange), nil
+}
+
+// selectRecords returns the matching records the latest first.
+func (r *AuditMemoryRepository) selectRecords(match func(model.AuditRecord) bool, auditRange model.AuditRange) []model.AuditRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	records := make([]model.AuditRecord, 0)
+	for i := len(r.records) - 1; i >= 0; i-- {
+		record := r.records[i]
+		if auditRange.Before > 0 && record.ID >= auditRange.Before || !match(record) {
+			continue
+		}
+
+		records = append(records, record)
+		if auditRange.Limit > 0 && len(records) == auditRange.Limit {
+			break
+		}
+	}
+
+	return records
+}
+
+// append mirrors appendAudit. EventMemoryRepository calls it while holding
+// its own lock.
+func (r *AuditMemoryRepository) append(record model.AuditRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.ID = len(r.records) + 1
+	record.CreatedAt = time.Now()
+	r.records = append(r.records, record)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAudit(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	ownerID, actorID := 1, 2
+	eventID, _ := repo.Event.Create(ctx, ownerID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "10:00", RRule: "FREQ=DAILY"})
+	assert.NoError(t, repo.Event.As(actorID).Update(ctx, ownerID, eventID, model.Event{Description: "daily"}))
+	assert.NoError(t, repo.Event.AddException(ctx, ownerID, eventID, "2026-02-03", 0))
+	assert.NoError(t, repo.Event.Delete(ctx, ownerID, eventID, 0))
+	assert.NoError(t, repo.Event.Restore(ctx, ownerID, eventID))
+	otherEventID, _ := repo.Event.Create(ctx, 12345, model.Event{Description: "other", Date: "2026-02-02", Time: "10:00"})
+
+	// Failed writes are not recorded
+	assert.Equal(t, NotFoundError, repo.Event.Update(ctx, 12345, eventID, model.Event{Description: "mine"}))
+
+	records, err := repo.Audit.GetEventHistory(ctx, ownerID, eventID, model.AuditRange{})
+	assert.NoError(t, err)
+	actions := make([]string, 0, len(records))
+	for _, record := range records {
+		assert.Equal(t, eventID, record.EventID)
+		assert.Equal(t, ownerID, record.OwnerID)
+		actions = append(actions, record.Action)
+	}
+	assert.Equal(t, []string{model.AuditRestore, model.AuditDelete, model.AuditUpdate, model.AuditUpdate, model.AuditCreate}, actions)
+
+	create, update, del := records[4], records[3], records[1]
+	assert.Equal(t, "null", string(create.Before))
+	assert.Equal(t, actorID, update.ActorID)
+	assert.Equal(t, ownerID, del.ActorID)
+	assert.Equal(t, "null", string(del.After))
+
+	var before, after model.Event
+	assert.NoError(t, json.Unmarshal(update.Before, &before))
+	assert.NoError(t, json.Unmarshal(update.After, &after))
+	assert.Equal(t, "stand-up", before.Description)
+	assert.Equal(t, "daily", after.Description)
+	assert.Equal(t, before.Version+1, after.Version)
+
+	records, err = repo.Audit.GetEventHistory(ctx, 12345, eventID, model.AuditRange{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	page, err := repo.Audit.GetEventHistory(ctx, ownerID, eventID, model.AuditRange{Before: update.ID, Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, create.ID, page[0].ID)
+	}
+
+	records, err = repo.Audit.GetUserHistory(ctx, actorID, model.AuditRange{})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, update.ID, records[0].ID)
+	}
+
+	records, err = repo.Audit.GetUserHistory(ctx, 12345, model.AuditRange{})
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, otherEventID, records[0].EventID)
+	}
+}
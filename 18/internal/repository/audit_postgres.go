@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const auditColumns = "id, event_id, owner_id, actor_id, action, before, after, created_at"
+
+// auditRecordFromDB scans the JSONB columns, which are NULL for missing
+// states of the event.
+type auditRecordFromDB struct {
+	ID        int       `db:"id"`
+	EventID   int       `db:"event_id"`
+	OwnerID   int       `db:"owner_id"`
+	ActorID   int       `db:"actor_id"`
+	Action    string    `db:"action"`
+	Before    []byte    `db:"before"`
+	After     []byte    `db:"after"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type AuditPostgresRepository struct {
+	db *sqlx.DB
+	queryDeadline
+}
+
+func NewAuditPostgres(db *sqlx.DB) *AuditPostgresRepository {
+	return &AuditPostgresRepository{db: db}
+}
+
+func (r *AuditPostgresRepository) GetEventHistory(ctx context.Context, ownerID, eventID int, auditRange model.AuditRange) ([]model.AuditRecord, error) {
+	return r.selectRecords(ctx, "owner_id = $1 AND event_id = $2", []any{ownerID, eventID}, auditRange)
+}
+
+func (r *AuditPostgresRepository) GetUserHistory(ctx context.Context, userID int, auditRange model.AuditRange) ([]model.AuditRecord, error) {
+	return r.selectRecords(ctx, "(owner_id = $1 OR actor_id = $1)", []any{userID}, auditRange)
+}
+
+func (r *AuditPostgresRepository) selectRecords(ctx context.Context, condition string, args []any, auditRange model.AuditRange) (_ []model.AuditRecord, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	conditions := []string{condition}
+
+	if auditRange.Before > 0 {
+		args = append(args, auditRange.Before)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id DESC", auditColumns, auditTable, strings.Join(conditions, " AND "))
+
+	if auditRange.Limit > 0 {
+		args = append(args, auditRange.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	var recordsFromDB []auditRecordFromDB
+	if err := r.db.SelectContext(ctx, &recordsFromDB, query+";", args...); err != nil {
+		return nil, err
+	}
+
+	records := make([]model.AuditRecord, 0, len(recordsFromDB))
+	for _, record := range recordsFromDB {
+		records = append(records, model.AuditRecord{
+			ID:        record.ID,
+			EventID:   record.EventID,
+			OwnerID:   record.OwnerID,
+			ActorID:   record.ActorID,
+			Action:    record.Action,
+			Before:    jsonOrNull(record.Before),
+			After:     jsonOrNull(record.After),
+			CreatedAt: record.CreatedAt,
+		})
+	}
+
+	return records, nil
+}
+
+// appendAudit stores the record in tx, so that it is written if and only if
+// the change it describes is.
+func appendAudit(ctx context.Context, tx *sqlx.Tx, record model.AuditRecord) error {
+	query := fmt.Sprintf("INSERT INTO %s (event_id, owner_id, actor_id, action, before, after) VALUES ($1, $2, $3, $4, $5, $6);", auditTable)
+	_, err := tx.ExecContext(ctx, query, record.EventID, record.OwnerID, record.ActorID, record.Action, nullJSON(record.Before), nullJSON(record.After))
+	return err
+}
+
+// nullJSON stores a JSON null as SQL NULL.
+func nullJSON(data json.RawMessage) []byte {
+	if data == nil || string(data) == "null" {
+		return nil
+	}
+
+	return data
+}
+
+// jsonOrNull reverses nullJSON.
+func jsonOrNull(data []byte) json.RawMessage {
+	if data == nil {
+		return json.RawMessage("null")
+	}
+
+	return data
+}
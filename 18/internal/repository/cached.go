@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/cache"
+	"wbtech_l2/18/internal/model"
+)
+
+// Results of cache lookups reported to CacheObserver.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// CacheObserver receives the result of every lookup of a cached repository
+// and the failures of invalidations, reported as "Invalidate".
+type CacheObserver interface {
+	ObserveCache(method, result string)
+}
+
+// CachedEvent serves GetEventsForDay, GetEventsForWeek and GetEventsForMonth
+// from store, falling through to the wrapped Event when the store fails.
+// Results are tagged with the user and every UTC day of their window. Writes
+// of an event drop the days it starts on before and after the write for its
+// owner and attendees once their unit of work commits.
+// CachedEvent wraps Attendee too, as invitations change the events listed
+// for the invitee. Reads inside units of work bypass the store, as they may
+// see uncommitted writes.
+type CachedEvent struct {
+	next      Event
+	attendees Attendee
+	store     cache.Store
+	observer  CacheObserver
+	writes    *writeCounter
+}
+
+// writeCounter counts the invalidations of the process, so that reads that
+// race a write don't store what they read before it. It is shared with the
+// repositories returned by As.
+type writeCounter struct {
+	mu sync.RWMutex
+	n  uint64
+}
+
+func NewCachedEvent(next Event, attendees Attendee, store cache.Store, observer CacheObserver) *CachedEvent {
+	return &CachedEvent{next: next, attendees: attendees, store: store, observer: observer, writes: &writeCounter{}}
+}
+
+// As keeps the cache of the repository of the actor.
+func (r *CachedEvent) As(actorID int) Event {
+	return &CachedEvent{next: r.next.As(actorID), attendees: r.attendees, store: r.store, observer: r.observer, writes: r.writes}
+}
+
+func (r *CachedEvent) Create(ctx context.Context, userID int, event model.Event) (int, error) {
+	id, err := r.next.Create(ctx, userID, event)
+	if err != nil {
+		return 0, err
+	}
+
+	r.invalidate(ctx, r.tagsOf(ctx, userID, id))
+	return id, nil
+}
+
+func (r *CachedEvent) Update(ctx context.Context, userID, eventID int, event model.Event) error {
+	tags := r.tagsOf(ctx, userID, eventID)
+	if err := r.next.Update(ctx, userID, eventID, event); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, append(tags, r.tagsOf(ctx, userID, eventID)...))
+	return nil
+}
+
+func (r *CachedEvent) Delete(ctx context.Context, userID, eventID, version int) error {
+	tags := r.tagsOf(ctx, userID, eventID)
+	if err := r.next.Delete(ctx, userID, eventID, version); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, tags)
+	return nil
+}
+
+func (r *CachedEvent) GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	return r.cached(ctx, "GetEventsForDay", userID, date, loc, 1, r.next.GetEventsForDay)
+}
+
+func (r *CachedEvent) GetEventsForWeek(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	return r.cached(ctx, "GetEventsForWeek", userID, date, loc, 7, r.next.GetEventsForWeek)
+}
+
+func (r *CachedEvent) GetEventsForMonth(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	return r.cached(ctx, "GetEventsForMonth", userID, date, loc, 31, r.next.GetEventsForMonth)
+}
+
+func (r *CachedEvent) GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) ([]model.Event, error) {
+	return r.next.GetEventsInRange(ctx, userID, eventRange)
+}
+
+func (r *CachedEvent) GetEventsOverlapping(ctx context.Context, userID int, from, to time.Time) ([]model.Event, error) {
+	return r.next.GetEventsOverlapping(ctx, userID, from, to)
+}
+
+func (r *CachedEvent) Search(ctx context.Context, userID int, search model.EventSearch) ([]model.EventMatch, error) {
+	return r.next.Search(ctx, userID, search)
+}
+
+func (r *CachedEvent) GetByID(ctx context.Context, userID, eventID int) (model.Event, error) {
+	return r.next.GetByID(ctx, userID, eventID)
+}
+
+func (r *CachedEvent) GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]model.Event, error) {
+	return r.next.GetRecurringEvents(ctx, userID, before)
+}
+
+// AddException only changes recurring events, which are never cached.
+func (r *CachedEvent) AddException(ctx context.Context, userID, eventID int, date string, version int) error {
+	return r.next.AddException(ctx, userID, eventID, date, version)
+}
+
+func (r *CachedEvent) Restore(ctx context.Context, userID, eventID int) error {
+	if err := r.next.Restore(ctx, userID, eventID); err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, r.tagsOf(ctx, userID, eventID))
+	return nil
+}
+
+func (r *CachedEvent) GetDeleted(ctx context.Context, userID int) ([]model.Event, error) {
+	return r.next.GetDeleted(ctx, userID)
+}
+
+func (r *CachedEvent) AddAttendee(ctx context.Context, ownerID, eventID, userID int) error {
+	if err := r.attendees.AddAttendee(ctx, ownerID, eventID, userID); err != nil {
+		return err
+	}
+
+	r.invalidateFor(ctx, userID, ownerID, eventID)
+	return nil
+}
+
+func (r *CachedEvent) RemoveAttendee(ctx context.Context, ownerID, eventID, userID int) error {
+	if err := r.attendees.RemoveAttendee(ctx, ownerID, eventID, userID); err != nil {
+		return err
+	}
+
+	r.invalidateFor(ctx, userID, ownerID, eventID)
+	return nil
+}
+
+func (r *CachedEvent) GetAttendees(ctx context.Context, eventID int) ([]model.Attendee, error) {
+	return r.attendees.GetAttendees(ctx, eventID)
+}
+
+// SetRSVP changes only the events listed for userID, e.g. hiding declined
+// invitations.
+func (r *CachedEvent) SetRSVP(ctx context.Context, userID, eventID int, status string) error {
+	if err := r.attendees.SetRSVP(ctx, userID, eventID, status); err != nil {
+		return err
+	}
+
+	r.invalidateFor(ctx, userID, userID, eventID)
+	return nil
+}
+
+// cached serves the window of days days from date in loc, which query reads
+// on a miss.
+func (r *CachedEvent) cached(ctx context.Context, method string, userID int, date string, loc *time.Location, days int,
+	query func(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)) ([]model.Event, error) {
+	from, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil || inUnit(ctx) {
+		return query(ctx, userID, date, loc)
+	}
+	to := from.AddDate(0, 0, days)
+
+	// Windows are the same instants whatever the location they were given in
+	key := fmt.Sprintf("events:%d:%s:%s", userID, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+
+	value, ok, err := r.store.Get(ctx, key)
+	if err == nil && ok {
+		var events []model.Event
+		if err = gob.NewDecoder(bytes.NewReader(value)).Decode(&events); err == nil {
+			r.observer.ObserveCache(method, CacheHit)
+			// Empty results decode as nil
+			if events == nil {
+				events = []model.Event{}
+			}
+			return events, nil
+		}
+	}
+	if err != nil {
+		r.observer.ObserveCache(method, CacheError)
+	} else {
+		r.observer.ObserveCache(method, CacheMiss)
+	}
+
+	r.writes.mu.RLock()
+	writes := r.writes.n
+	r.writes.mu.RUnlock()
+
+	events, err := query(ctx, userID, date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values are encoded, so that callers are free to change the events.
+	// gob, unlike JSON, keeps every field.
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(events); err != nil {
+		return events, nil
+	}
+
+	var tags []string
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		tags = append(tags, dayTag(userID, day))
+	}
+
+	// Holding the lock keeps writes from invalidating between the check and
+	// Set
+	r.writes.mu.RLock()
+	defer r.writes.mu.RUnlock()
+	if r.writes.n == writes {
+		_ = r.store.Set(ctx, key, buf.Bytes(), tags)
+	}
+
+	return events, nil
+}
+
+// tagsOf returns the tags of the day the event starts on for its owner and
+// attendees, none if the event can't be read.
+func (r *CachedEvent) tagsOf(ctx context.Context, userID, eventID int) []string {
+	event, err := r.next.GetByID(ctx, userID, eventID)
+	if err != nil {
+		return nil
+	}
+
+	tags := []string{dayTag(userID, event.StartsAt)}
+
+	attendees, err := r.attendees.GetAttendees(ctx, eventID)
+	if err != nil {
+		return tags
+	}
+	for _, attendee := range attendees {
+		tags = append(tags, dayTag(attendee.UserID, event.StartsAt))
+	}
+
+	return tags
+}
+
+// invalidateFor drops the day of the event for userID only. readerID is a
+// user the event is visible to.
+func (r *CachedEvent) invalidateFor(ctx context.Context, userID, readerID, eventID int) {
+	event, err := r.next.GetByID(ctx, readerID, eventID)
+	if err != nil {
+		return
+	}
+
+	r.invalidate(ctx, []string{dayTag(userID, event.StartsAt)})
+}
+
+// invalidate drops tags once the unit of work of ctx commits, as reads of
+// other requests may store the state before the commit until then.
+func (r *CachedEvent) invalidate(ctx context.Context, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	// The request of the unit may be gone when it commits
+	ctx = context.WithoutCancel(ctx)
+	afterCommit(ctx, func() {
+		r.writes.mu.Lock()
+		r.writes.n++
+		r.writes.mu.Unlock()
+
+		if err := r.store.Invalidate(ctx, tags...); err != nil {
+			r.observer.ObserveCache("Invalidate", CacheError)
+		}
+	})
+}
+
+func dayTag(userID int, t time.Time) string {
+	return fmt.Sprintf("events:%d:%s", userID, t.UTC().Format("2006-01-02"))
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/cache"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingCacheObserver struct {
+	results []string
+}
+
+func (o *recordingCacheObserver) ObserveCache(_, result string) {
+	o.results = append(o.results, result)
+}
+
+// failingStore fails every call.
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("unavailable")
+}
+
+func (failingStore) Set(context.Context, string, []byte, []string) error {
+	return errors.New("unavailable")
+}
+
+func (failingStore) Invalidate(context.Context, ...string) error {
+	return errors.New("unavailable")
+}
+
+func TestCachedEvent(t *testing.T) {
+	ctx := context.Background()
+	repos := NewMemoryRepository()
+	observer := &recordingCacheObserver{}
+	repo := NewCachedEvent(repos.Event, repos.Attendee, cache.NewMemoryStore(0, 0), observer)
+
+	ownerID, inviteeID := 1, 2
+	eventID, err := repo.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+	assert.NoError(t, err)
+	otherDayID, err := repo.Create(ctx, ownerID, model.Event{Description: "review", Date: "2026-02-10", Time: "10:00"})
+	assert.NoError(t, err)
+
+	// lookup reads the events of the day and returns the result of the cache
+	lookup := func(userID int, date string) (int, string) {
+		observer.results = nil
+		events, err := repo.GetEventsForDay(ctx, userID, date, time.UTC)
+		assert.NoError(t, err)
+		if !assert.Len(t, observer.results, 1) {
+			return len(events), ""
+		}
+		return len(events), observer.results[0]
+	}
+
+	count, result := lookup(ownerID, "2026-02-04")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, CacheMiss, result)
+	count, result = lookup(ownerID, "2026-02-04")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, CacheHit, result)
+
+	// Cached events are copies
+	events, _ := repo.GetEventsForDay(ctx, ownerID, "2026-02-04", time.UTC)
+	events[0].Render(time.FixedZone("UTC+3", 3*60*60))
+	events, _ = repo.GetEventsForDay(ctx, ownerID, "2026-02-04", time.UTC)
+	assert.Equal(t, "10:00:00", events[0].Time)
+
+	// Other days, users and windows are cached on their own
+	_, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, CacheMiss, result)
+	lookup(inviteeID, "2026-02-04")
+	_, err = repo.GetEventsForWeek(ctx, ownerID, "2026-02-09", time.UTC)
+	assert.NoError(t, err)
+
+	// Writes drop the days of the event only
+	assert.NoError(t, repo.Update(ctx, ownerID, otherDayID, model.Event{Description: "review 2"}))
+	_, result = lookup(ownerID, "2026-02-04")
+	assert.Equal(t, CacheHit, result)
+	_, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, CacheMiss, result)
+	observer.results = nil
+	_, err = repo.GetEventsForWeek(ctx, ownerID, "2026-02-09", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{CacheMiss}, observer.results)
+
+	// Invitations drop the day of the invitee
+	assert.NoError(t, repo.AddAttendee(ctx, ownerID, eventID, inviteeID))
+	count, result = lookup(inviteeID, "2026-02-04")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, CacheMiss, result)
+	_, result = lookup(ownerID, "2026-02-04")
+	assert.Equal(t, CacheHit, result)
+
+	assert.NoError(t, repo.SetRSVP(ctx, inviteeID, eventID, model.RSVPDeclined))
+	count, result = lookup(inviteeID, "2026-02-04")
+	assert.Equal(t, 0, count)
+	assert.Equal(t, CacheMiss, result)
+
+	// Moving an event drops both days for the owner and the attendees
+	assert.NoError(t, repo.Update(ctx, ownerID, eventID, model.Event{Date: "2026-02-10"}))
+	for _, userID := range []int{ownerID, inviteeID} {
+		_, result = lookup(userID, "2026-02-04")
+		assert.Equal(t, CacheMiss, result)
+	}
+	count, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, 2, count)
+	assert.Equal(t, CacheMiss, result)
+
+	// Writes of units of work are dropped once they commit
+	assert.NoError(t, repos.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		assert.NoError(t, repo.Delete(ctx, ownerID, eventID, 0))
+
+		// Reads of the unit bypass the cache
+		observer.results = nil
+		events, err := repo.GetEventsForDay(ctx, ownerID, "2026-02-10", time.UTC)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Empty(t, observer.results)
+
+		// Other requests read the committed state meanwhile
+		_, result = lookup(ownerID, "2026-02-10")
+		assert.Equal(t, CacheHit, result)
+		return nil
+	}))
+	count, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, CacheMiss, result)
+
+	assert.NoError(t, repo.Restore(ctx, ownerID, eventID))
+	count, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, 2, count)
+	assert.Equal(t, CacheMiss, result)
+
+	// Failing stores fall through to the repository
+	repo = NewCachedEvent(repos.Event, repos.Attendee, failingStore{}, observer)
+	count, result = lookup(ownerID, "2026-02-10")
+	assert.Equal(t, 2, count)
+	assert.Equal(t, CacheError, result)
+
+	observer.results = nil
+	assert.NoError(t, repo.Delete(ctx, ownerID, eventID, 0))
+	assert.Equal(t, []string{CacheError}, observer.results)
+}
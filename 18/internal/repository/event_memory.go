@@ -0,0 +1,701 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+// EventMemoryRepository keeps its state in eventMemoryStore, which is shared
+// with the repositories returned by As.
+type EventMemoryRepository struct {
+	*eventMemoryStore
+	// actorID is recorded as the author of changes; zero means the owner of
+	// the event
+	actorID int
+}
+
+type eventMemoryStore struct {
+	mu     sync.RWMutex
+	lastID int
+	events map[int]memoryEvent
+	// trash keeps deleted events apart, so that no query has to skip them
+	trash map[int]memoryEvent
+	// attendees maps event ids to the RSVP status of every invited user
+	attendees map[int]map[int]string
+	// webhooks receives the payloads about changed events if set
+	webhooks *WebhookMemoryRepository
+	// audit receives the changes of events if set
+	audit *AuditMemoryRepository
+	// gate holds writes back while a unit of work runs if set
+	gate *unitGate
+}
+
+type memoryEvent struct {
+	userID       int
+	description  string
+	startsAt     time.Time
+	timezone     string
+	rrule        string
+	exDates      []string
+	remindBefore string
+	duration     string
+	version      int
+	deletedAt    time.Time
+}
+
+func NewEventMemory() *EventMemoryRepository {
+	return &EventMemoryRepository{eventMemoryStore: &eventMemoryStore{
+		events:    make(map[int]memoryEvent),
+		trash:     make(map[int]memoryEvent),
+		attendees: make(map[int]map[int]string),
+	}}
+}
+
+// As returns the repository that records actorID as the author of changes.
+func (r *EventMemoryRepository) As(actorID int) Event {
+	return &EventMemoryRepository{eventMemoryStore: r.eventMemoryStore, actorID: actorID}
+}
+
+func (r *EventMemoryRepository) Create(ctx context.Context, userID int, event model.Event) (int, error) {
+	timezone := event.Timezone
+	if timezone == "" {
+		timezone = model.DefaultTimezone
+	}
+
+	startsAt, err := parseStart(event.Date, event.Time, timezone)
+	if err != nil {
+		return 0, err
+	}
+
+	exDates, err := parseExDates(event.ExDates)
+	if err != nil {
+		return 0, err
+	}
+
+	remindBefore, err := parseDuration(event.RemindBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	duration, err := parseDuration(event.Duration)
+	if err != nil {
+		return 0, err
+	}
+
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	r.events[r.lastID] = memoryEvent{
+		userID:       userID,
+		description:  event.Description,
+		startsAt:     startsAt,
+		timezone:     timezone,
+		rrule:        event.RRule,
+		exDates:      exDates,
+		remindBefore: remindBefore,
+		duration:     duration,
+		version:      1,
+	}
+
+	return r.lastID, r.recordChange(model.AuditCreate, r.lastID, nil)
+}
+
+func (r *EventMemoryRepository) Update(ctx context.Context, userID, eventID int, event model.Event) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[eventID]
+	if !ok || stored.userID != userID {
+		return NotFoundError
+	}
+
+	if event.Version != 0 && event.Version != stored.version {
+		return VersionMismatchError
+	}
+
+	before := stored.toModel(eventID)
+
+	if event.Description != "" {
+		stored.description = event.Description
+	}
+
+	// Date, time and timezone that are not given keep their values on the
+	// wall clock of the event's current timezone.
+	if event.Date != "" || event.Time != "" || event.Timezone != "" {
+		current := stored.toModel(eventID)
+
+		date, eventTime, timezone := current.Date, current.Time, current.Timezone
+		if event.Date != "" {
+			date = event.Date
+		}
+		if event.Time != "" {
+			eventTime = event.Time
+		}
+		if event.Timezone != "" {
+			timezone = event.Timezone
+		}
+
+		startsAt, err := parseStart(date, eventTime, timezone)
+		if err != nil {
+			return err
+		}
+		stored.startsAt, stored.timezone = startsAt, timezone
+	}
+
+	if event.RRule != "" {
+		stored.rrule = event.RRule
+	}
+
+	if event.ExDates != nil {
+		exDates, err := parseExDates(event.ExDates)
+		if err != nil {
+			return err
+		}
+		stored.exDates = exDates
+	}
+
+	if event.RemindBefore != "" {
+		remindBefore, err := parseDuration(event.RemindBefore)
+		if err != nil {
+			return err
+		}
+		stored.remindBefore = remindBefore
+	}
+
+	if event.Duration != "" {
+		duration, err := parseDuration(event.Duration)
+		if err != nil {
+			return err
+		}
+		stored.duration = duration
+	}
+
+	stored.version++
+	r.events[eventID] = stored
+
+	return r.recordChange(model.AuditUpdate, eventID, &before)
+}
+
+func (r *EventMemoryRepository) Delete(ctx context.Context, userID, eventID, version int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[eventID]
+	if !ok || stored.userID != userID {
+		return NotFoundError
+	}
+
+	if version != 0 && version != stored.version {
+		return VersionMismatchError
+	}
+
+	before := stored.toModel(eventID)
+
+	stored.version++
+	stored.deletedAt = time.Now()
+	r.trash[eventID] = stored
+	delete(r.events, eventID)
+
+	return r.recordChange(model.AuditDelete, eventID, &before)
+}
+
+func (r *EventMemoryRepository) Restore(ctx context.Context, userID, eventID int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.trash[eventID]
+	if !ok || stored.userID != userID {
+		return NotFoundError
+	}
+
+	stored.version++
+	stored.deletedAt = time.Time{}
+	r.events[eventID] = stored
+	delete(r.trash, eventID)
+
+	return r.recordChange(model.AuditRestore, eventID, nil)
+}
+
+// GetDeleted returns the trash of userID, most recently deleted first.
+func (r *EventMemoryRepository) GetDeleted(ctx context.Context, userID int) ([]model.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := r.trashed(func(stored memoryEvent) bool {
+		return stored.userID == userID
+	})
+
+	events := make([]model.Event, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		events = append(events, r.trash[ids[i]].toModel(ids[i]))
+	}
+
+	return events, nil
+}
+
+// PurgeDeleted removes at most limit events of all users that were moved to
+// the trash before the given time, oldest first, and records each purge.
+func (r *EventMemoryRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := r.trashed(func(stored memoryEvent) bool {
+		return stored.deletedAt.Before(before)
+	})
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	for _, id := range ids {
+		event := r.trash[id].toModel(id)
+
+		delete(r.trash, id)
+		delete(r.attendees, id)
+
+		if err := r.recordChange(model.AuditPurge, id, &event); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(ids), nil
+}
+
+// trashed returns the ids of the matching events in the trash ordered by
+// deletion. The caller holds the lock.
+func (r *EventMemoryRepository) trashed(match func(memoryEvent) bool) []int {
+	ids := make([]int, 0)
+	for id, stored := range r.trash {
+		if match(stored) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := r.trash[ids[i]], r.trash[ids[j]]
+		if !a.deletedAt.Equal(b.deletedAt) {
+			return a.deletedAt.Before(b.deletedAt)
+		}
+		return ids[i] < ids[j]
+	})
+
+	return ids
+}
+
+func (r *EventMemoryRepository) GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 1)})
+}
+
+func (r *EventMemoryRepository) GetEventsForWeek(ctx context.Context, userID int, firstDate string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", firstDate, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 7)})
+}
+
+func (r *EventMemoryRepository) GetEventsForMonth(ctx context.Context, userID int, firstDate string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", firstDate, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 31)})
+}
+
+// GetEventsInRange returns user's single events starting in [r.From, r.To)
+// after the cursor, ordered the same way as the postgres queries do: by
+// start, then by id.
+func (r *EventMemoryRepository) GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) ([]model.Event, error) {
+	events := r.selectEvents(userID, func(id int, stored memoryEvent) bool {
+		if stored.rrule != "" || stored.startsAt.Before(eventRange.From) || !stored.startsAt.Before(eventRange.To) {
+			return false
+		}
+		return eventRange.After.IsZero() || eventRange.After.Before(model.Event{ID: id, StartsAt: stored.startsAt})
+	})
+
+	if eventRange.Limit > 0 && len(events) > eventRange.Limit {
+		events = events[:eventRange.Limit]
+	}
+
+	return events, nil
+}
+
+// GetEventsOverlapping returns user's single events with a duration that
+// overlap [from, to).
+func (r *EventMemoryRepository) GetEventsOverlapping(ctx context.Context, userID int, from, to time.Time) ([]model.Event, error) {
+	return r.selectEvents(userID, func(id int, stored memoryEvent) bool {
+		if stored.rrule != "" || !stored.startsAt.Before(to) {
+			return false
+		}
+
+		end := stored.toModel(id).End()
+		return end.After(stored.startsAt) && end.After(from)
+	}), nil
+}
+
+// Search mirrors the postgres search: every term has to be a prefix of a
+// word of the description. The rank is the share of the description's words
+// matched by the terms, which orders results close to ts_rank.
+func (r *EventMemoryRepository) Search(ctx context.Context, userID int, search model.EventSearch) ([]model.EventMatch, error) {
+	terms := model.SearchTerms(search.Query)
+	if len(terms) == 0 {
+		return []model.EventMatch{}, nil
+	}
+
+	matches := make([]model.EventMatch, 0)
+	events := r.selectEvents(userID, func(_ int, stored memoryEvent) bool {
+		if !search.From.IsZero() && stored.rrule == "" && stored.startsAt.Before(search.From) {
+			return false
+		}
+		return search.To.IsZero() || stored.startsAt.Before(search.To)
+	})
+
+	for _, event := range events {
+		if rank := searchRank(terms, model.SearchTerms(event.Description)); rank > 0 {
+			matches = append(matches, model.EventMatch{Event: event, Rank: rank})
+		}
+	}
+
+	// events are already ordered by start and id
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Rank > matches[j].Rank
+	})
+
+	if search.Offset >= len(matches) {
+		return []model.EventMatch{}, nil
+	}
+	matches = matches[search.Offset:]
+
+	if search.Limit > 0 && len(matches) > search.Limit {
+		matches = matches[:search.Limit]
+	}
+
+	return matches, nil
+}
+
+// searchRank returns zero unless every term is a prefix of one of words.
+func searchRank(terms, words []string) float64 {
+	matched := make(map[int]bool)
+
+	for _, term := range terms {
+		found := false
+		for i, word := range words {
+			if strings.HasPrefix(word, term) {
+				matched[i], found = true, true
+			}
+		}
+
+		if !found {
+			return 0
+		}
+	}
+
+	return float64(len(matched)) / float64(len(words))
+}
+
+// selectEvents returns the matching events listed for userID: own events and
+// invitations that are not declined.
+func (r *EventMemoryRepository) selectEvents(userID int, match func(int, memoryEvent) bool) []model.Event {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int, 0)
+	for id, stored := range r.events {
+		if stored.userID != userID && !isListed(r.attendees[id][userID]) {
+			continue
+		}
+		if match(id, stored) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := r.events[ids[i]], r.events[ids[j]]
+		if !a.startsAt.Equal(b.startsAt) {
+			return a.startsAt.Before(b.startsAt)
+		}
+		return ids[i] < ids[j]
+	})
+
+	events := make([]model.Event, 0, len(ids))
+
+	for _, id := range ids {
+		events = append(events, r.viewOf(userID, id))
+	}
+
+	return events
+}
+
+// viewOf renders the event as seen by userID, with the RSVP of an attendee.
+func (r *EventMemoryRepository) viewOf(userID, eventID int) model.Event {
+	event := r.events[eventID].toModel(eventID)
+
+	if status, ok := r.attendees[eventID][userID]; ok {
+		event.RSVP, event.OrganizerID = status, event.UserID
+	}
+
+	return event
+}
+
+func isListed(status string) bool {
+	return status == model.RSVPNeedsAction || status == model.RSVPAccepted || status == model.RSVPTentative
+}
+
+func (r *EventMemoryRepository) GetByID(ctx context.Context, userID, eventID int) (model.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.events[eventID]
+	if !ok {
+		return model.Event{}, NotFoundError
+	}
+
+	// Declined invitations stay reachable, so that the RSVP can be changed
+	if _, invited := r.attendees[eventID][userID]; stored.userID != userID && !invited {
+		return model.Event{}, NotFoundError
+	}
+
+	return r.viewOf(userID, eventID), nil
+}
+
+func (r *EventMemoryRepository) GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]model.Event, error) {
+	return r.selectEvents(userID, func(_ int, stored memoryEvent) bool {
+		return stored.rrule != "" && stored.startsAt.Before(before)
+	}), nil
+}
+
+func (r *EventMemoryRepository) AddAttendee(ctx context.Context, ownerID, eventID, userID int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[eventID]
+	if !ok || stored.userID != ownerID {
+		return NotFoundError
+	}
+
+	if _, invited := r.attendees[eventID][userID]; invited {
+		return AttendeeExistsError
+	}
+
+	if r.attendees[eventID] == nil {
+		r.attendees[eventID] = make(map[int]string)
+	}
+	r.attendees[eventID][userID] = model.RSVPNeedsAction
+
+	return nil
+}
+
+func (r *EventMemoryRepository) RemoveAttendee(ctx context.Context, ownerID, eventID, userID int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[eventID]
+	if _, invited := r.attendees[eventID][userID]; !ok || stored.userID != ownerID || !invited {
+		return AttendeeNotFoundError
+	}
+
+	delete(r.attendees[eventID], userID)
+
+	return nil
+}
+
+func (r *EventMemoryRepository) GetAttendees(ctx context.Context, eventID int) ([]model.Attendee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attendees := make([]model.Attendee, 0, len(r.attendees[eventID]))
+	for userID, status := range r.attendees[eventID] {
+		attendees = append(attendees, model.Attendee{EventID: eventID, UserID: userID, Status: status})
+	}
+
+	sort.Slice(attendees, func(i, j int) bool {
+		return attendees[i].UserID < attendees[j].UserID
+	})
+
+	return attendees, nil
+}
+
+func (r *EventMemoryRepository) SetRSVP(ctx context.Context, userID, eventID int, status string) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.events[eventID]
+	if _, invited := r.attendees[eventID][userID]; !exists || !invited {
+		return AttendeeNotFoundError
+	}
+
+	r.attendees[eventID][userID] = status
+
+	return nil
+}
+
+func (r *EventMemoryRepository) AddException(ctx context.Context, userID, eventID int, date string, version int) error {
+	if _, err := parseDate(date); err != nil {
+		return err
+	}
+
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.events[eventID]
+	if !ok || stored.userID != userID || stored.rrule == "" {
+		return NotFoundError
+	}
+
+	if version != 0 && version != stored.version {
+		return VersionMismatchError
+	}
+
+	before := stored.toModel(eventID)
+
+	stored.exDates = append(append([]string{}, stored.exDates...), date)
+	stored.version++
+	r.events[eventID] = stored
+
+	return r.recordChange(model.AuditUpdate, eventID, &before)
+}
+
+// recordChange mirrors EventPostgresRepository.recordChange: it passes the
+// change to audit and webhooks. The caller holds the lock.
+func (r *EventMemoryRepository) recordChange(action string, eventID int, before *model.Event) error {
+	var after *model.Event
+	if stored, ok := r.events[eventID]; ok {
+		event := stored.toModel(eventID)
+		after = &event
+	}
+
+	payloadEvent := after
+	if payloadEvent == nil {
+		payloadEvent = before
+	}
+
+	actorID := r.actorID
+	if actorID == 0 {
+		actorID = payloadEvent.UserID
+	}
+
+	if r.audit != nil {
+		record, err := model.NewAuditRecord(action, payloadEvent.UserID, actorID, eventID, before, after)
+		if err != nil {
+			return err
+		}
+		r.audit.append(record)
+	}
+
+	if r.webhooks == nil {
+		return nil
+	}
+
+	return r.webhooks.enqueue(webhookTypes[action], *payloadEvent)
+}
+
+// toModel renders Date and Time on the wall clock of the event's timezone,
+// which was validated when the event was stored.
+func (e memoryEvent) toModel(id int) model.Event {
+	loc, _ := model.LoadLocation(e.timezone)
+
+	event := model.Event{
+		ID:           id,
+		UserID:       e.userID,
+		Description:  e.description,
+		Timezone:     e.timezone,
+		StartsAt:     e.startsAt.In(loc),
+		RRule:        e.rrule,
+		ExDates:      append([]string{}, e.exDates...),
+		RemindBefore: e.remindBefore,
+		Duration:     e.duration,
+		Version:      e.version,
+	}
+
+	if !e.deletedAt.IsZero() {
+		deletedAt := e.deletedAt
+		event.DeletedAt = &deletedAt
+	}
+
+	event.Render(loc)
+
+	return event
+}
+
+// parseStart resolves date and time on the wall clock of timezone to an
+// instant, as postgres "(date + time) AT TIME ZONE timezone" does.
+func parseStart(date, eventTime, timezone string) (time.Time, error) {
+	loc, err := model.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	parsedDate, err := parseDate(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	parsedTime, err := parseTime(eventTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(parsedDate.Year(), parsedDate.Month(), parsedDate.Day(),
+		parsedTime.Hour(), parsedTime.Minute(), parsedTime.Second(), 0, loc), nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+// parseExDates validates exception dates the way a postgres DATE[] would.
+func parseExDates(exDates []string) ([]string, error) {
+	for _, exDate := range exDates {
+		if _, err := parseDate(exDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return append([]string{}, exDates...), nil
+}
+
+// parseDuration normalizes the duration the way it reads back from postgres,
+// where it is stored in seconds.
+func parseDuration(duration string) (string, error) {
+	if duration == "" {
+		return "", nil
+	}
+
+	offset, err := time.ParseDuration(duration)
+	if err != nil {
+		return "", err
+	}
+
+	return offset.Truncate(time.Second).String(), nil
+}
+
+// parseTime accepts both "15:04" and "15:04:05", as postgres TIME does.
+func parseTime(eventTime string) (time.Time, error) {
+	parsed, err := time.Parse("15:04", eventTime)
+	if err != nil {
+		return time.Parse("15:04:05", eventTime)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,657 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCreateEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	// Valid data
+	id1, err1 := repo.Event.Create(ctx, 1, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-05",
+		Time:        "23:00",
+	})
+
+	// Valid data (ID field is redundant)
+	id2, err2 := repo.Event.Create(ctx, 1, model.Event{
+		ID:          1,
+		Description: "test_data",
+		Date:        "2026-02-05",
+		Time:        "23:00",
+	})
+
+	// Valid data (Time is given with seconds)
+	id3, err3 := repo.Event.Create(ctx, 1, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-05",
+		Time:        "23:00:00",
+	})
+
+	// Invalid data (incorrect Date)
+	id4, err4 := repo.Event.Create(ctx, 1, model.Event{
+		Description: "test_data",
+		Date:        "date",
+		Time:        "23:00",
+	})
+
+	// Invalid data (incorrect Time)
+	id5, err5 := repo.Event.Create(ctx, 1, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-05",
+		Time:        "time",
+	})
+
+	assert.NoError(t, err1)
+	assert.NotEmpty(t, id1)
+
+	assert.NoError(t, err2)
+	assert.NotEmpty(t, id2)
+	assert.NotEqual(t, 1, id2)
+
+	assert.NoError(t, err3)
+	assert.NotEmpty(t, id3)
+
+	assert.Error(t, err4)
+	assert.Empty(t, id4)
+
+	assert.Error(t, err5)
+	assert.Empty(t, id5)
+}
+
+func TestMemoryUpdateEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+
+	id, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+
+	// Valid
+	err1 := repo.Event.Update(ctx, userID, id, model.Event{
+		Description: "my birthday",
+		Date:        "2026-03-24",
+		Time:        "16:00",
+	})
+
+	// Valid (updating just date)
+	err2 := repo.Event.Update(ctx, userID, id, model.Event{
+		Date: "2026-03-25",
+	})
+
+	// Valid (updating just time)
+	err3 := repo.Event.Update(ctx, userID, id, model.Event{
+		Time: "17:00",
+	})
+
+	// Invalid date
+	err4 := repo.Event.Update(ctx, userID, id, model.Event{
+		Description: "my birthday",
+		Date:        "date",
+		Time:        "16:00",
+	})
+
+	// Invalid time
+	err5 := repo.Event.Update(ctx, userID, id, model.Event{
+		Description: "my birthday",
+		Date:        "2026-03-24",
+		Time:        "time",
+	})
+
+	// Invalid eventID
+	err6 := repo.Event.Update(ctx, userID, 12345, model.Event{
+		Description: "my birthday",
+		Date:        "2026-03-24",
+		Time:        "16:00",
+	})
+
+	// Event of another user
+	err7 := repo.Event.Update(ctx, 12345, id, model.Event{
+		Description: "not my birthday",
+	})
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.NoError(t, err3)
+
+	assert.Error(t, err4)
+	assert.Error(t, err5)
+	assert.Error(t, err6)
+	assert.Equal(t, NotFoundError, err6)
+	assert.Equal(t, NotFoundError, err7)
+
+	events, err := repo.Event.GetEventsForDay(ctx, userID, "2026-03-25", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "my birthday", events[0].Description)
+	assert.Equal(t, "17:00:00", events[0].Time)
+}
+
+func TestMemoryDeleteEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	id1, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+	id2, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-06",
+		Time:        "14:00",
+	})
+
+	err1 := repo.Event.Delete(ctx, userID, id1, 0)
+	err2 := repo.Event.Delete(ctx, 12345, id2, 0)
+	err3 := repo.Event.Delete(ctx, userID, 12345, 0)
+	err4 := repo.Event.Delete(ctx, userID, id1, 0)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, NotFoundError, err2)
+	assert.Equal(t, NotFoundError, err3)
+	assert.Equal(t, NotFoundError, err4)
+}
+
+func TestMemoryGetEventsForDay(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	eventDate := "2026-02-05"
+	eventDescription := "test_data"
+
+	repo.Event.Create(ctx, userID, model.Event{Description: eventDescription, Date: eventDate, Time: "23:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: eventDescription, Date: eventDate, Time: "09:30"})
+	repo.Event.Create(ctx, userID, model.Event{Description: eventDescription, Date: "2026-02-06", Time: "00:00"})
+
+	eventsForDay1, err1 := repo.Event.GetEventsForDay(ctx, userID, eventDate, time.UTC)
+	eventsForDay2, err2 := repo.Event.GetEventsForDay(ctx, userID, "2000-01-01", time.UTC)
+	eventsForDay3, err3 := repo.Event.GetEventsForDay(ctx, 12345, eventDate, time.UTC)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, 2, len(eventsForDay1))
+	assert.Equal(t, eventDate, eventsForDay1[0].Date)
+	assert.Equal(t, eventDescription, eventsForDay1[0].Description)
+	assert.Equal(t, "09:30:00", eventsForDay1[0].Time)
+	assert.Equal(t, "23:00:00", eventsForDay1[1].Time)
+
+	assert.NoError(t, err2)
+	assert.Empty(t, eventsForDay2)
+
+	assert.NoError(t, err3)
+	assert.Empty(t, eventsForDay3)
+}
+
+func TestMemoryGetEventsForWeek(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	eventDate := "2026-02-05"
+	stillHaveEventDate := "2026-01-30"
+	noEventDate := "2026-01-29"
+
+	repo.Event.Create(ctx, userID, model.Event{Description: "later", Date: eventDate, Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "earlier", Date: "2026-02-01", Time: "23:00"})
+
+	eventsForWeek1, err1 := repo.Event.GetEventsForWeek(ctx, userID, eventDate, time.UTC)
+	eventsForWeek2, err2 := repo.Event.GetEventsForWeek(ctx, userID, noEventDate, time.UTC)
+	eventsForWeek3, err3 := repo.Event.GetEventsForWeek(ctx, userID, stillHaveEventDate, time.UTC)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, 1, len(eventsForWeek1))
+
+	assert.NoError(t, err2)
+	assert.Equal(t, 1, len(eventsForWeek2))
+	assert.Equal(t, "earlier", eventsForWeek2[0].Description)
+
+	assert.NoError(t, err3)
+	assert.Equal(t, 2, len(eventsForWeek3))
+	assert.Equal(t, "earlier", eventsForWeek3[0].Description)
+	assert.Equal(t, "later", eventsForWeek3[1].Description)
+}
+
+func TestMemoryGetEventsForMonth(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	eventDate := "2026-02-05"
+	stillHaveEventDate := "2026-01-06"
+	noEventDate := "2026-01-05"
+
+	repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: eventDate, Time: "23:00"})
+
+	eventsForMonth1, err1 := repo.Event.GetEventsForMonth(ctx, userID, stillHaveEventDate, time.UTC)
+	eventsForMonth2, err2 := repo.Event.GetEventsForMonth(ctx, userID, noEventDate, time.UTC)
+	_, err3 := repo.Event.GetEventsForMonth(ctx, userID, "date", time.UTC)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, 1, len(eventsForMonth1))
+
+	assert.NoError(t, err2)
+	assert.Empty(t, eventsForMonth2)
+
+	assert.Error(t, err3)
+}
+
+func TestMemoryConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	eventsAmount := 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < eventsAmount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-05", Time: "12:00"})
+			assert.NoError(t, err)
+			assert.NoError(t, repo.Event.Update(ctx, userID, id, model.Event{Time: "13:00"}))
+			_, err = repo.Event.GetEventsForDay(ctx, userID, "2026-02-05", time.UTC)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	events, err := repo.Event.GetEventsForDay(ctx, userID, "2026-02-05", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, eventsAmount, len(events))
+}
+
+func TestMemoryTimezones(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// 23:30 in New York is the next day in UTC
+	id, err1 := repo.Event.Create(ctx, userID, model.Event{
+		Description: "late call",
+		Date:        "2026-03-07",
+		Time:        "23:30",
+		Timezone:    "America/New_York",
+	})
+	_, err2 := repo.Event.Create(ctx, userID, model.Event{
+		Description: "invalid timezone",
+		Date:        "2026-03-07",
+		Time:        "23:30",
+		Timezone:    "Mars/Olympus",
+	})
+
+	assert.NoError(t, err1)
+	assert.Error(t, err2)
+
+	eventsInNewYork, err3 := repo.Event.GetEventsForDay(ctx, userID, "2026-03-07", newYork)
+	eventsInUTC, err4 := repo.Event.GetEventsForDay(ctx, userID, "2026-03-08", time.UTC)
+
+	assert.NoError(t, err3)
+	assert.Equal(t, 1, len(eventsInNewYork))
+	assert.Equal(t, "2026-03-07", eventsInNewYork[0].Date)
+	assert.Equal(t, "23:30:00", eventsInNewYork[0].Time)
+	assert.Equal(t, time.Date(2026, 3, 8, 4, 30, 0, 0, time.UTC), eventsInNewYork[0].StartsAt.UTC())
+
+	assert.NoError(t, err4)
+	assert.Equal(t, 1, len(eventsInUTC))
+
+	// The day of the DST change is 23 hours long in New York
+	assert.NoError(t, repo.Event.Update(ctx, userID, id, model.Event{Date: "2026-03-08", Time: "23:30"}))
+
+	eventsForDSTDay, err5 := repo.Event.GetEventsForDay(ctx, userID, "2026-03-08", newYork)
+	assert.NoError(t, err5)
+	assert.Equal(t, 1, len(eventsForDSTDay))
+	assert.Equal(t, time.Date(2026, 3, 9, 3, 30, 0, 0, time.UTC), eventsForDSTDay[0].StartsAt.UTC())
+
+	// Changing just the timezone keeps the wall clock
+	assert.NoError(t, repo.Event.Update(ctx, userID, id, model.Event{Timezone: "Europe/Moscow"}))
+
+	event, err6 := repo.Event.GetByID(ctx, userID, id)
+	assert.NoError(t, err6)
+	assert.Equal(t, "Europe/Moscow", event.Timezone)
+	assert.Equal(t, "2026-03-08", event.Date)
+	assert.Equal(t, "23:30:00", event.Time)
+	assert.Equal(t, time.Date(2026, 3, 8, 20, 30, 0, 0, time.UTC), event.StartsAt.UTC())
+}
+
+func descriptions(events []model.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Description)
+	}
+	return result
+}
+
+func TestMemoryGetEventsInRange(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "first", Date: "2026-02-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "second", Date: "2026-02-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "last", Date: "2026-02-28", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "out of range", Date: "2026-03-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "series", Date: "2026-02-01", Time: "09:00", RRule: "FREQ=DAILY"})
+	repo.Event.Create(ctx, 12345, model.Event{Description: "other user", Date: "2026-02-01", Time: "10:00"})
+
+	february := model.EventRange{
+		From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	events, err := repo.Event.GetEventsInRange(ctx, userID, february)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "last"}, descriptions(events))
+
+	firstPage := february
+	firstPage.Limit = 1
+	events, err = repo.Event.GetEventsInRange(ctx, userID, firstPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, descriptions(events))
+
+	// Events starting at the same time are ordered by id
+	nextPage := february
+	nextPage.After = model.CursorOf(events[0])
+	events, err = repo.Event.GetEventsInRange(ctx, userID, nextPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second", "last"}, descriptions(events))
+
+	nextPage.After = model.CursorOf(events[1])
+	events, err = repo.Event.GetEventsInRange(ctx, userID, nextPage)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestMemorySearch(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "dentist appointment", Date: "2026-02-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "Dentist", Date: "2026-03-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "call the dentist about the appointment", Date: "2026-01-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "weekly dentistry review", Date: "2025-01-06", Time: "09:00", RRule: "FREQ=WEEKLY"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, 12345, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+
+	matches, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Dentist", "dentist appointment", "weekly dentistry review", "call the dentist about the appointment"}, matchDescriptions(matches))
+	assert.Greater(t, matches[0].Rank, matches[1].Rank)
+
+	// Every term has to match
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "Dentist, appoint!"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dentist appointment", "call the dentist about the appointment"}, matchDescriptions(matches))
+
+	// Recurring events started before from still match
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{
+		Query: "dent",
+		From:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dentist appointment", "weekly dentistry review"}, matchDescriptions(matches))
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist", Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dentist appointment", "weekly dentistry review"}, matchDescriptions(matches))
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist", Offset: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "--"})
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func matchDescriptions(matches []model.EventMatch) []string {
+	result := make([]string, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match.Description)
+	}
+	return result
+}
+
+func TestMemoryGetEventsOverlapping(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "on call", Date: "2026-02-03", Time: "20:00", Duration: "14h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "ends at from", Date: "2026-02-04", Time: "08:00", Duration: "1h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "no duration", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "series", Date: "2026-02-01", Time: "10:00", Duration: "1h", RRule: "FREQ=DAILY"})
+	repo.Event.Create(ctx, 12345, model.Event{Description: "other user", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+
+	events, err := repo.Event.GetEventsOverlapping(ctx, userID, time.Date(2026, 2, 4, 9, 0, 0, 0, time.UTC), time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"on call", "planning"}, descriptions(events))
+	assert.Equal(t, "1h0m0s", events[1].Duration)
+	assert.Equal(t, time.Date(2026, 2, 4, 11, 0, 0, 0, time.UTC), events[1].EndsAt.UTC())
+
+	_, err = repo.Event.Create(ctx, userID, model.Event{Description: "invalid", Date: "2026-02-04", Time: "10:00", Duration: "long"})
+	assert.Error(t, err)
+}
+
+func TestMemoryAttendees(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	ownerID, attendeeID := 1, 2
+	eventID, _ := repo.Event.Create(ctx, ownerID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, ownerID, model.Event{Description: "private", Date: "2026-02-04", Time: "11:00"})
+	seriesID, _ := repo.Event.Create(ctx, ownerID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "09:00", RRule: "FREQ=DAILY"})
+	repo.Event.Create(ctx, attendeeID, model.Event{Description: "own", Date: "2026-02-04", Time: "12:00"})
+
+	err1 := repo.Attendee.AddAttendee(ctx, ownerID, eventID, attendeeID)
+	err2 := repo.Attendee.AddAttendee(ctx, ownerID, eventID, attendeeID)
+	err3 := repo.Attendee.AddAttendee(ctx, attendeeID, eventID, 3)
+	err4 := repo.Attendee.AddAttendee(ctx, ownerID, seriesID, attendeeID)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, AttendeeExistsError, err2)
+	assert.Equal(t, NotFoundError, err3)
+	assert.NoError(t, err4)
+
+	events, err := repo.Event.GetEventsForDay(ctx, attendeeID, "2026-02-04", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"planning", "own"}, descriptions(events))
+	assert.Equal(t, model.RSVPNeedsAction, events[0].RSVP)
+	assert.Equal(t, ownerID, events[0].OrganizerID)
+	assert.Empty(t, events[1].RSVP)
+
+	recurring, err := repo.Event.GetRecurringEvents(ctx, attendeeID, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"stand-up"}, descriptions(recurring))
+
+	// Attendees can neither edit nor delete the event
+	assert.Equal(t, NotFoundError, repo.Event.Update(ctx, attendeeID, eventID, model.Event{Description: "mine"}))
+	assert.Equal(t, NotFoundError, repo.Event.Delete(ctx, attendeeID, eventID, 0))
+
+	// Declined invitations are hidden from the lists but not from GetByID
+	assert.NoError(t, repo.Attendee.SetRSVP(ctx, attendeeID, eventID, model.RSVPDeclined))
+	assert.Equal(t, AttendeeNotFoundError, repo.Attendee.SetRSVP(ctx, 3, eventID, model.RSVPAccepted))
+
+	events, err = repo.Event.GetEventsForDay(ctx, attendeeID, "2026-02-04", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"own"}, descriptions(events))
+
+	event, err := repo.Event.GetByID(ctx, attendeeID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.RSVPDeclined, event.RSVP)
+
+	attendees, err := repo.Attendee.GetAttendees(ctx, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Attendee{{EventID: eventID, UserID: attendeeID, Status: model.RSVPDeclined}}, attendees)
+
+	err5 := repo.Attendee.RemoveAttendee(ctx, attendeeID, eventID, attendeeID)
+	err6 := repo.Attendee.RemoveAttendee(ctx, ownerID, eventID, attendeeID)
+	err7 := repo.Attendee.RemoveAttendee(ctx, ownerID, eventID, attendeeID)
+	_, err8 := repo.Event.GetByID(ctx, attendeeID, eventID)
+
+	assert.Equal(t, AttendeeNotFoundError, err5)
+	assert.NoError(t, err6)
+	assert.Equal(t, AttendeeNotFoundError, err7)
+	assert.Equal(t, NotFoundError, err8)
+
+	// Deleting the event hides it from the attendees, purging it removes the
+	// invitations
+	assert.NoError(t, repo.Event.Delete(ctx, ownerID, seriesID, 0))
+	_, err = repo.Event.GetByID(ctx, attendeeID, seriesID)
+	assert.Equal(t, NotFoundError, err)
+
+	_, err = repo.Trash.PurgeDeleted(ctx, time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	attendees, err = repo.Attendee.GetAttendees(ctx, seriesID)
+	assert.NoError(t, err)
+	assert.Empty(t, attendees)
+}
+
+func TestMemoryEventVersions(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	id, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00", RRule: "FREQ=DAILY"})
+
+	event, err := repo.Event.GetByID(ctx, userID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, event.Version)
+
+	err1 := repo.Event.Update(ctx, userID, id, model.Event{Description: "v2", Version: 1})
+	err2 := repo.Event.Update(ctx, userID, id, model.Event{Description: "stale", Version: 1})
+	err3 := repo.Event.AddException(ctx, userID, id, "2026-02-07", 1)
+	err4 := repo.Event.AddException(ctx, userID, id, "2026-02-07", 2)
+	err5 := repo.Event.Update(ctx, userID, id, model.Event{Description: "unconditional"})
+	err6 := repo.Event.Delete(ctx, userID, id, 3)
+	err7 := repo.Event.Update(ctx, 12345, id, model.Event{Description: "other user", Version: 4})
+
+	assert.NoError(t, err1)
+	assert.Equal(t, VersionMismatchError, err2)
+	assert.Equal(t, VersionMismatchError, err3)
+	assert.NoError(t, err4)
+	assert.NoError(t, err5)
+	assert.Equal(t, VersionMismatchError, err6)
+	assert.Equal(t, NotFoundError, err7)
+
+	event, err = repo.Event.GetByID(ctx, userID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, event.Version)
+	assert.Equal(t, "unconditional", event.Description)
+
+	assert.NoError(t, repo.Event.Delete(ctx, userID, id, 4))
+	assert.Equal(t, NotFoundError, repo.Event.Delete(ctx, userID, id, 4))
+}
+
+func TestMemoryTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID, attendeeID := 1, 2
+	repo.Webhook.CreateWebhook(ctx, userID, "http://localhost/hook", "secret")
+	eventID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00"})
+	keptID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "15:00"})
+	assert.NoError(t, repo.Attendee.AddAttendee(ctx, userID, eventID, attendeeID))
+
+	assert.NoError(t, repo.Event.Delete(ctx, userID, eventID, 0))
+
+	// Deleted events are hidden from everything but the trash of the owner
+	_, err1 := repo.Event.GetByID(ctx, userID, eventID)
+	_, err2 := repo.Event.GetByID(ctx, attendeeID, eventID)
+	err3 := repo.Event.Update(ctx, userID, eventID, model.Event{Description: "changed"})
+	err4 := repo.Event.Delete(ctx, userID, eventID, 0)
+	err5 := repo.Attendee.SetRSVP(ctx, attendeeID, eventID, model.RSVPAccepted)
+	err6 := repo.Event.Restore(ctx, attendeeID, eventID)
+	err7 := repo.Event.Restore(ctx, userID, keptID)
+
+	assert.Equal(t, NotFoundError, err1)
+	assert.Equal(t, NotFoundError, err2)
+	assert.Equal(t, NotFoundError, err3)
+	assert.Equal(t, NotFoundError, err4)
+	assert.Equal(t, AttendeeNotFoundError, err5)
+	assert.Equal(t, NotFoundError, err6)
+	assert.Equal(t, NotFoundError, err7)
+
+	events, err := repo.Event.GetEventsForDay(ctx, userID, "2026-02-06", time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, keptID, events[0].ID)
+	}
+
+	matches, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "test"})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	trash, err := repo.Event.GetDeleted(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, eventID, trash[0].ID)
+		assert.Equal(t, 2, trash[0].Version)
+		assert.NotNil(t, trash[0].DeletedAt)
+	}
+
+	trash, err = repo.Event.GetDeleted(ctx, attendeeID)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// Restored events come back with their attendees
+	assert.NoError(t, repo.Event.Restore(ctx, userID, eventID))
+	event, err := repo.Event.GetByID(ctx, attendeeID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, event.Version)
+	assert.Nil(t, event.DeletedAt)
+
+	// Only events deleted before the given time are purged
+	assert.NoError(t, repo.Event.Delete(ctx, userID, eventID, 0))
+	purged, err := repo.Trash.PurgeDeleted(ctx, time.Now().Add(-time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = repo.Trash.PurgeDeleted(ctx, time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, NotFoundError, repo.Event.Restore(ctx, userID, eventID))
+
+	// Purges are recorded and sent to webhooks like the other changes
+	records, err := repo.Audit.GetEventHistory(ctx, userID, eventID, model.AuditRange{})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, records) {
+		assert.Equal(t, model.AuditPurge, records[0].Action)
+		assert.Equal(t, userID, records[0].ActorID)
+		assert.Equal(t, "null", string(records[0].After))
+
+		var before model.Event
+		assert.NoError(t, json.Unmarshal(records[0].Before, &before))
+		assert.Equal(t, eventID, before.ID)
+	}
+
+	deliveries, err := repo.Outbox.ClaimDueDeliveries(time.Now(), 10, time.Minute)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, deliveries) {
+		var payload model.WebhookPayload
+		assert.NoError(t, json.Unmarshal(deliveries[len(deliveries)-1].Payload, &payload))
+		assert.Equal(t, model.WebhookEventPurged, payload.Type)
+		assert.Equal(t, eventID, payload.Event.ID)
+	}
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,46 +10,163 @@ import (
 	"wbtech_l2/18/internal/model"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-var NotFoundError = errors.New("event with given ID not found")
+var (
+	NotFoundError = errors.New("event with given ID not found")
+	// CanceledError is returned by calls whose context was cancelled, e.g.
+	// because the client of the request went away.
+	CanceledError = errors.New("query was cancelled")
+	// TimeoutError is returned by calls that ran past the deadline of their
+	// context or the query timeout.
+	TimeoutError = errors.New("query deadline exceeded")
+	// VersionMismatchError is returned by writes based on a version of the
+	// event that is no longer current.
+	VersionMismatchError = errors.New("event was changed since the given version")
+)
+
+// eventColumns are selected from eventsOf. rsvp is the answer of the user the
+// events are read for to the invitation, empty for the user's own events.
+const eventColumns = "e.id, e.user_id, e.description, e.starts_at, e.timezone, e.rrule, e.exdates, e.remind_before, e.duration, e.version, e.deleted_at, COALESCE(a.status, '') AS rsvp"
+
+// eventsOf joins invitations of the user $1 to events; listedFor keeps the
+// user's own events and the ones the user is invited to and hasn't declined,
+// leaving out the trash.
+var (
+	eventsOf  = fmt.Sprintf("%s e LEFT JOIN %s a ON a.event_id = e.id AND a.user_id = $1", eventsTable, attendeesTable)
+	listedFor = fmt.Sprintf("(e.user_id = $1 OR a.status IN ('%s', '%s', '%s')) AND e.deleted_at IS NULL", model.RSVPNeedsAction, model.RSVPAccepted, model.RSVPTentative)
+)
 
 type EventPostgresRepository struct {
 	db *sqlx.DB
+	// actorID is recorded as the author of changes; zero means the owner of
+	// the event
+	actorID int
+	queryDeadline
+}
+
+// EventOption configures optional behaviour of EventPostgresRepository.
+type EventOption func(*EventPostgresRepository)
+
+// WithQueryTimeout makes every call fail with TimeoutError once it takes
+// longer than timeout, including the transaction of a write. NewRepository
+// applies it to API keys and the audit log as well.
+func WithQueryTimeout(timeout time.Duration) EventOption {
+	return func(r *EventPostgresRepository) {
+		r.queryTimeout = timeout
+	}
+}
+
+func NewEventPostgres(db *sqlx.DB, opts ...EventOption) *EventPostgresRepository {
+	r := &EventPostgresRepository{db: db}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// As returns the repository that records actorID as the author of changes.
+func (r *EventPostgresRepository) As(actorID int) Event {
+	actor := *r
+	actor.actorID = actorID
+
+	return &actor
+}
+
+// queryDeadline is embedded by the repositories whose calls are bounded by
+// the query timeout.
+type queryDeadline struct {
+	// queryTimeout bounds every call on top of the deadline of its context;
+	// zero leaves calls bounded by the context only
+	queryTimeout time.Duration
+}
+
+// withDeadline bounds the queries of a call by the query timeout. The
+// returned func is deferred with the named error result of the call.
+func (d queryDeadline) withDeadline(ctx context.Context) (context.Context, func(*error)) {
+	cancel := func() {}
+	if d.queryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.queryTimeout)
+	}
+
+	return ctx, func(err *error) {
+		*err = contextError(ctx, *err)
+		cancel()
+	}
+}
+
+// contextError reports err as CanceledError or TimeoutError if the context
+// of the call is done, as the driver fails in different ways then: with the
+// error of the context, with a cancelled statement or a closed connection.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, CanceledError) || errors.Is(err, TimeoutError) {
+		return err
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", TimeoutError, err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%w: %w", CanceledError, err)
+	}
+
+	return err
 }
 
-func NewEventPostgres(db *sqlx.DB) *EventPostgresRepository {
-	return &EventPostgresRepository{db: db}
+// reportContext is deferred with the error result of queries that run
+// without a deadline of their own, passing it through contextError.
+func reportContext(ctx context.Context, err *error) {
+	*err = contextError(ctx, *err)
 }
 
-func (r *EventPostgresRepository) Create(userID int, event model.Event) (int, error) {
-	tx, err := r.db.Begin()
+func (r *EventPostgresRepository) Create(ctx context.Context, userID int, event model.Event) (id int, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	remindBefore, err := durationSeconds(event.RemindBefore)
 	if err != nil {
 		return 0, err
 	}
 
-	var id int
-	query := fmt.Sprintf("INSERT INTO %s (user_id, description, date, time) VALUES ($1, $2, $3, $4) RETURNING id;", eventsTable)
-	row := r.db.QueryRow(query, userID, event.Description, event.Date, event.Time)
-	err = row.Scan(&id)
+	duration, err := durationSeconds(event.Duration)
 	if err != nil {
-		txErr := tx.Rollback()
-		if txErr != nil {
-			return 0, txErr
-		}
 		return 0, err
 	}
 
-	txErr := tx.Commit()
-	if txErr != nil {
-		return 0, txErr
+	timezone := event.Timezone
+	if timezone == "" {
+		timezone = model.DefaultTimezone
+	}
+
+	err = inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("INSERT INTO %s (user_id, description, starts_at, timezone, rrule, exdates, remind_before, duration) VALUES ($1, $2, ($3::date + $4::time) AT TIME ZONE $5, $5, $6, $7, $8, $9) RETURNING id;", eventsTable)
+		row := tx.QueryRowContext(ctx, query, userID, event.Description, event.Date, event.Time, timezone, event.RRule, exDatesArray(event.ExDates), remindBefore, duration)
+		if err := row.Scan(&id); err != nil {
+			return err
+		}
 
+		return r.recordChange(ctx, tx, model.AuditCreate, userID, id, nil)
+	})
+	if err != nil {
+		return 0, err
 	}
+
 	return id, nil
 }
 
-func (r *EventPostgresRepository) Update(eventID int, event model.Event) error {
-	tx, err := r.db.Begin()
+func (r *EventPostgresRepository) Update(ctx context.Context, userID, eventID int, event model.Event) (err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	remindBefore, err := durationSeconds(event.RemindBefore)
+	if err != nil {
+		return err
+	}
+
+	duration, err := durationSeconds(event.Duration)
 	if err != nil {
 		return err
 	}
@@ -60,159 +179,488 @@ func (r *EventPostgresRepository) Update(eventID int, event model.Event) error {
 		args = append(args, event.Description)
 	}
 
-	if event.Date != "" {
-		args = append(args, event.Date)
-		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("date = $%d, ", len(args))}, ""))...)
+	// Date, time and timezone that are not given keep their values on the
+	// wall clock of the event's current timezone.
+	if event.Date != "" || event.Time != "" || event.Timezone != "" {
+		args = append(args, nullString(event.Date), nullString(event.Time), nullString(event.Timezone))
+		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf(
+			"starts_at = (COALESCE($%[1]d::date, (starts_at AT TIME ZONE timezone)::date) + COALESCE($%[2]d::time, (starts_at AT TIME ZONE timezone)::time)) AT TIME ZONE COALESCE($%[3]d, timezone), timezone = COALESCE($%[3]d, timezone), ",
+			len(args)-2, len(args)-1, len(args))}, ""))...)
 	}
 
-	if event.Time != "" {
-		args = append(args, event.Time)
-		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("time = $%d, ", len(args))}, ""))...)
+	if event.RRule != "" {
+		args = append(args, event.RRule)
+		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("rrule = $%d, ", len(args))}, ""))...)
 	}
 
-	toChangeStr := strings.TrimRight(string(fieldsToChange), ", ")
-	args = append(args, eventID)
+	if event.ExDates != nil {
+		args = append(args, exDatesArray(event.ExDates))
+		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("exdates = $%d, ", len(args))}, ""))...)
+	}
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d;", eventsTable, toChangeStr, len(args))
-	affected, err := r.db.Exec(query, args...)
-	if err != nil {
-		txErr := tx.Rollback()
-		if txErr != nil {
-			return txErr
-		}
+	if remindBefore.Valid {
+		args = append(args, remindBefore)
+		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("remind_before = $%d, ", len(args))}, ""))...)
+	}
 
-		return err
+	if duration.Valid {
+		args = append(args, duration)
+		fieldsToChange = append(fieldsToChange, []byte(strings.Join([]string{fmt.Sprintf("duration = $%d, ", len(args))}, ""))...)
 	}
 
-	if temp, _ := affected.RowsAffected(); temp == 0 {
-		txErr := tx.Rollback()
-		if txErr != nil {
-			return txErr
+	fieldsToChange = append(fieldsToChange, []byte("version = version + 1")...)
+	args = append(args, eventID, userID, event.Version)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d AND user_id = $%d AND deleted_at IS NULL AND $%[5]d IN (0, version);", eventsTable, string(fieldsToChange), len(args)-2, len(args)-1, len(args))
+
+	return inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockEvent(ctx, tx, userID, eventID)
+		if err != nil {
+			return err
 		}
 
-		return NotFoundError
-	}
+		affected, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
 
-	txErr := tx.Commit()
-	if txErr != nil {
-		return txErr
+		if temp, _ := affected.RowsAffected(); temp == 0 {
+			return notWritten(ctx, tx, userID, eventID, false)
+		}
+
+		return r.recordChange(ctx, tx, model.AuditUpdate, userID, eventID, &before)
+	})
+}
+
+// Delete moves the event to the trash. Attendees stay with it, while
+// reminders are cancelled by the service.
+func (r *EventPostgresRepository) Delete(ctx context.Context, userID, eventID, version int) (err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	return inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockEvent(ctx, tx, userID, eventID)
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET deleted_at = now(), version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND $3 IN (0, version);", eventsTable)
+		affected, err := tx.ExecContext(ctx, query, eventID, userID, version)
+		if err != nil {
+			return err
+		}
+
+		if temp, _ := affected.RowsAffected(); temp == 0 {
+			return notWritten(ctx, tx, userID, eventID, false)
+		}
+
+		return r.recordChange(ctx, tx, model.AuditDelete, userID, eventID, &before)
+	})
+}
+
+func (r *EventPostgresRepository) GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 1)})
 }
 
-func (r *EventPostgresRepository) Delete(userID, eventID int) error {
-	tx, err := r.db.Begin()
+func (r *EventPostgresRepository) GetEventsForWeek(ctx context.Context, userID int, firstDate string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", firstDate, loc)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s e WHERE e.id = $1 AND e.user_id = $2;", eventsTable)
-	affected, err := r.db.Exec(query, eventID, userID)
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 7)})
+}
+
+func (r *EventPostgresRepository) GetEventsForMonth(ctx context.Context, userID int, firstDate string, loc *time.Location) ([]model.Event, error) {
+	parsedDate, err := time.ParseInLocation("2006-01-02", firstDate, loc)
 	if err != nil {
-		txErr := tx.Rollback()
-		if txErr != nil {
-			return txErr
-		}
+		return nil, err
+	}
 
-		return err
+	return r.GetEventsInRange(ctx, userID, model.EventRange{From: parsedDate, To: parsedDate.AddDate(0, 0, 31)})
+}
+
+// GetEventsInRange returns single events starting in [r.From, r.To) after
+// the cursor, at most r.Limit of them unless it is zero.
+func (r *EventPostgresRepository) GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) (events []model.Event, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	var eventsFromDB []model.EventFromDB
+
+	conditions := []string{listedFor, "e.starts_at >= $2", "e.starts_at < $3", "e.rrule = ''"}
+	args := []any{userID, eventRange.From, eventRange.To}
+
+	if !eventRange.After.IsZero() {
+		args = append(args, eventRange.After.StartsAt, eventRange.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(e.starts_at, e.id) > ($%d, $%d)", len(args)-1, len(args)))
 	}
 
-	if temp, _ := affected.RowsAffected(); temp == 0 {
-		txErr := tx.Rollback()
-		if txErr != nil {
-			return txErr
-		}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY e.starts_at, e.id", eventColumns, eventsOf, strings.Join(conditions, " AND "))
 
-		return NotFoundError
+	if eventRange.Limit > 0 {
+		args = append(args, eventRange.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
 	}
 
-	txErr := tx.Commit()
-	if txErr != nil {
-		return txErr
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &eventsFromDB, query+";", args...); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return eventsFromDBList(eventsFromDB)
 }
 
-func (r *EventPostgresRepository) GetEventsForDay(userID int, date string) ([]model.Event, error) {
+// GetEventsOverlapping returns single events with a duration that overlap
+// [from, to).
+func (r *EventPostgresRepository) GetEventsOverlapping(ctx context.Context, userID int, from, to time.Time) (events []model.Event, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
 	var eventsFromDB []model.EventFromDB
 
-	query := fmt.Sprintf("SELECT e.id, e.description, e.date, e.time FROM %s e WHERE e.user_id = $1 AND e.date = $2 ORDER BY e.time;", eventsTable)
-	if err := r.db.Select(&eventsFromDB, query, userID, date); err != nil {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s AND e.rrule = '' AND e.duration > 0 AND e.starts_at < $3 AND e.starts_at + e.duration * interval '1 second' > $2 ORDER BY e.starts_at, e.id;", eventColumns, eventsOf, listedFor)
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &eventsFromDB, query, userID, from, to); err != nil {
 		return nil, err
 	}
 
-	events := make([]model.Event, 0, len(eventsFromDB))
+	return eventsFromDBList(eventsFromDB)
+}
 
-	for _, dbEvent := range eventsFromDB {
-		event := model.Event{
-			ID:          dbEvent.ID,
-			Description: dbEvent.Description,
-			Date:        dbEvent.Date.Format("2006-01-02"),
-			Time:        dbEvent.Time.Format("15:04:05"),
+// Search matches the "simple" tsvector of the description, so the terms are
+// neither stemmed nor dropped as stop words.
+func (r *EventPostgresRepository) Search(ctx context.Context, userID int, search model.EventSearch) (matches []model.EventMatch, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	terms := model.SearchTerms(search.Query)
+	if len(terms) == 0 {
+		return []model.EventMatch{}, nil
+	}
+
+	for i := range terms {
+		terms[i] += ":*"
+	}
+
+	conditions := []string{listedFor, "e.search_vector @@ q.query"}
+	args := []any{userID, strings.Join(terms, " & ")}
+
+	if !search.From.IsZero() {
+		args = append(args, search.From)
+		conditions = append(conditions, fmt.Sprintf("(e.starts_at >= $%d OR e.rrule <> '')", len(args)))
+	}
+
+	if !search.To.IsZero() {
+		args = append(args, search.To)
+		conditions = append(conditions, fmt.Sprintf("e.starts_at < $%d", len(args)))
+	}
+
+	query := fmt.Sprintf("SELECT %s, ts_rank(e.search_vector, q.query) AS rank FROM %s, to_tsquery('simple', $2) q(query) WHERE %s ORDER BY rank DESC, e.starts_at, e.id", eventColumns, eventsOf, strings.Join(conditions, " AND "))
+
+	if search.Limit > 0 {
+		args = append(args, search.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if search.Offset > 0 {
+		args = append(args, search.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	var matchesFromDB []struct {
+		model.EventFromDB
+		Rank float64 `db:"rank"`
+	}
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &matchesFromDB, query+";", args...); err != nil {
+		return nil, err
+	}
+
+	matches = make([]model.EventMatch, 0, len(matchesFromDB))
+	for _, match := range matchesFromDB {
+		event, err := eventFromDB(match.EventFromDB)
+		if err != nil {
+			return nil, err
 		}
+		matches = append(matches, model.EventMatch{Event: event, Rank: match.Rank})
+	}
 
-		events = append(events, event)
+	return matches, nil
+}
+
+func (r *EventPostgresRepository) GetByID(ctx context.Context, userID, eventID int) (event model.Event, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	return getEvent(ctx, queryer(ctx, r.db), userID, eventID)
+}
+
+func getEvent(ctx context.Context, q sqlx.QueryerContext, userID, eventID int) (model.Event, error) {
+	return selectEvent(ctx, q, "", userID, eventID)
+}
+
+// lockEvent reads the event before a change, locking it until tx ends.
+func lockEvent(ctx context.Context, tx *sqlx.Tx, userID, eventID int) (model.Event, error) {
+	return selectEvent(ctx, tx, " FOR UPDATE OF e", userID, eventID)
+}
+
+func selectEvent(ctx context.Context, q sqlx.QueryerContext, lock string, userID, eventID int) (model.Event, error) {
+	var dbEvent model.EventFromDB
+
+	// Invited users see the event even if they have declined it
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE e.id = $2 AND (e.user_id = $1 OR a.user_id IS NOT NULL) AND e.deleted_at IS NULL%s;", eventColumns, eventsOf, lock)
+	if err := sqlx.GetContext(ctx, q, &dbEvent, query, userID, eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Event{}, NotFoundError
+		}
+		return model.Event{}, err
 	}
 
-	return events, nil
+	return eventFromDB(dbEvent)
 }
 
-func (r *EventPostgresRepository) GetEventsForWeek(userID int, firstDate string) ([]model.Event, error) {
+func (r *EventPostgresRepository) GetRecurringEvents(ctx context.Context, userID int, before time.Time) (events []model.Event, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
 	var eventsFromDB []model.EventFromDB
 
-	parsedDate, err := time.Parse("2006-01-02", firstDate)
-	if err != nil {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s AND e.rrule <> '' AND e.starts_at < $2 ORDER BY e.starts_at, e.id;", eventColumns, eventsOf, listedFor)
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &eventsFromDB, query, userID, before); err != nil {
 		return nil, err
 	}
 
-	lastDate := parsedDate.Add(time.Hour * 24 * 7).Format("2006-01-02")
+	return eventsFromDBList(eventsFromDB)
+}
+
+func (r *EventPostgresRepository) AddException(ctx context.Context, userID, eventID int, date string, version int) (err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	return inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		before, err := lockEvent(ctx, tx, userID, eventID)
+		if err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("UPDATE %s SET exdates = array_append(exdates, $1::date), version = version + 1 WHERE id = $2 AND user_id = $3 AND rrule <> '' AND deleted_at IS NULL AND $4 IN (0, version);", eventsTable)
+		affected, err := tx.ExecContext(ctx, query, date, eventID, userID, version)
+		if err != nil {
+			return err
+		}
+
+		if temp, _ := affected.RowsAffected(); temp == 0 {
+			return notWritten(ctx, tx, userID, eventID, true)
+		}
+
+		return r.recordChange(ctx, tx, model.AuditUpdate, userID, eventID, &before)
+	})
+}
+
+// notWritten tells why a write of the event matched no rows: the event of
+// userID (a recurring one if recurring is set) either doesn't exist, is in
+// the trash or has another version than the write was based on.
+func notWritten(ctx context.Context, tx *sqlx.Tx, userID, eventID int, recurring bool) error {
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND (rrule <> '' OR NOT $3));", eventsTable)
+
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, query, eventID, userID, recurring); err != nil {
+		return err
+	}
+
+	if exists {
+		return VersionMismatchError
+	}
+
+	return NotFoundError
+}
+
+// Restore takes the event of userID out of the trash.
+func (r *EventPostgresRepository) Restore(ctx context.Context, userID, eventID int) (err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	return inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL;", eventsTable)
+		affected, err := tx.ExecContext(ctx, query, eventID, userID)
+		if err != nil {
+			return err
+		}
 
-	query := fmt.Sprintf("SELECT e.id, e.description, e.date, e.time FROM %s e WHERE e.user_id = $1 AND e.date >= $2 AND e.date < $3 ORDER BY e.date, e.time;", eventsTable)
-	if err = r.db.Select(&eventsFromDB, query, userID, firstDate, lastDate); err != nil {
+		if temp, _ := affected.RowsAffected(); temp == 0 {
+			return NotFoundError
+		}
+
+		return r.recordChange(ctx, tx, model.AuditRestore, userID, eventID, nil)
+	})
+}
+
+// GetDeleted returns the trash of userID, most recently deleted first.
+// Events the user is invited to never show up there.
+func (r *EventPostgresRepository) GetDeleted(ctx context.Context, userID int) (events []model.Event, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	var eventsFromDB []model.EventFromDB
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE e.user_id = $1 AND e.deleted_at IS NOT NULL ORDER BY e.deleted_at DESC, e.id;", eventColumns, eventsOf)
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &eventsFromDB, query, userID); err != nil {
 		return nil, err
 	}
 
-	events := make([]model.Event, 0, len(eventsFromDB))
+	return eventsFromDBList(eventsFromDB)
+}
 
-	for _, dbEvent := range eventsFromDB {
-		event := model.Event{
-			ID:          dbEvent.ID,
-			Description: dbEvent.Description,
-			Date:        dbEvent.Date.Format("2006-01-02"),
-			Time:        dbEvent.Time.Format("15:04:05"),
+// PurgeDeleted removes at most limit events of all users that were moved to
+// the trash before the given time, together with their attendees and
+// reminders, and records each purge.
+func (r *EventPostgresRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (purged int, err error) {
+	ctx, done := r.withDeadline(ctx)
+	defer done(&err)
+
+	err = inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		var eventsFromDB []model.EventFromDB
+
+		// Events being restored are skipped rather than waited for
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE e.deleted_at < $2 ORDER BY e.deleted_at, e.id LIMIT $3 FOR UPDATE OF e SKIP LOCKED;", eventColumns, eventsOf)
+		if err := tx.SelectContext(ctx, &eventsFromDB, query, 0, before, limit); err != nil {
+			return err
 		}
 
-		events = append(events, event)
+		events, err := eventsFromDBList(eventsFromDB)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			query = fmt.Sprintf("DELETE FROM %s WHERE id = $1;", eventsTable)
+			if _, err = tx.ExecContext(ctx, query, event.ID); err != nil {
+				return err
+			}
+
+			if err = r.recordChange(ctx, tx, model.AuditPurge, event.UserID, event.ID, &event); err != nil {
+				return err
+			}
+		}
+
+		purged = len(events)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return events, nil
+	return purged, nil
 }
 
-func (r *EventPostgresRepository) GetEventsForMonth(userID int, firstDate string) ([]model.Event, error) {
-	var eventsFromDB []model.EventFromDB
+// webhookTypes are the payload types sent about audit actions.
+var webhookTypes = map[string]string{
+	model.AuditCreate:  model.WebhookEventCreated,
+	model.AuditUpdate:  model.WebhookEventUpdated,
+	model.AuditDelete:  model.WebhookEventDeleted,
+	model.AuditRestore: model.WebhookEventRestored,
+	model.AuditPurge:   model.WebhookEventPurged,
+}
 
-	parsedDate, err := time.Parse("2006-01-02", firstDate)
+// recordChange appends the change made in tx to the audit log and enqueues
+// the webhook payload about it, which describes the event after the change
+// or before it for deleted and purged events. before is nil for created and
+// restored events.
+func (r *EventPostgresRepository) recordChange(ctx context.Context, tx *sqlx.Tx, action string, userID, eventID int, before *model.Event) error {
+	var after *model.Event
+	if action != model.AuditDelete && action != model.AuditPurge {
+		event, err := getEvent(ctx, tx, userID, eventID)
+		if err != nil {
+			return err
+		}
+		after = &event
+	}
+
+	actorID := r.actorID
+	if actorID == 0 {
+		actorID = userID
+	}
+
+	record, err := model.NewAuditRecord(action, userID, actorID, eventID, before, after)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	lastDate := parsedDate.Add(time.Hour * 24 * 31).Format("2006-01-02")
+	if err = appendAudit(ctx, tx, record); err != nil {
+		return err
+	}
 
-	query := fmt.Sprintf("SELECT e.id, e.description, e.date, e.time FROM %s e WHERE e.user_id = $1 AND e.date >= $2 AND e.date < $3 ORDER BY e.date, e.time;", eventsTable)
-	if err = r.db.Select(&eventsFromDB, query, userID, firstDate, lastDate); err != nil {
-		return nil, err
+	payloadEvent := after
+	if payloadEvent == nil {
+		payloadEvent = before
+	}
+
+	return enqueueWebhooks(ctx, tx, webhookTypes[action], *payloadEvent)
+}
+
+// rollback rolls tx back after err. An error of the rollback itself takes
+// precedence.
+func rollback(tx *sqlx.Tx, err error) error {
+	if txErr := tx.Rollback(); txErr != nil {
+		return txErr
+	}
+
+	return err
+}
+
+// eventFromDB converts the row to an event with Date and Time on the wall
+// clock of the event's timezone.
+func eventFromDB(dbEvent model.EventFromDB) (model.Event, error) {
+	loc, err := model.LoadLocation(dbEvent.Timezone)
+	if err != nil {
+		return model.Event{}, err
 	}
 
+	event := model.Event{
+		ID:          dbEvent.ID,
+		UserID:      dbEvent.UserID,
+		Description: dbEvent.Description,
+		Timezone:    dbEvent.Timezone,
+		StartsAt:    dbEvent.StartsAt.In(loc),
+		RRule:       dbEvent.RRule,
+		ExDates:     dbEvent.ExDates,
+		RSVP:        dbEvent.RSVP,
+		Version:     dbEvent.Version,
+	}
+
+	if dbEvent.DeletedAt.Valid {
+		deletedAt := dbEvent.DeletedAt.Time
+		event.DeletedAt = &deletedAt
+	}
+
+	// Users are never invited to their own events
+	if dbEvent.RSVP != "" {
+		event.OrganizerID = dbEvent.UserID
+	}
+
+	if dbEvent.RemindBefore.Valid {
+		event.RemindBefore = (time.Duration(dbEvent.RemindBefore.Int64) * time.Second).String()
+	}
+
+	if dbEvent.Duration.Valid {
+		event.Duration = (time.Duration(dbEvent.Duration.Int64) * time.Second).String()
+	}
+
+	event.Render(loc)
+
+	return event, nil
+}
+
+func eventsFromDBList(eventsFromDB []model.EventFromDB) ([]model.Event, error) {
 	events := make([]model.Event, 0, len(eventsFromDB))
 
 	for _, dbEvent := range eventsFromDB {
-		event := model.Event{
-			ID:          dbEvent.ID,
-			Description: dbEvent.Description,
-			Date:        dbEvent.Date.Format("2006-01-02"),
-			Time:        dbEvent.Time.Format("15:04:05"),
+		event, err := eventFromDB(dbEvent)
+		if err != nil {
+			return nil, err
 		}
 
 		events = append(events, event)
@@ -220,3 +668,31 @@ func (r *EventPostgresRepository) GetEventsForMonth(userID int, firstDate string
 
 	return events, nil
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// exDatesArray keeps the column NOT NULL: pq stores a nil slice as NULL.
+func exDatesArray(exDates []string) pq.StringArray {
+	if exDates == nil {
+		return pq.StringArray{}
+	}
+
+	return exDates
+}
+
+// durationSeconds converts a duration to seconds stored in the remind_before
+// and duration columns. An empty duration is stored as NULL.
+func durationSeconds(duration string) (sql.NullInt64, error) {
+	if duration == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	offset, err := time.ParseDuration(duration)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	return sql.NullInt64{Int64: int64(offset / time.Second), Valid: true}, nil
+}
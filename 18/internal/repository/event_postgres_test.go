@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 	"wbtech_l2/18/internal/model"
@@ -9,20 +11,21 @@ import (
 )
 
 func TestCreateEvent(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
 	repo := NewRepository(db)
 
 	// Valid data
-	id1, err1 := repo.Event.Create(1, model.Event{
+	id1, err1 := repo.Event.Create(ctx, 1, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-05",
 		Time:        "23:00",
 	})
 
 	// Valid data (ID field is redundant)
-	id2, err2 := repo.Event.Create(1, model.Event{
+	id2, err2 := repo.Event.Create(ctx, 1, model.Event{
 		ID:          1,
 		Description: "test_data",
 		Date:        "2026-02-05",
@@ -30,21 +33,21 @@ func TestCreateEvent(t *testing.T) {
 	})
 
 	// Valid data (Time is given with seconds)
-	id3, err3 := repo.Event.Create(1, model.Event{
+	id3, err3 := repo.Event.Create(ctx, 1, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-05",
 		Time:        "23:00",
 	})
 
 	// Invalid data (incorrect Date)
-	id4, err4 := repo.Event.Create(1, model.Event{
+	id4, err4 := repo.Event.Create(ctx, 1, model.Event{
 		Description: "test_data",
 		Date:        "date",
 		Time:        "23:00",
 	})
 
 	// Invalid data (incorrect Time)
-	id5, err5 := repo.Event.Create(1, model.Event{
+	id5, err5 := repo.Event.Create(ctx, 1, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-05",
 		Time:        "time",
@@ -68,6 +71,7 @@ func TestCreateEvent(t *testing.T) {
 }
 
 func TestUpdateEvent(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
@@ -75,55 +79,60 @@ func TestUpdateEvent(t *testing.T) {
 
 	userID := 1
 
-	id, _ := repo.Event.Create(userID, model.Event{
+	id, _ := repo.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
 	})
 
 	// Valid
-	err1 := repo.Event.Update(id, model.Event{
+	err1 := repo.Event.Update(ctx, userID, id, model.Event{
 		Description: "my birthday",
 		Date:        "2026-03-24",
 		Time:        "16:00",
 	})
 
 	// Valid (updating just date)
-	err2 := repo.Event.Update(id, model.Event{
+	err2 := repo.Event.Update(ctx, userID, id, model.Event{
 		Date: "2026-03-24",
 	})
 
 	// Valid (updating just time)
-	err3 := repo.Event.Update(id, model.Event{
+	err3 := repo.Event.Update(ctx, userID, id, model.Event{
 		Time: "16:00",
 	})
 
 	// Valid (updating just description)
-	err4 := repo.Event.Update(id, model.Event{
+	err4 := repo.Event.Update(ctx, userID, id, model.Event{
 		Description: "my birthday",
 	})
 
 	// Invalid date
-	err5 := repo.Event.Update(id, model.Event{
+	err5 := repo.Event.Update(ctx, userID, id, model.Event{
 		Description: "my birthday",
 		Date:        "date",
 		Time:        "16:00",
 	})
 
 	// Invalid time
-	err6 := repo.Event.Update(id, model.Event{
+	err6 := repo.Event.Update(ctx, userID, id, model.Event{
 		Description: "my birthday",
 		Date:        "2026-03-24",
 		Time:        "time",
 	})
 
 	// Invalid eventID
-	err7 := repo.Event.Update(12345, model.Event{
+	err7 := repo.Event.Update(ctx, userID, 12345, model.Event{
 		Description: "my birthday",
 		Date:        "2026-03-24",
 		Time:        "16:00",
 	})
 
+	// Event of another user
+	err8 := repo.Event.Update(ctx, 12345, id, model.Event{
+		Description: "not my birthday",
+	})
+
 	assert.NoError(t, err1)
 	assert.NoError(t, err2)
 	assert.NoError(t, err3)
@@ -133,29 +142,31 @@ func TestUpdateEvent(t *testing.T) {
 	assert.Error(t, err6)
 	assert.Error(t, err7)
 	assert.Equal(t, NotFoundError, err7)
+	assert.Equal(t, NotFoundError, err8)
 }
 
 func TestDeleteEvent(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
 	repo := NewRepository(db)
 
 	userID := 1
-	id1, _ := repo.Event.Create(userID, model.Event{
+	id1, _ := repo.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
 	})
-	id2, _ := repo.Event.Create(userID, model.Event{
+	id2, _ := repo.Event.Create(ctx, userID, model.Event{
 		Description: "test_data",
 		Date:        "2026-02-06",
 		Time:        "14:00",
 	})
 
-	err1 := repo.Event.Delete(userID, id1)
-	err2 := repo.Event.Delete(12345, id2)
-	err3 := repo.Event.Delete(userID, 12345)
+	err1 := repo.Event.Delete(ctx, userID, id1, 0)
+	err2 := repo.Event.Delete(ctx, 12345, id2, 0)
+	err3 := repo.Event.Delete(ctx, userID, 12345, 0)
 
 	assert.NoError(t, err1)
 	assert.Error(t, err2)
@@ -163,6 +174,7 @@ func TestDeleteEvent(t *testing.T) {
 }
 
 func TestGetEventsForDay(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
@@ -175,16 +187,16 @@ func TestGetEventsForDay(t *testing.T) {
 	eventsAmount := 3
 
 	for i := 0; i < eventsAmount; i++ {
-		repo.Event.Create(userID, model.Event{
+		repo.Event.Create(ctx, userID, model.Event{
 			Description: eventDescription,
 			Date:        eventDate,
 			Time:        eventTime,
 		})
 	}
 
-	eventsForDay1, err1 := repo.Event.GetEventsForDay(userID, eventDate)
-	eventsForDay2, err2 := repo.Event.GetEventsForDay(userID, "2000-01-01")
-	eventsForDay3, err3 := repo.Event.GetEventsForDay(12345, eventDate)
+	eventsForDay1, err1 := repo.Event.GetEventsForDay(ctx, userID, eventDate, time.UTC)
+	eventsForDay2, err2 := repo.Event.GetEventsForDay(ctx, userID, "2000-01-01", time.UTC)
+	eventsForDay3, err3 := repo.Event.GetEventsForDay(ctx, 12345, eventDate, time.UTC)
 
 	assert.Equal(t, eventsAmount, len(eventsForDay1))
 
@@ -202,6 +214,7 @@ func TestGetEventsForDay(t *testing.T) {
 }
 
 func TestGetEventsForWeek(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
@@ -216,18 +229,18 @@ func TestGetEventsForWeek(t *testing.T) {
 	eventsAmount := 3
 
 	for i := 0; i < eventsAmount; i++ {
-		repo.Event.Create(userID, model.Event{
+		repo.Event.Create(ctx, userID, model.Event{
 			Description: eventDescription,
 			Date:        eventDate,
 			Time:        eventTime,
 		})
 	}
 
-	eventsForWeek1, err1 := repo.Event.GetEventsForWeek(userID, eventDate)
-	eventsForWeek2, err2 := repo.Event.GetEventsForWeek(userID, "2000-01-01")
-	eventsForWeek3, err3 := repo.Event.GetEventsForWeek(12345, eventDate)
-	eventsForWeek4, err4 := repo.Event.GetEventsForWeek(userID, noEventDate)
-	eventsForWeek5, err5 := repo.Event.GetEventsForWeek(userID, stillHaveEventDate)
+	eventsForWeek1, err1 := repo.Event.GetEventsForWeek(ctx, userID, eventDate, time.UTC)
+	eventsForWeek2, err2 := repo.Event.GetEventsForWeek(ctx, userID, "2000-01-01", time.UTC)
+	eventsForWeek3, err3 := repo.Event.GetEventsForWeek(ctx, 12345, eventDate, time.UTC)
+	eventsForWeek4, err4 := repo.Event.GetEventsForWeek(ctx, userID, noEventDate, time.UTC)
+	eventsForWeek5, err5 := repo.Event.GetEventsForWeek(ctx, userID, stillHaveEventDate, time.UTC)
 
 	assert.Equal(t, eventsAmount, len(eventsForWeek1))
 
@@ -255,6 +268,7 @@ func TestGetEventsForWeek(t *testing.T) {
 }
 
 func TestGetEventsForMonth(t *testing.T) {
+	ctx := context.Background()
 	db, teardown := TestDB(t)
 	defer teardown(eventsTable)
 
@@ -269,18 +283,18 @@ func TestGetEventsForMonth(t *testing.T) {
 	eventsAmount := 3
 
 	for i := 0; i < eventsAmount; i++ {
-		repo.Event.Create(userID, model.Event{
+		repo.Event.Create(ctx, userID, model.Event{
 			Description: eventDescription,
 			Date:        eventDate,
 			Time:        eventTime,
 		})
 	}
 
-	eventsForMonth1, err1 := repo.Event.GetEventsForMonth(userID, eventDate)
-	eventsForMonth2, err2 := repo.Event.GetEventsForMonth(userID, "2000-01-01")
-	eventsForMonth3, err3 := repo.Event.GetEventsForMonth(12345, eventDate)
-	eventsForMonth4, err4 := repo.Event.GetEventsForMonth(userID, noEventDate)
-	eventsForMonth5, err5 := repo.Event.GetEventsForMonth(userID, stillHaveEventDate)
+	eventsForMonth1, err1 := repo.Event.GetEventsForMonth(ctx, userID, eventDate, time.UTC)
+	eventsForMonth2, err2 := repo.Event.GetEventsForMonth(ctx, userID, "2000-01-01", time.UTC)
+	eventsForMonth3, err3 := repo.Event.GetEventsForMonth(ctx, 12345, eventDate, time.UTC)
+	eventsForMonth4, err4 := repo.Event.GetEventsForMonth(ctx, userID, noEventDate, time.UTC)
+	eventsForMonth5, err5 := repo.Event.GetEventsForMonth(ctx, userID, stillHaveEventDate, time.UTC)
 
 	assert.Equal(t, eventsAmount, len(eventsForMonth1))
 
@@ -306,3 +320,353 @@ func TestGetEventsForMonth(t *testing.T) {
 	temp, _ = time.Parse("15:04", eventTime)
 	assert.Equal(t, temp.Format("15:04:05"), eventsForMonth5[0].Time)
 }
+
+func TestGetRecurringEvents(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	seriesID, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY",
+		ExDates:     []string{"2026-02-03"},
+	})
+	repo.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-02",
+		Time:        "14:00",
+	})
+
+	recurring1, err1 := repo.Event.GetRecurringEvents(ctx, userID, time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))
+	recurring2, err2 := repo.Event.GetRecurringEvents(ctx, userID, time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC))
+	eventsForDay, err3 := repo.Event.GetEventsForDay(ctx, userID, "2026-02-02", time.UTC)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, 1, len(recurring1))
+	assert.Equal(t, seriesID, recurring1[0].ID)
+	assert.Equal(t, userID, recurring1[0].UserID)
+	assert.Equal(t, "FREQ=DAILY", recurring1[0].RRule)
+	assert.Equal(t, []string{"2026-02-03"}, recurring1[0].ExDates)
+
+	assert.NoError(t, err2)
+	assert.Empty(t, recurring2)
+
+	assert.NoError(t, err3)
+	assert.Equal(t, 1, len(eventsForDay))
+	assert.Equal(t, "test_data", eventsForDay[0].Description)
+}
+
+func TestAddException(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	seriesID, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY",
+	})
+	singleID, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description: "test_data",
+		Date:        "2026-02-02",
+		Time:        "14:00",
+	})
+
+	err1 := repo.Event.AddException(ctx, userID, seriesID, "2026-02-04", 0)
+	err2 := repo.Event.AddException(ctx, userID, singleID, "2026-02-02", 0)
+	err3 := repo.Event.AddException(ctx, userID, 12345, "2026-02-02", 0)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, NotFoundError, err2)
+	assert.Equal(t, NotFoundError, err3)
+
+	series, err := repo.Event.GetByID(ctx, userID, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-04"}, series.ExDates)
+}
+
+func TestGetEventsInRange(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "first", Date: "2026-02-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "second", Date: "2026-02-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "last", Date: "2026-02-28", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "out of range", Date: "2026-03-01", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "series", Date: "2026-02-01", Time: "09:00", RRule: "FREQ=DAILY"})
+
+	february := model.EventRange{
+		From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	events, err := repo.Event.GetEventsInRange(ctx, userID, february)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "last"}, descriptions(events))
+
+	firstPage := february
+	firstPage.Limit = 1
+	events, err = repo.Event.GetEventsInRange(ctx, userID, firstPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, descriptions(events))
+
+	nextPage := february
+	nextPage.After = model.CursorOf(events[0])
+	events, err = repo.Event.GetEventsInRange(ctx, userID, nextPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second", "last"}, descriptions(events))
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "dentist appointment", Date: "2026-02-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "Dentist", Date: "2026-03-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "call the dentist about the appointment", Date: "2026-01-04", Time: "09:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "weekly dentistry review", Date: "2025-01-06", Time: "09:00", RRule: "FREQ=WEEKLY"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, 12345, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+
+	matches, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Dentist", "dentist appointment", "weekly dentistry review", "call the dentist about the appointment"}, matchDescriptions(matches))
+	for i := 1; i < len(matches); i++ {
+		assert.GreaterOrEqual(t, matches[i-1].Rank, matches[i].Rank)
+	}
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "Dentist, appoint!"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"dentist appointment", "call the dentist about the appointment"}, matchDescriptions(matches))
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{
+		Query: "dent",
+		From:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"dentist appointment", "weekly dentistry review"}, matchDescriptions(matches))
+
+	firstPage, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist", Limit: 2})
+	assert.NoError(t, err)
+	secondPage, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "dentist", Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(firstPage))
+	assert.Equal(t, 2, len(secondPage))
+	assert.ElementsMatch(t, []string{"Dentist", "dentist appointment", "weekly dentistry review", "call the dentist about the appointment"},
+		append(matchDescriptions(firstPage), matchDescriptions(secondPage)...))
+
+	matches, err = repo.Event.Search(ctx, userID, model.EventSearch{Query: "--"})
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestGetEventsOverlapping(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	repo.Event.Create(ctx, userID, model.Event{Description: "on call", Date: "2026-02-03", Time: "20:00", Duration: "14h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "ends at from", Date: "2026-02-04", Time: "08:00", Duration: "1h"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "no duration", Date: "2026-02-04", Time: "10:00"})
+	repo.Event.Create(ctx, userID, model.Event{Description: "series", Date: "2026-02-01", Time: "10:00", Duration: "1h", RRule: "FREQ=DAILY"})
+	repo.Event.Create(ctx, 12345, model.Event{Description: "other user", Date: "2026-02-04", Time: "10:00", Duration: "1h"})
+
+	events, err := repo.Event.GetEventsOverlapping(ctx, userID, time.Date(2026, 2, 4, 9, 0, 0, 0, time.UTC), time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"on call", "planning"}, descriptions(events))
+	assert.Equal(t, "1h0m0s", events[1].Duration)
+	assert.Equal(t, time.Date(2026, 2, 4, 11, 0, 0, 0, time.UTC), events[1].EndsAt.UTC())
+}
+
+func TestEventVersions(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	id, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00", RRule: "FREQ=DAILY"})
+
+	event, err := repo.Event.GetByID(ctx, userID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, event.Version)
+
+	err1 := repo.Event.Update(ctx, userID, id, model.Event{Description: "v2", Version: 1})
+	err2 := repo.Event.Update(ctx, userID, id, model.Event{Description: "stale", Version: 1})
+	err3 := repo.Event.AddException(ctx, userID, id, "2026-02-07", 1)
+	err4 := repo.Event.AddException(ctx, userID, id, "2026-02-07", 2)
+	err5 := repo.Event.Update(ctx, userID, id, model.Event{Description: "unconditional"})
+	err6 := repo.Event.Delete(ctx, userID, id, 3)
+	err7 := repo.Event.Update(ctx, 12345, id, model.Event{Description: "other user", Version: 4})
+
+	assert.NoError(t, err1)
+	assert.Equal(t, VersionMismatchError, err2)
+	assert.Equal(t, VersionMismatchError, err3)
+	assert.NoError(t, err4)
+	assert.NoError(t, err5)
+	assert.Equal(t, VersionMismatchError, err6)
+	assert.Equal(t, NotFoundError, err7)
+
+	event, err = repo.Event.GetByID(ctx, userID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, event.Version)
+	assert.Equal(t, "unconditional", event.Description)
+
+	assert.NoError(t, repo.Event.Delete(ctx, userID, id, 4))
+	assert.Equal(t, NotFoundError, repo.Event.Delete(ctx, userID, id, 4))
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable, webhooksTable, outboxTable, auditTable)
+
+	repo := NewRepository(db)
+
+	userID, attendeeID := 1, 2
+	repo.Webhook.CreateWebhook(ctx, userID, "http://localhost/hook", "secret")
+	eventID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00"})
+	keptID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "15:00"})
+	assert.NoError(t, repo.Attendee.AddAttendee(ctx, userID, eventID, attendeeID))
+
+	assert.NoError(t, repo.Event.Delete(ctx, userID, eventID, 0))
+
+	// Deleted events are hidden from everything but the trash of the owner
+	_, err1 := repo.Event.GetByID(ctx, userID, eventID)
+	_, err2 := repo.Event.GetByID(ctx, attendeeID, eventID)
+	err3 := repo.Event.Update(ctx, userID, eventID, model.Event{Description: "changed"})
+	err4 := repo.Event.Delete(ctx, userID, eventID, 0)
+	err5 := repo.Attendee.SetRSVP(ctx, attendeeID, eventID, model.RSVPAccepted)
+	err6 := repo.Event.Restore(ctx, attendeeID, eventID)
+	err7 := repo.Event.Restore(ctx, userID, keptID)
+
+	assert.Equal(t, NotFoundError, err1)
+	assert.Equal(t, NotFoundError, err2)
+	assert.Equal(t, NotFoundError, err3)
+	assert.Equal(t, NotFoundError, err4)
+	assert.Equal(t, AttendeeNotFoundError, err5)
+	assert.Equal(t, NotFoundError, err6)
+	assert.Equal(t, NotFoundError, err7)
+
+	events, err := repo.Event.GetEventsForDay(ctx, userID, "2026-02-06", time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, keptID, events[0].ID)
+	}
+
+	matches, err := repo.Event.Search(ctx, userID, model.EventSearch{Query: "test"})
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	trash, err := repo.Event.GetDeleted(ctx, userID)
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, eventID, trash[0].ID)
+		assert.Equal(t, 2, trash[0].Version)
+		assert.NotNil(t, trash[0].DeletedAt)
+	}
+
+	trash, err = repo.Event.GetDeleted(ctx, attendeeID)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// Restored events come back with their attendees
+	assert.NoError(t, repo.Event.Restore(ctx, userID, eventID))
+	event, err := repo.Event.GetByID(ctx, attendeeID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, event.Version)
+	assert.Nil(t, event.DeletedAt)
+
+	// Only events deleted before the given time are purged
+	assert.NoError(t, repo.Event.Delete(ctx, userID, eventID, 0))
+	purged, err := repo.Trash.PurgeDeleted(ctx, time.Now().Add(-time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = repo.Trash.PurgeDeleted(ctx, time.Now().Add(time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, NotFoundError, repo.Event.Restore(ctx, userID, eventID))
+
+	// Purges are recorded and sent to webhooks like the other changes
+	records, err := repo.Audit.GetEventHistory(ctx, userID, eventID, model.AuditRange{})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, records) {
+		assert.Equal(t, model.AuditPurge, records[0].Action)
+		assert.Equal(t, userID, records[0].ActorID)
+		assert.Equal(t, "null", string(records[0].After))
+
+		var before model.Event
+		assert.NoError(t, json.Unmarshal(records[0].Before, &before))
+		assert.Equal(t, eventID, before.ID)
+	}
+
+	deliveries, err := repo.Outbox.ClaimDueDeliveries(time.Now(), 10, time.Minute)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, deliveries) {
+		var payload model.WebhookPayload
+		assert.NoError(t, json.Unmarshal(deliveries[len(deliveries)-1].Payload, &payload))
+		assert.Equal(t, model.WebhookEventPurged, payload.Type)
+		assert.Equal(t, eventID, payload.Event.ID)
+	}
+}
+
+func TestQueryDeadline(t *testing.T) {
+	db, teardown := TestDB(t)
+	defer teardown(eventsTable)
+
+	repo := NewRepository(db)
+	userID := 1
+	event := model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00"}
+
+	// Writes of cancelled requests are rolled back
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repo.Event.Create(cancelled, userID, event)
+	assert.ErrorIs(t, err, CanceledError)
+	_, err = repo.Reminder.ClaimDueReminders(cancelled, time.Now(), 10, time.Minute)
+	assert.ErrorIs(t, err, CanceledError)
+
+	events, err := repo.Event.GetEventsForDay(context.Background(), userID, "2026-02-06", time.UTC)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	repo = NewRepository(db, WithQueryTimeout(time.Nanosecond))
+	_, err = repo.Event.GetEventsForDay(context.Background(), userID, "2026-02-06", time.UTC)
+	assert.ErrorIs(t, err, TimeoutError)
+
+	// API keys and the audit log share the timeout of events
+	_, err = repo.APIKey.GetUserIDByAPIKey(context.Background(), "hash")
+	assert.ErrorIs(t, err, TimeoutError)
+	_, err = repo.Audit.GetUserHistory(context.Background(), userID, model.AuditRange{})
+	assert.ErrorIs(t, err, TimeoutError)
+
+	// The deadline of the context applies as well
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = NewRepository(db).Event.GetByID(expired, userID, 1)
+	assert.ErrorIs(t, err, TimeoutError)
+}
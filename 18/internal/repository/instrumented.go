@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+// QueryObserver receives the latency of every call of an instrumented
+// repository together with its result.
+type QueryObserver interface {
+	ObserveQuery(method string, duration time.Duration, err error)
+}
+
+// InstrumentedEvent reports the latency of every call of the wrapped Event
+// to the observer.
+type InstrumentedEvent struct {
+	next     Event
+	observer QueryObserver
+}
+
+func NewInstrumentedEvent(next Event, observer QueryObserver) *InstrumentedEvent {
+	return &InstrumentedEvent{next: next, observer: observer}
+}
+
+// observe is deferred with the named error result, which is read only when
+// the call returns.
+func (r *InstrumentedEvent) observe(method string, start time.Time, err *error) {
+	r.observer.ObserveQuery(method, time.Since(start), *err)
+}
+
+// As keeps the instrumentation of the repository of the actor.
+func (r *InstrumentedEvent) As(actorID int) Event {
+	return NewInstrumentedEvent(r.next.As(actorID), r.observer)
+}
+
+func (r *InstrumentedEvent) Create(ctx context.Context, userID int, event model.Event) (id int, err error) {
+	defer r.observe("Create", time.Now(), &err)
+	return r.next.Create(ctx, userID, event)
+}
+
+func (r *InstrumentedEvent) Update(ctx context.Context, userID, eventID int, event model.Event) (err error) {
+	defer r.observe("Update", time.Now(), &err)
+	return r.next.Update(ctx, userID, eventID, event)
+}
+
+func (r *InstrumentedEvent) Delete(ctx context.Context, userID, eventID, version int) (err error) {
+	defer r.observe("Delete", time.Now(), &err)
+	return r.next.Delete(ctx, userID, eventID, version)
+}
+
+func (r *InstrumentedEvent) GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) (events []model.Event, err error) {
+	defer r.observe("GetEventsForDay", time.Now(), &err)
+	return r.next.GetEventsForDay(ctx, userID, date, loc)
+}
+
+func (r *InstrumentedEvent) GetEventsForWeek(ctx context.Context, userID int, date string, loc *time.Location) (events []model.Event, err error) {
+	defer r.observe("GetEventsForWeek", time.Now(), &err)
+	return r.next.GetEventsForWeek(ctx, userID, date, loc)
+}
+
+func (r *InstrumentedEvent) GetEventsForMonth(ctx context.Context, userID int, date string, loc *time.Location) (events []model.Event, err error) {
+	defer r.observe("GetEventsForMonth", time.Now(), &err)
+	return r.next.GetEventsForMonth(ctx, userID, date, loc)
+}
+
+func (r *InstrumentedEvent) GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) (events []model.Event, err error) {
+	defer r.observe("GetEventsInRange", time.Now(), &err)
+	return r.next.GetEventsInRange(ctx, userID, eventRange)
+}
+
+func (r *InstrumentedEvent) GetEventsOverlapping(ctx context.Context, userID int, from, to time.Time) (events []model.Event, err error) {
+	defer r.observe("GetEventsOverlapping", time.Now(), &err)
+	return r.next.GetEventsOverlapping(ctx, userID, from, to)
+}
+
+func (r *InstrumentedEvent) Search(ctx context.Context, userID int, search model.EventSearch) (matches []model.EventMatch, err error) {
+	defer r.observe("Search", time.Now(), &err)
+	return r.next.Search(ctx, userID, search)
+}
+
+func (r *InstrumentedEvent) GetByID(ctx context.Context, userID, eventID int) (event model.Event, err error) {
+	defer r.observe("GetByID", time.Now(), &err)
+	return r.next.GetByID(ctx, userID, eventID)
+}
+
+func (r *InstrumentedEvent) GetRecurringEvents(ctx context.Context, userID int, before time.Time) (events []model.Event, err error) {
+	defer r.observe("GetRecurringEvents", time.Now(), &err)
+	return r.next.GetRecurringEvents(ctx, userID, before)
+}
+
+func (r *InstrumentedEvent) AddException(ctx context.Context, userID, eventID int, date string, version int) (err error) {
+	defer r.observe("AddException", time.Now(), &err)
+	return r.next.AddException(ctx, userID, eventID, date, version)
+}
+
+func (r *InstrumentedEvent) Restore(ctx context.Context, userID, eventID int) (err error) {
+	defer r.observe("Restore", time.Now(), &err)
+	return r.next.Restore(ctx, userID, eventID)
+}
+
+func (r *InstrumentedEvent) GetDeleted(ctx context.Context, userID int) (events []model.Event, err error) {
+	defer r.observe("GetDeleted", time.Now(), &err)
+	return r.next.GetDeleted(ctx, userID)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	methods []string
+	errs    []error
+}
+
+func (o *recordingObserver) ObserveQuery(method string, _ time.Duration, err error) {
+	o.methods = append(o.methods, method)
+	o.errs = append(o.errs, err)
+}
+
+func TestInstrumentedEvent(t *testing.T) {
+	ctx := context.Background()
+	observer := &recordingObserver{}
+	repo := NewInstrumentedEvent(NewEventMemory(), observer)
+
+	userID := 1
+	id, err := repo.Create(ctx, userID, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+	assert.NoError(t, err)
+
+	event, err := repo.GetByID(ctx, userID, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "dentist", event.Description)
+
+	_, err = repo.GetByID(ctx, userID, 12345)
+	assert.Equal(t, NotFoundError, err)
+
+	assert.Equal(t, []string{"Create", "GetByID", "GetByID"}, observer.methods)
+	assert.Equal(t, []error{nil, nil, NotFoundError}, observer.errs)
+}
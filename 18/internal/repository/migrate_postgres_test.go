@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+	"wbtech_l2/18/internal/migrate"
+	"wbtech_l2/18/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	db, teardown := TestDB(t)
+	defer teardown()
+
+	all, err := migrate.Load(migrations.FS)
+	assert.NoError(t, err)
+
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
+	assert.NoError(t, err)
+
+	// TestDB has applied everything
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	if !assert.Len(t, statuses, len(all)) || len(all) < 2 {
+		return
+	}
+	for _, s := range statuses {
+		assert.True(t, s.Applied, "%d_%s", s.Version, s.Name)
+	}
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	last := statuses[len(statuses)-1]
+	reverted, err := migrator.Down(1)
+	assert.NoError(t, err)
+	if !assert.Len(t, reverted, 1) {
+		return
+	}
+	assert.Equal(t, last.Version, reverted[0].Version)
+
+	statuses, err = migrator.Status()
+	assert.NoError(t, err)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+	assert.True(t, statuses[len(statuses)-2].Applied)
+
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	if !assert.Len(t, applied, 1) {
+		return
+	}
+	assert.Equal(t, last.Version, applied[0].Version)
+
+	var version int64
+	assert.NoError(t, db.Get(&version, "SELECT version FROM schema_migrations"))
+	assert.Equal(t, last.Version, version)
+}
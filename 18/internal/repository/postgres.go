@@ -15,7 +15,16 @@ type Config struct {
 	SSLMode  string
 }
 
-var eventsTable = "event"
+var (
+	eventsTable    = "event"
+	remindersTable = "reminder"
+	apiKeysTable   = "api_key"
+	attendeesTable = "attendee"
+	sharesTable    = "calendar_share"
+	webhooksTable  = "webhook"
+	outboxTable    = "webhook_outbox"
+	auditTable     = "audit_log"
+)
 
 func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 	db, err := sqlx.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+type ReminderMemoryRepository struct {
+	mu        sync.Mutex
+	lastID    int
+	reminders map[int]*memoryReminder
+	// gate holds writes back while a unit of work runs if set
+	gate *unitGate
+}
+
+type memoryReminder struct {
+	reminder    model.Reminder
+	lockedUntil time.Time
+	sent        bool
+}
+
+func NewReminderMemory() *ReminderMemoryRepository {
+	return &ReminderMemoryRepository{reminders: make(map[int]*memoryReminder)}
+}
+
+func (r *ReminderMemoryRepository) ScheduleReminder(ctx context.Context, reminder model.Reminder) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancel(reminder.EventID)
+
+	r.lastID++
+	reminder.ID = r.lastID
+	reminder.Attempts = 0
+	r.reminders[r.lastID] = &memoryReminder{reminder: reminder}
+
+	return nil
+}
+
+func (r *ReminderMemoryRepository) CancelReminder(ctx context.Context, eventID int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cancel(eventID)
+
+	return nil
+}
+
+func (r *ReminderMemoryRepository) ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.Reminder, error) {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]*memoryReminder, 0)
+	for _, stored := range r.reminders {
+		if !stored.sent && !stored.reminder.RemindAt.After(now) && !stored.lockedUntil.After(now) {
+			due = append(due, stored)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].reminder.RemindAt.Equal(due[j].reminder.RemindAt) {
+			return due[i].reminder.RemindAt.Before(due[j].reminder.RemindAt)
+		}
+		return due[i].reminder.ID < due[j].reminder.ID
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	reminders := make([]model.Reminder, 0, len(due))
+	for _, stored := range due {
+		stored.lockedUntil = now.Add(lease)
+		stored.reminder.Attempts++
+		reminders = append(reminders, stored.reminder)
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderMemoryRepository) MarkReminderSent(ctx context.Context, reminderID int) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reminders[reminderID]
+	if !ok || stored.sent {
+		return NotFoundError
+	}
+
+	stored.sent = true
+	stored.lockedUntil = time.Time{}
+
+	return nil
+}
+
+func (r *ReminderMemoryRepository) ReleaseReminder(ctx context.Context, reminderID int, retryAt time.Time) error {
+	defer r.gate.enter(ctx)()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.reminders[reminderID]; ok && !stored.sent {
+		stored.lockedUntil = retryAt
+	}
+
+	return nil
+}
+
+func (r *ReminderMemoryRepository) cancel(eventID int) {
+	for id, stored := range r.reminders {
+		if stored.reminder.EventID == eventID && !stored.sent {
+			delete(r.reminders, id)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ReminderPostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewReminderPostgres(db *sqlx.DB) *ReminderPostgresRepository {
+	return &ReminderPostgresRepository{db: db}
+}
+
+func (r *ReminderPostgresRepository) ScheduleReminder(ctx context.Context, reminder model.Reminder) error {
+	return inTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		query := fmt.Sprintf("DELETE FROM %s WHERE event_id = $1 AND sent_at IS NULL;", remindersTable)
+		if _, err := tx.ExecContext(ctx, query, reminder.EventID); err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf("INSERT INTO %s (event_id, user_id, description, event_at, remind_at) VALUES ($1, $2, $3, $4, $5);", remindersTable)
+		_, err := tx.ExecContext(ctx, query, reminder.EventID, reminder.UserID, reminder.Description, reminder.EventAt, reminder.RemindAt)
+		return err
+	})
+}
+
+func (r *ReminderPostgresRepository) CancelReminder(ctx context.Context, eventID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE event_id = $1 AND sent_at IS NULL;", remindersTable)
+	_, err := queryer(ctx, r.db).ExecContext(ctx, query, eventID)
+	return err
+}
+
+// ClaimDueReminders locks due reminders with SKIP LOCKED, so several
+// instances of the service can poll the same table.
+func (r *ReminderPostgresRepository) ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) (_ []model.Reminder, err error) {
+	defer reportContext(ctx, &err)
+
+	var reminders []model.Reminder
+
+	query := fmt.Sprintf(`UPDATE %[1]s SET locked_until = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM %[1]s
+			WHERE sent_at IS NULL AND remind_at <= $1 AND (locked_until IS NULL OR locked_until <= $1)
+			ORDER BY remind_at LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_id, user_id, description, event_at, remind_at, attempts;`, remindersTable)
+	if err := sqlx.SelectContext(ctx, queryer(ctx, r.db), &reminders, query, now, now.Add(lease), limit); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderPostgresRepository) MarkReminderSent(ctx context.Context, reminderID int) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("UPDATE %s SET sent_at = now(), locked_until = NULL WHERE id = $1 AND sent_at IS NULL;", remindersTable)
+	affected, err := queryer(ctx, r.db).ExecContext(ctx, query, reminderID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return NotFoundError
+	}
+
+	return nil
+}
+
+func (r *ReminderPostgresRepository) ReleaseReminder(ctx context.Context, reminderID int, retryAt time.Time) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("UPDATE %s SET locked_until = $1 WHERE id = $2 AND sent_at IS NULL;", remindersTable)
+	_, err = queryer(ctx, r.db).ExecContext(ctx, query, retryAt, reminderID)
+	return err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimDueReminders(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(remindersTable, eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	eventID, _ := repo.Event.Create(ctx, userID, model.Event{
+		Description:  "test_data",
+		Date:         "2026-02-06",
+		Time:         "14:00",
+		RemindBefore: "1h",
+	})
+
+	now := time.Date(2026, 2, 6, 13, 0, 0, 0, time.UTC)
+	err1 := repo.Reminder.ScheduleReminder(ctx, model.Reminder{
+		EventID:     eventID,
+		UserID:      userID,
+		Description: "test_data",
+		EventAt:     now.Add(time.Hour),
+		RemindAt:    now,
+	})
+
+	// Scheduling again replaces the pending reminder.
+	err2 := repo.Reminder.ScheduleReminder(ctx, model.Reminder{
+		EventID:     eventID,
+		UserID:      userID,
+		Description: "test_data",
+		EventAt:     now.Add(time.Hour),
+		RemindAt:    now,
+	})
+
+	notDue, err3 := repo.Reminder.ClaimDueReminders(ctx, now.Add(-time.Second), 10, time.Minute)
+	claimed, err4 := repo.Reminder.ClaimDueReminders(ctx, now, 10, time.Minute)
+	locked, err5 := repo.Reminder.ClaimDueReminders(ctx, now, 10, time.Minute)
+
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+
+	assert.NoError(t, err3)
+	assert.Empty(t, notDue)
+
+	assert.NoError(t, err4)
+	assert.Equal(t, 1, len(claimed))
+	assert.Equal(t, eventID, claimed[0].EventID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+
+	assert.NoError(t, err5)
+	assert.Empty(t, locked)
+
+	err6 := repo.Reminder.ReleaseReminder(ctx, claimed[0].ID, now)
+	retried, err7 := repo.Reminder.ClaimDueReminders(ctx, now, 10, time.Minute)
+	err8 := repo.Reminder.MarkReminderSent(ctx, claimed[0].ID)
+	err9 := repo.Reminder.MarkReminderSent(ctx, claimed[0].ID)
+	sent, err10 := repo.Reminder.ClaimDueReminders(ctx, now.Add(time.Hour), 10, time.Minute)
+
+	assert.NoError(t, err6)
+	assert.NoError(t, err7)
+	assert.Equal(t, 1, len(retried))
+	assert.Equal(t, 2, retried[0].Attempts)
+
+	assert.NoError(t, err8)
+	assert.Equal(t, NotFoundError, err9)
+
+	assert.NoError(t, err10)
+	assert.Empty(t, sent)
+}
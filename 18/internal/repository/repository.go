@@ -1,26 +1,202 @@
 package repository
 
 import (
+	"context"
+	"time"
 	"wbtech_l2/18/internal/model"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// Event stores events as instants together with their IANA timezone. Every
+// method is scoped to the events userID owns or is invited to, reporting the
+// others as NotFoundError, and fails with CanceledError or TimeoutError once
+// ctx is cancelled or past its deadline.
 type Event interface {
-	Create(userID int, event model.Event) (int, error)
-	Update(eventID int, event model.Event) error
-	Delete(userID, eventID int) error
-	GetEventsForDay(userID int, date string) ([]model.Event, error)
-	GetEventsForWeek(userID int, date string) ([]model.Event, error)
-	GetEventsForMonth(userID int, date string) ([]model.Event, error)
+	// As returns the repository that records actorID as the author of the
+	// changes in Audit instead of the owner.
+	As(actorID int) Event
+
+	// Create, Update, Delete, AddException and Restore are allowed to the
+	// owner only. Each sets a new version of the event and appends a record
+	// to Audit in the same transaction, which is the one of the UnitOfWork of
+	// ctx if there is one.
+	Create(ctx context.Context, userID int, event model.Event) (int, error)
+	// Update fails with VersionMismatchError unless Event.Version is zero or
+	// the current version.
+	Update(ctx context.Context, userID, eventID int, event model.Event) error
+	// Delete moves the event to the trash of its owner, which hides it from
+	// every other method until it is restored. A non-zero version must be the
+	// current one, as for Update.
+	Delete(ctx context.Context, userID, eventID, version int) error
+
+	// GetEventsForDay, GetEventsForWeek and GetEventsForMonth return the
+	// windows of 1, 7 and 31 days from midnight of date in loc, including the
+	// invitations of userID with their RSVP. Declined invitations are only
+	// reachable through GetByID.
+	GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	GetEventsForWeek(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	GetEventsForMonth(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	// GetEventsInRange serves arbitrary and calendar-aligned ranges page by
+	// page.
+	GetEventsInRange(ctx context.Context, userID int, eventRange model.EventRange) ([]model.Event, error)
+	GetEventsOverlapping(ctx context.Context, userID int, from, to time.Time) ([]model.Event, error)
+	// Search matches every term of the query as a word prefix and orders the
+	// matches by rank, then by start.
+	Search(ctx context.Context, userID int, search model.EventSearch) ([]model.EventMatch, error)
+	GetByID(ctx context.Context, userID, eventID int) (model.Event, error)
+	GetRecurringEvents(ctx context.Context, userID int, before time.Time) ([]model.Event, error)
+	// AddException skips date in a recurring event, checking version as
+	// Delete does.
+	AddException(ctx context.Context, userID, eventID int, date string, version int) error
+
+	// Restore takes the event out of the trash of userID.
+	Restore(ctx context.Context, userID, eventID int) error
+	// GetDeleted returns the trash of userID, most recently deleted first.
+	GetDeleted(ctx context.Context, userID int) ([]model.Event, error)
+}
+
+// Audit pages through the changes of events, the latest first.
+// GetUserHistory returns the changes of the events of userID together with
+// the changes userID made in shared calendars.
+type Audit interface {
+	GetEventHistory(ctx context.Context, ownerID, eventID int, auditRange model.AuditRange) ([]model.AuditRecord, error)
+	GetUserHistory(ctx context.Context, userID int, auditRange model.AuditRange) ([]model.AuditRecord, error)
+}
+
+// Trash empties the trashes of all users. Purges are recorded in the audit
+// log and sent to webhooks like the other changes.
+type Trash interface {
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+// Reminder keeps at most one pending reminder per event. Due reminders are
+// claimed for lease and become due again if they are neither marked as sent
+// nor released before it expires. ScheduleReminder and CancelReminder join
+// the unit of work of ctx, so that reminders change together with their
+// events.
+type Reminder interface {
+	ScheduleReminder(ctx context.Context, reminder model.Reminder) error
+	CancelReminder(ctx context.Context, eventID int) error
+	ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.Reminder, error)
+	MarkReminderSent(ctx context.Context, reminderID int) error
+	ReleaseReminder(ctx context.Context, reminderID int, retryAt time.Time) error
+}
+
+// APIKey stores SHA-256 hashes of API keys, never the keys themselves.
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, userID int, keyHash string) (int, error)
+	GetUserIDByAPIKey(ctx context.Context, keyHash string) (int, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+}
+
+// Attendee keeps the invitations to events. Only the owner of an event
+// invites and removes attendees, which is reported as NotFoundError and
+// AttendeeNotFoundError for events of other users. GetAttendees is not
+// scoped: callers check that the event is visible with Event.GetByID first.
+type Attendee interface {
+	AddAttendee(ctx context.Context, ownerID, eventID, userID int) error
+	RemoveAttendee(ctx context.Context, ownerID, eventID, userID int) error
+	GetAttendees(ctx context.Context, eventID int) ([]model.Attendee, error)
+	SetRSVP(ctx context.Context, userID, eventID int, status string) error
+}
+
+// Share keeps the permissions the owners of calendars grant to other users.
+// ShareCalendar replaces the permission of an existing share.
+type Share interface {
+	ShareCalendar(ctx context.Context, ownerID, userID int, permission string) error
+	UnshareCalendar(ctx context.Context, ownerID, userID int) error
+	GetPermission(ctx context.Context, ownerID, userID int) (string, error)
+	GetShares(ctx context.Context, userID int) ([]model.Share, error)
+}
+
+// Webhook keeps the webhooks of users. Every write of Event enqueues a
+// payload about the changed event to each webhook of the event's owner in the
+// same transaction (a transactional outbox).
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userID int, url, secret string) (model.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, webhookID int) error
+	GetWebhooks(ctx context.Context, userID int) ([]model.Webhook, error)
+}
+
+// Outbox hands out the enqueued webhook payloads. Claiming works like
+// Reminder: a claimed delivery becomes due again after the lease unless it
+// is marked as delivered or failed, or released to a later attempt.
+type Outbox interface {
+	ClaimDueDeliveries(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	MarkDelivered(deliveryID int) error
+	ReleaseDelivery(deliveryID int, retryAt time.Time, lastError string) error
+	MarkFailed(deliveryID int, lastError string) error
+}
+
+// UnitOfWork runs fn in a transaction: the writes Event and Reminder make
+// with the context fn is given are committed together if fn returns nil and
+// rolled back otherwise. Writes of Event also append to Audit and the outbox
+// of Webhook in the same transaction. AfterCommit defers fn until the unit
+// of ctx commits and drops it on rollback; outside of units fn is called
+// right away.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+	AfterCommit(ctx context.Context, fn func())
 }
 
 type Repository struct {
 	Event
+	Reminder
+	APIKey
+	Attendee
+	Share
+	Webhook
+	Outbox
+	Trash
+	Audit
+	UnitOfWork
 }
 
-func NewRepository(db *sqlx.DB) *Repository {
+func NewRepository(db *sqlx.DB, opts ...EventOption) *Repository {
+	events := NewEventPostgres(db, opts...)
+	webhooks := NewWebhookPostgres(db)
+
+	// API keys are looked up and the audit log is read on the request path
+	// too
+	apiKeys, audit := NewAPIKeyPostgres(db), NewAuditPostgres(db)
+	apiKeys.queryDeadline, audit.queryDeadline = events.queryDeadline, events.queryDeadline
+
+	return &Repository{
+		Event:      events,
+		Reminder:   NewReminderPostgres(db),
+		APIKey:     apiKeys,
+		Attendee:   NewAttendeePostgres(db),
+		Share:      NewSharePostgres(db),
+		Webhook:    webhooks,
+		Outbox:     webhooks,
+		Trash:      events,
+		Audit:      audit,
+		UnitOfWork: NewUnitOfWorkPostgres(db),
+	}
+}
+
+// NewMemoryRepository shares one EventMemoryRepository between events and
+// attendees, as both are queried together, and connects it to the outbox and
+// the audit log.
+func NewMemoryRepository() *Repository {
+	webhooks := NewWebhookMemory()
+	audit := NewAuditMemory()
+	reminders := NewReminderMemory()
+	events := NewEventMemory()
+	events.webhooks = webhooks
+	events.audit = audit
+
 	return &Repository{
-		Event: NewEventPostgres(db),
+		Event:      events,
+		Reminder:   reminders,
+		APIKey:     NewAPIKeyMemory(),
+		Attendee:   events,
+		Share:      NewShareMemory(),
+		Webhook:    webhooks,
+		Outbox:     webhooks,
+		Trash:      events,
+		Audit:      audit,
+		UnitOfWork: NewUnitOfWorkMemory(events, reminders, webhooks, audit),
 	}
 }
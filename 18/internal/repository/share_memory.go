@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"wbtech_l2/18/internal/model"
+)
+
+type ShareMemoryRepository struct {
+	mu     sync.RWMutex
+	shares map[[2]int]string
+}
+
+func NewShareMemory() *ShareMemoryRepository {
+	return &ShareMemoryRepository{shares: make(map[[2]int]string)}
+}
+
+func (r *ShareMemoryRepository) ShareCalendar(ctx context.Context, ownerID, userID int, permission string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.shares[[2]int{ownerID, userID}] = permission
+
+	return nil
+}
+
+func (r *ShareMemoryRepository) UnshareCalendar(ctx context.Context, ownerID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.shares[[2]int{ownerID, userID}]; !ok {
+		return ShareNotFoundError
+	}
+
+	delete(r.shares, [2]int{ownerID, userID})
+
+	return nil
+}
+
+func (r *ShareMemoryRepository) GetPermission(ctx context.Context, ownerID, userID int) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	permission, ok := r.shares[[2]int{ownerID, userID}]
+	if !ok {
+		return "", ShareNotFoundError
+	}
+
+	return permission, nil
+}
+
+func (r *ShareMemoryRepository) GetShares(ctx context.Context, userID int) ([]model.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shares := make([]model.Share, 0)
+	for key, permission := range r.shares {
+		if key[0] == userID || key[1] == userID {
+			shares = append(shares, model.Share{OwnerID: key[0], UserID: key[1], Permission: permission})
+		}
+	}
+
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].OwnerID != shares[j].OwnerID {
+			return shares[i].OwnerID < shares[j].OwnerID
+		}
+		return shares[i].UserID < shares[j].UserID
+	})
+
+	return shares, nil
+}
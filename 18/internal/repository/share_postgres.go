@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ShareNotFoundError = errors.New("calendar is not shared with the user")
+
+type SharePostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewSharePostgres(db *sqlx.DB) *SharePostgresRepository {
+	return &SharePostgresRepository{db: db}
+}
+
+func (r *SharePostgresRepository) ShareCalendar(ctx context.Context, ownerID, userID int, permission string) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("INSERT INTO %s (owner_id, user_id, permission) VALUES ($1, $2, $3) ON CONFLICT (owner_id, user_id) DO UPDATE SET permission = EXCLUDED.permission;", sharesTable)
+	_, err = r.db.ExecContext(ctx, query, ownerID, userID, permission)
+
+	return err
+}
+
+func (r *SharePostgresRepository) UnshareCalendar(ctx context.Context, ownerID, userID int) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE owner_id = $1 AND user_id = $2;", sharesTable)
+	affected, err := r.db.ExecContext(ctx, query, ownerID, userID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return ShareNotFoundError
+	}
+
+	return nil
+}
+
+func (r *SharePostgresRepository) GetPermission(ctx context.Context, ownerID, userID int) (_ string, err error) {
+	defer reportContext(ctx, &err)
+
+	var permission string
+
+	query := fmt.Sprintf("SELECT s.permission FROM %s s WHERE s.owner_id = $1 AND s.user_id = $2;", sharesTable)
+	if err := r.db.GetContext(ctx, &permission, query, ownerID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ShareNotFoundError
+		}
+		return "", err
+	}
+
+	return permission, nil
+}
+
+func (r *SharePostgresRepository) GetShares(ctx context.Context, userID int) (_ []model.Share, err error) {
+	defer reportContext(ctx, &err)
+
+	shares := make([]model.Share, 0)
+
+	query := fmt.Sprintf("SELECT s.owner_id, s.user_id, s.permission FROM %s s WHERE s.owner_id = $1 OR s.user_id = $1 ORDER BY s.owner_id, s.user_id;", sharesTable)
+	if err := r.db.SelectContext(ctx, &shares, query, userID); err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShares(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(sharesTable)
+
+	repo := NewRepository(db)
+
+	ownerID, userID := 1, 2
+	assert.NoError(t, repo.Share.ShareCalendar(ctx, ownerID, userID, model.PermissionRead))
+	assert.NoError(t, repo.Share.ShareCalendar(ctx, 3, ownerID, model.PermissionRead))
+
+	permission, err := repo.Share.GetPermission(ctx, ownerID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PermissionRead, permission)
+
+	_, err = repo.Share.GetPermission(ctx, userID, ownerID)
+	assert.Equal(t, ShareNotFoundError, err)
+
+	assert.NoError(t, repo.Share.ShareCalendar(ctx, ownerID, userID, model.PermissionWrite))
+	permission, err = repo.Share.GetPermission(ctx, ownerID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PermissionWrite, permission)
+
+	shares, err := repo.Share.GetShares(ctx, ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Share{
+		{OwnerID: ownerID, UserID: userID, Permission: model.PermissionWrite},
+		{OwnerID: 3, UserID: ownerID, Permission: model.PermissionRead},
+	}, shares)
+
+	assert.NoError(t, repo.Share.UnshareCalendar(ctx, ownerID, userID))
+	assert.Equal(t, ShareNotFoundError, repo.Share.UnshareCalendar(ctx, ownerID, userID))
+}
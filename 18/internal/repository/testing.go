@@ -7,6 +7,8 @@ import (
 	"os/exec"
 	"strings"
 	"testing"
+	"wbtech_l2/18/internal/migrate"
+	"wbtech_l2/18/migrations"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
@@ -32,11 +34,16 @@ func TestDB(t *testing.T) (*sqlx.DB, func(...string)) {
 		t.Fatal()
 	}
 
-	_, err = db.Exec("CREATE TABLE IF NOT EXISTS event (id SERIAL PRIMARY KEY, user_id INTEGER NOT NULL, description VARCHAR(255) NOT NULL,  date DATE NOT NULL, time TIME NOT NULL);")
+	// The schema comes from the same migrations as in production
+	migrator, err := migrate.NewMigrator(db, migrations.FS)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if _, err = migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
 	return db, func(tables ...string) {
 		if len(tables) > 0 {
 			_, err = db.Exec(fmt.Sprintf("TRUNCATE %s CASCADE", strings.Join(tables, ", ")))
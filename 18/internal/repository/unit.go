@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// hooksKey keys the commitHooks of a unit of work in its context.
+type hooksKey struct{}
+
+// commitHooks are the funcs registered with AfterCommit in a unit of work.
+type commitHooks struct {
+	mu    sync.Mutex
+	funcs []func()
+}
+
+func withCommitHooks(ctx context.Context) (context.Context, *commitHooks) {
+	hooks := &commitHooks{}
+
+	return context.WithValue(ctx, hooksKey{}, hooks), hooks
+}
+
+// run calls the hooks in the order they were registered.
+func (h *commitHooks) run() {
+	h.mu.Lock()
+	funcs := h.funcs
+	h.mu.Unlock()
+
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+// afterCommit registers fn with the unit of work of ctx or calls it right
+// away outside of units.
+func afterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(hooksKey{}).(*commitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	hooks.funcs = append(hooks.funcs, fn)
+}
+
+// inUnit reports whether ctx belongs to a unit of work, whose reads may see
+// writes that are not committed yet.
+func inUnit(ctx context.Context) bool {
+	_, ok := ctx.Value(hooksKey{}).(*commitHooks)
+	return ok
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// unitKey marks the context of a running in-memory unit of work.
+type unitKey struct{}
+
+// UnitOfWorkMemory rolls units back by restoring the state the repositories
+// had when the unit started. Units run one at a time and writes made outside
+// of units wait for the running one, so that a rollback undoes the writes of
+// its unit only; reads are not held back. Like sequences, the IDs of events,
+// reminders and deliveries are not reused after a rollback.
+type UnitOfWorkMemory struct {
+	gate      unitGate
+	events    *EventMemoryRepository
+	reminders *ReminderMemoryRepository
+	webhooks  *WebhookMemoryRepository
+	audit     *AuditMemoryRepository
+}
+
+// NewUnitOfWorkMemory makes the writes of the repositories wait for its
+// units. The audit log is written by events only.
+func NewUnitOfWorkMemory(events *EventMemoryRepository, reminders *ReminderMemoryRepository, webhooks *WebhookMemoryRepository, audit *AuditMemoryRepository) *UnitOfWorkMemory {
+	u := &UnitOfWorkMemory{events: events, reminders: reminders, webhooks: webhooks, audit: audit}
+	events.gate, reminders.gate, webhooks.gate = &u.gate, &u.gate, &u.gate
+
+	return u
+}
+
+// Do runs fn and restores the repositories if it fails. Units started within
+// fn join the running one.
+func (u *UnitOfWorkMemory) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(unitKey{}) != nil {
+		return fn(ctx)
+	}
+
+	u.gate.mu.Lock()
+	defer u.gate.mu.Unlock()
+
+	restores := []func(){u.events.snapshot(), u.reminders.snapshot(), u.webhooks.snapshot(), u.audit.snapshot()}
+
+	unitCtx, hooks := withCommitHooks(context.WithValue(ctx, unitKey{}, true))
+	if err := fn(unitCtx); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	hooks.run()
+
+	return nil
+}
+
+// unitGate is held by the running unit and by writes made outside of units.
+type unitGate struct {
+	mu sync.Mutex
+}
+
+// enter holds the gate for a write made with ctx and returns the func that
+// releases it. Writes of the running unit pass, as it holds the gate
+// already; so do all writes of repositories without a unit of work. Writes
+// that take no ctx are never made in units.
+func (g *unitGate) enter(ctx context.Context) func() {
+	if g == nil || ctx.Value(unitKey{}) != nil {
+		return func() {}
+	}
+
+	g.mu.Lock()
+	return g.mu.Unlock
+}
+
+func (u *UnitOfWorkMemory) AfterCommit(ctx context.Context, fn func()) {
+	afterCommit(ctx, fn)
+}
+
+// snapshot copies the events, the trash and the invitations and returns the
+// func that puts the copies back.
+func (s *eventMemoryStore) snapshot() func() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events, trash := maps.Clone(s.events), maps.Clone(s.trash)
+	attendees := make(map[int]map[int]string, len(s.attendees))
+	for eventID, statuses := range s.attendees {
+		attendees[eventID] = maps.Clone(statuses)
+	}
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.events, s.trash, s.attendees = events, trash, attendees
+	}
+}
+
+func (r *ReminderMemoryRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reminders := cloneValues(r.reminders)
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.reminders = reminders
+	}
+}
+
+// snapshot covers the outbox only: webhooks are not changed in units.
+func (r *WebhookMemoryRepository) snapshot() func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := cloneValues(r.deliveries)
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.deliveries = deliveries
+	}
+}
+
+// snapshot relies on the log being append-only.
+func (r *AuditMemoryRepository) snapshot() func() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	length := len(r.records)
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.records = r.records[:length]
+	}
+}
+
+// cloneValues copies the values pointers of m point to, which the memory
+// repositories change in place.
+func cloneValues[K comparable, V any](m map[K]*V) map[K]*V {
+	clone := make(map[K]*V, len(m))
+	for key, value := range m {
+		copied := *value
+		clone[key] = &copied
+	}
+
+	return clone
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txKey keys the transaction of a unit of work in its context.
+type txKey struct{}
+
+type UnitOfWorkPostgres struct {
+	db *sqlx.DB
+}
+
+func NewUnitOfWorkPostgres(db *sqlx.DB) *UnitOfWorkPostgres {
+	return &UnitOfWorkPostgres{db: db}
+}
+
+// Do runs fn in a transaction carried by the context fn is given. Units
+// started within fn join the transaction instead of starting their own.
+func (u *UnitOfWorkPostgres) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+
+	unitCtx, hooks := withCommitHooks(context.WithValue(ctx, txKey{}, tx))
+	if err = fn(unitCtx); err != nil {
+		return contextError(ctx, rollback(tx, err))
+	}
+
+	if err = tx.Commit(); err != nil {
+		return contextError(ctx, err)
+	}
+	hooks.run()
+
+	return nil
+}
+
+func (u *UnitOfWorkPostgres) AfterCommit(ctx context.Context, fn func()) {
+	afterCommit(ctx, fn)
+}
+
+// inTx runs fn in the transaction of the unit of work of ctx, which is
+// committed by the unit, or in a transaction of its own.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		return rollback(tx, err)
+	}
+
+	return tx.Commit()
+}
+
+// queryer returns the transaction of the unit of work of ctx, so that reads
+// see the writes made before them in the unit, or db.
+func queryer(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+
+	return db
+}
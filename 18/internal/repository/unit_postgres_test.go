@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	db, teardown := TestDB(t)
+	defer teardown(remindersTable, outboxTable, webhooksTable, auditTable, eventsTable)
+
+	repo := NewRepository(db)
+
+	userID := 1
+	repo.Webhook.CreateWebhook(ctx, userID, "http://localhost/hook", "secret")
+	keptID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "kept", Date: "2026-02-06", Time: "10:00"})
+	remindAt := time.Date(2026, 2, 6, 9, 0, 0, 0, time.UTC)
+
+	var hooks []string
+	failure := errors.New("failure")
+	err := repo.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		repo.UnitOfWork.AfterCommit(ctx, func() { hooks = append(hooks, "rolled back") })
+		eventID, err := repo.Event.Create(ctx, userID, model.Event{Description: "rolled back", Date: "2026-02-06", Time: "12:00"})
+		assert.NoError(t, err)
+		assert.NoError(t, repo.Event.Update(ctx, userID, keptID, model.Event{Description: "changed"}))
+		assert.NoError(t, repo.Reminder.ScheduleReminder(ctx, model.Reminder{EventID: eventID, UserID: userID, RemindAt: remindAt}))
+
+		// Units see their own writes
+		_, err = repo.Event.GetByID(ctx, userID, eventID)
+		assert.NoError(t, err)
+
+		return failure
+	})
+	assert.Equal(t, failure, err)
+
+	// Events, audit records, outbox deliveries and reminders are rolled back
+	// together
+	events, err := repo.Event.GetEventsForDay(ctx, userID, "2026-02-06", time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "kept", events[0].Description)
+		assert.Equal(t, 1, events[0].Version)
+	}
+
+	records, err := repo.Audit.GetUserHistory(ctx, userID, model.AuditRange{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	deliveries, err := repo.Outbox.ClaimDueDeliveries(time.Now(), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	reminders, err := repo.Reminder.ClaimDueReminders(ctx, remindAt, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	// Nested units join the outer one and are committed with it
+	var eventID int
+	err = repo.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		return repo.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+			repo.UnitOfWork.AfterCommit(ctx, func() { hooks = append(hooks, "committed") })
+			assert.Empty(t, hooks)
+
+			eventID, err = repo.Event.Create(ctx, userID, model.Event{Description: "committed", Date: "2026-02-06", Time: "12:00"})
+			if err != nil {
+				return err
+			}
+
+			return repo.Reminder.ScheduleReminder(ctx, model.Reminder{EventID: eventID, UserID: userID, RemindAt: remindAt})
+		})
+	})
+	assert.NoError(t, err)
+	// Hooks run once the outer unit commits and are dropped on rollback
+	assert.Equal(t, []string{"committed"}, hooks)
+	// The ID of the rolled back event is not reused
+	assert.Equal(t, keptID+2, eventID)
+
+	event, err := repo.Event.GetByID(ctx, userID, eventID)
+	assert.NoError(t, err)
+	assert.Equal(t, "committed", event.Description)
+
+	reminders, err = repo.Reminder.ClaimDueReminders(ctx, remindAt, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, reminders, 1)
+}
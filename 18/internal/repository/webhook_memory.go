@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+type WebhookMemoryRepository struct {
+	mu             sync.Mutex
+	lastWebhookID  int
+	webhooks       map[int]model.Webhook
+	lastDeliveryID int
+	deliveries     map[int]*memoryDelivery
+	// gate holds writes to the outbox back while a unit of work runs if set
+	gate *unitGate
+}
+
+type memoryDelivery struct {
+	delivery      model.WebhookDelivery
+	nextAttemptAt time.Time
+	lastError     string
+	done          bool
+}
+
+func NewWebhookMemory() *WebhookMemoryRepository {
+	return &WebhookMemoryRepository{
+		webhooks:   make(map[int]model.Webhook),
+		deliveries: make(map[int]*memoryDelivery),
+	}
+}
+
+func (r *WebhookMemoryRepository) CreateWebhook(ctx context.Context, userID int, url, secret string) (model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWebhookID++
+	webhook := model.Webhook{ID: r.lastWebhookID, UserID: userID, URL: url, Secret: secret, CreatedAt: time.Now()}
+	r.webhooks[webhook.ID] = webhook
+
+	return webhook, nil
+}
+
+func (r *WebhookMemoryRepository) DeleteWebhook(ctx context.Context, userID, webhookID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhook, ok := r.webhooks[webhookID]
+	if !ok || webhook.UserID != userID {
+		return WebhookNotFoundError
+	}
+
+	delete(r.webhooks, webhookID)
+
+	for id, stored := range r.deliveries {
+		if stored.delivery.WebhookID == webhookID {
+			delete(r.deliveries, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *WebhookMemoryRepository) GetWebhooks(ctx context.Context, userID int) ([]model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]model.Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			webhook.Secret = ""
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+func (r *WebhookMemoryRepository) ClaimDueDeliveries(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	defer r.gate.enter(context.Background())()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]*memoryDelivery, 0)
+	for _, stored := range r.deliveries {
+		if !stored.done && !stored.nextAttemptAt.After(now) {
+			due = append(due, stored)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttemptAt.Equal(due[j].nextAttemptAt) {
+			return due[i].nextAttemptAt.Before(due[j].nextAttemptAt)
+		}
+		return due[i].delivery.ID < due[j].delivery.ID
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(due))
+	for _, stored := range due {
+		stored.nextAttemptAt = now.Add(lease)
+		stored.delivery.Attempts++
+		deliveries = append(deliveries, stored.delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookMemoryRepository) MarkDelivered(deliveryID int) error {
+	defer r.gate.enter(context.Background())()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[deliveryID]
+	if !ok || stored.done {
+		return WebhookNotFoundError
+	}
+
+	stored.done, stored.lastError = true, ""
+
+	return nil
+}
+
+func (r *WebhookMemoryRepository) ReleaseDelivery(deliveryID int, retryAt time.Time, lastError string) error {
+	defer r.gate.enter(context.Background())()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.deliveries[deliveryID]; ok && !stored.done {
+		stored.nextAttemptAt, stored.lastError = retryAt, lastError
+	}
+
+	return nil
+}
+
+func (r *WebhookMemoryRepository) MarkFailed(deliveryID int, lastError string) error {
+	defer r.gate.enter(context.Background())()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.deliveries[deliveryID]; ok && !stored.done {
+		stored.done, stored.lastError = true, lastError
+	}
+
+	return nil
+}
+
+// enqueue mirrors enqueueWebhooks. EventMemoryRepository calls it while
+// holding its own lock, so the change and the payloads are seen together.
+func (r *WebhookMemoryRepository) enqueue(payloadType string, event model.Event) error {
+	payload, err := model.NewWebhookPayload(payloadType, event, time.Now())
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, webhook := range r.webhooks {
+		if webhook.UserID != event.UserID {
+			continue
+		}
+
+		r.lastDeliveryID++
+		r.deliveries[r.lastDeliveryID] = &memoryDelivery{
+			delivery: model.WebhookDelivery{
+				ID:        r.lastDeliveryID,
+				WebhookID: webhook.ID,
+				URL:       webhook.URL,
+				Secret:    webhook.Secret,
+				Payload:   payload,
+			},
+			nextAttemptAt: time.Now(),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryWebhookOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+
+	userID := 1
+	webhook, err := repo.Webhook.CreateWebhook(ctx, userID, "http://localhost/hook", "secret")
+	assert.NoError(t, err)
+	repo.Webhook.CreateWebhook(ctx, 12345, "http://localhost/other", "secret")
+
+	webhooks, err := repo.Webhook.GetWebhooks(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+	assert.Empty(t, webhooks[0].Secret)
+
+	eventID, _ := repo.Event.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "10:00", RRule: "FREQ=DAILY"})
+	repo.Event.Update(ctx, userID, eventID, model.Event{Description: "daily"})
+	repo.Event.AddException(ctx, userID, eventID, "2026-02-03", 0)
+	repo.Event.Delete(ctx, userID, eventID, 0)
+
+	// Failed writes enqueue nothing
+	assert.Equal(t, NotFoundError, repo.Event.Update(ctx, 12345, eventID, model.Event{Description: "mine"}))
+
+	now := time.Now()
+	deliveries, err := repo.Outbox.ClaimDueDeliveries(now, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(deliveries))
+
+	types := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		var payload model.WebhookPayload
+		assert.NoError(t, json.Unmarshal(delivery.Payload, &payload))
+		types = append(types, payload.Type)
+
+		assert.Equal(t, webhook.ID, delivery.WebhookID)
+		assert.Equal(t, "secret", delivery.Secret)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, eventID, payload.Event.ID)
+	}
+	assert.Equal(t, []string{model.WebhookEventCreated, model.WebhookEventUpdated, model.WebhookEventUpdated, model.WebhookEventDeleted}, types)
+
+	// Claimed deliveries are leased
+	claimed, err := repo.Outbox.ClaimDueDeliveries(now, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	assert.NoError(t, repo.Outbox.MarkDelivered(deliveries[0].ID))
+	assert.Equal(t, WebhookNotFoundError, repo.Outbox.MarkDelivered(deliveries[0].ID))
+	assert.NoError(t, repo.Outbox.MarkFailed(deliveries[1].ID, "gone"))
+	assert.NoError(t, repo.Outbox.ReleaseDelivery(deliveries[2].ID, now, "timeout"))
+
+	claimed, err = repo.Outbox.ClaimDueDeliveries(now, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(claimed))
+	assert.Equal(t, deliveries[2].ID, claimed[0].ID)
+	assert.Equal(t, 2, claimed[0].Attempts)
+
+	// Deleting the webhook drops its pending deliveries
+	assert.NoError(t, repo.Webhook.DeleteWebhook(ctx, userID, webhook.ID))
+	assert.Equal(t, WebhookNotFoundError, repo.Webhook.DeleteWebhook(ctx, userID, webhook.ID))
+
+	claimed, err = repo.Outbox.ClaimDueDeliveries(now.Add(time.Hour), 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wbtech_l2/18/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var WebhookNotFoundError = errors.New("webhook with given ID not found")
+
+type WebhookPostgresRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookPostgres(db *sqlx.DB) *WebhookPostgresRepository {
+	return &WebhookPostgresRepository{db: db}
+}
+
+func (r *WebhookPostgresRepository) CreateWebhook(ctx context.Context, userID int, url, secret string) (_ model.Webhook, err error) {
+	defer reportContext(ctx, &err)
+
+	webhook := model.Webhook{UserID: userID, URL: url, Secret: secret}
+
+	query := fmt.Sprintf("INSERT INTO %s (user_id, url, secret) VALUES ($1, $2, $3) RETURNING id, created_at;", webhooksTable)
+	if err := r.db.QueryRowContext(ctx, query, userID, url, secret).Scan(&webhook.ID, &webhook.CreatedAt); err != nil {
+		return model.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (r *WebhookPostgresRepository) DeleteWebhook(ctx context.Context, userID, webhookID int) (err error) {
+	defer reportContext(ctx, &err)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2;", webhooksTable)
+	affected, err := r.db.ExecContext(ctx, query, webhookID, userID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return WebhookNotFoundError
+	}
+
+	return nil
+}
+
+func (r *WebhookPostgresRepository) GetWebhooks(ctx context.Context, userID int) (_ []model.Webhook, err error) {
+	defer reportContext(ctx, &err)
+
+	webhooks := make([]model.Webhook, 0)
+
+	query := fmt.Sprintf("SELECT w.id, w.user_id, w.url, w.created_at FROM %s w WHERE w.user_id = $1 ORDER BY w.id;", webhooksTable)
+	if err := r.db.SelectContext(ctx, &webhooks, query, userID); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// ClaimDueDeliveries locks due deliveries with SKIP LOCKED the same way as
+// ClaimDueReminders: a claimed delivery is not due again until the lease
+// expires.
+func (r *WebhookPostgresRepository) ClaimDueDeliveries(now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+
+	query := fmt.Sprintf(`UPDATE %[1]s o SET next_attempt_at = $2, attempts = o.attempts + 1
+		FROM %[2]s w
+		WHERE w.id = o.webhook_id AND o.id IN (
+			SELECT id FROM %[1]s
+			WHERE delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, id LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.id, o.webhook_id, w.url, w.secret, o.payload, o.attempts;`, outboxTable, webhooksTable)
+	if err := r.db.Select(&deliveries, query, now, now.Add(lease), limit); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookPostgresRepository) MarkDelivered(deliveryID int) error {
+	query := fmt.Sprintf("UPDATE %s SET delivered_at = now(), last_error = '' WHERE id = $1 AND delivered_at IS NULL AND failed_at IS NULL;", outboxTable)
+	affected, err := r.db.Exec(query, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	if temp, _ := affected.RowsAffected(); temp == 0 {
+		return WebhookNotFoundError
+	}
+
+	return nil
+}
+
+func (r *WebhookPostgresRepository) ReleaseDelivery(deliveryID int, retryAt time.Time, lastError string) error {
+	query := fmt.Sprintf("UPDATE %s SET next_attempt_at = $1, last_error = $2 WHERE id = $3 AND delivered_at IS NULL AND failed_at IS NULL;", outboxTable)
+	_, err := r.db.Exec(query, retryAt, lastError, deliveryID)
+	return err
+}
+
+func (r *WebhookPostgresRepository) MarkFailed(deliveryID int, lastError string) error {
+	query := fmt.Sprintf("UPDATE %s SET failed_at = now(), last_error = $1 WHERE id = $2 AND delivered_at IS NULL AND failed_at IS NULL;", outboxTable)
+	_, err := r.db.Exec(query, lastError, deliveryID)
+	return err
+}
+
+// enqueueWebhooks adds the payload about the change of the event to the
+// outbox of every webhook of the event's owner. It runs in the transaction
+// of the change, so a payload is enqueued if and only if the change is
+// committed.
+func enqueueWebhooks(ctx context.Context, tx *sqlx.Tx, payloadType string, event model.Event) error {
+	payload, err := model.NewWebhookPayload(payloadType, event, time.Now())
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (webhook_id, payload) SELECT w.id, $2 FROM %s w WHERE w.user_id = $1;", outboxTable, webhooksTable)
+	_, err = tx.ExecContext(ctx, query, event.UserID, payload)
+	return err
+}
@@ -0,0 +1,329 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekDay is a BYDAY entry. N is the ordinal ("1MO" - first Monday, "-1FR" -
+// last Friday) and is only allowed for monthly rules; 0 means every such day.
+type WeekDay struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule is a subset of RFC 5545 RRULE: FREQ, INTERVAL, BYDAY, COUNT and UNTIL.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekDay
+	Count    int
+	Until    time.Time
+}
+
+var weekDays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// maxPeriods stops expansion of rules that can never produce an occurrence,
+// e.g. a monthly rule started on the 31st with BYDAY that never matches.
+const maxPeriods = 100000
+
+func Parse(s string) (*Rule, error) {
+	rule := &Rule{Interval: 1}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty rule")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			switch freq := Frequency(strings.ToUpper(value)); freq {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = freq
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekDay, err := parseWeekDay(strings.ToUpper(day))
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, weekDay)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+
+	if rule.Count != 0 && !rule.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL are mutually exclusive")
+	}
+
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly {
+			return nil, fmt.Errorf("ordinal BYDAY is only supported with FREQ=%s", Monthly)
+		}
+	}
+
+	if len(rule.ByDay) > 0 && rule.Freq == Yearly {
+		return nil, fmt.Errorf("BYDAY is not supported with FREQ=%s", Yearly)
+	}
+
+	return rule, nil
+}
+
+// Between returns the occurrences of the rule started at start that fall into
+// [from, to). COUNT is counted from start, so occurrences before from still
+// use it up. The time of day of every occurrence is the one of start.
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+
+	r.iterate(start, func(occurrence time.Time) bool {
+		if !occurrence.Before(to) {
+			return false
+		}
+
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+
+		return true
+	})
+
+	return occurrences
+}
+
+// Next returns the first occurrence of the rule started at start that is
+// strictly after after. ok is false if the rule has no more occurrences.
+func (r *Rule) Next(start, after time.Time) (next time.Time, ok bool) {
+	r.iterate(start, func(occurrence time.Time) bool {
+		if occurrence.After(after) {
+			next, ok = occurrence, true
+			return false
+		}
+
+		return true
+	})
+
+	return next, ok
+}
+
+// iterate calls yield for every occurrence in order until yield returns false
+// or the rule ends by COUNT or UNTIL.
+func (r *Rule) iterate(start time.Time, yield func(time.Time) bool) {
+	count := 0
+
+	for period := 0; period < maxPeriods; period++ {
+		for _, occurrence := range r.candidates(start, period) {
+			if occurrence.Before(start) {
+				continue
+			}
+
+			if !r.Until.IsZero() && occurrence.After(r.Until) {
+				return
+			}
+
+			if !yield(occurrence) {
+				return
+			}
+
+			count++
+			if r.Count != 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// candidates returns the sorted occurrences of the period-th period (day,
+// week, month or year counted by INTERVAL) after start.
+func (r *Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	hour, minute, sec := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, sec, 0, start.Location())
+	}
+
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		day := start.AddDate(0, 0, step)
+		if r.matchesWeekDay(day.Weekday()) {
+			days = append(days, day)
+		}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			days = append(days, start.AddDate(0, 0, 7*step))
+			break
+		}
+		// Weeks start on Monday (the default WKST).
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*step)
+		for i := 0; i < 7; i++ {
+			day := monday.AddDate(0, 0, i)
+			if r.matchesWeekDay(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := at(start.Year(), start.Month(), 1).AddDate(0, step, 0)
+		if len(r.ByDay) == 0 {
+			day := at(first.Year(), first.Month(), start.Day())
+			if day.Month() == first.Month() {
+				days = append(days, day)
+			}
+			break
+		}
+		days = r.monthDays(first)
+	case Yearly:
+		day := at(start.Year()+step, start.Month(), start.Day())
+		if day.Month() == start.Month() {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
+
+// monthDays returns the days of the month starting at first matching BYDAY.
+func (r *Rule) monthDays(first time.Time) []time.Time {
+	var inMonth []time.Time
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		inMonth = append(inMonth, day)
+	}
+
+	matched := make(map[int]bool)
+	for _, byDay := range r.ByDay {
+		var sameWeekDay []int
+		for i, day := range inMonth {
+			if day.Weekday() == byDay.Day {
+				sameWeekDay = append(sameWeekDay, i)
+			}
+		}
+
+		switch {
+		case byDay.N == 0:
+			for _, i := range sameWeekDay {
+				matched[i] = true
+			}
+		case byDay.N > 0 && byDay.N <= len(sameWeekDay):
+			matched[sameWeekDay[byDay.N-1]] = true
+		case byDay.N < 0 && -byDay.N <= len(sameWeekDay):
+			matched[sameWeekDay[len(sameWeekDay)+byDay.N]] = true
+		}
+	}
+
+	indexes := make([]int, 0, len(matched))
+	for i := range matched {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	days := make([]time.Time, 0, len(indexes))
+	for _, i := range indexes {
+		days = append(days, inMonth[i])
+	}
+
+	return days
+}
+
+func (r *Rule) matchesWeekDay(day time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, byDay := range r.ByDay {
+		if byDay.Day == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseWeekDay(s string) (WeekDay, error) {
+	if len(s) < 2 {
+		return WeekDay{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	day, ok := weekDays[s[len(s)-2:]]
+	if !ok {
+		return WeekDay{}, fmt.Errorf("invalid BYDAY %q", s)
+	}
+
+	var n int
+	if prefix := s[:len(s)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekDay{}, fmt.Errorf("invalid BYDAY %q", s)
+		}
+	}
+
+	return WeekDay{Day: day, N: n}, nil
+}
+
+// parseUntil accepts the DATE and the UTC DATE-TIME forms. A bare date means
+// the whole day is included.
+func parseUntil(s string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", s); err == nil {
+		return until, nil
+	}
+
+	until, err := time.Parse("20060102", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return until.Add(24*time.Hour - time.Second), nil
+}
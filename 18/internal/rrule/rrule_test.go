@@ -0,0 +1,160 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	parsed, _ := time.Parse("2006-01-02 15:04", s)
+	return parsed
+}
+
+func format(occurrences []time.Time) []string {
+	formatted := make([]string, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		formatted = append(formatted, occurrence.Format("2006-01-02 15:04"))
+	}
+	return formatted
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rule    string
+		isValid bool
+	}{
+		{name: "daily", rule: "FREQ=DAILY", isValid: true},
+		{name: "with prefix", rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE", isValid: true},
+		{name: "monthly ordinal", rule: "FREQ=MONTHLY;BYDAY=-1FR", isValid: true},
+		{name: "until date", rule: "FREQ=DAILY;UNTIL=20260301", isValid: true},
+		{name: "until date-time", rule: "FREQ=DAILY;UNTIL=20260301T120000Z", isValid: true},
+		{name: "empty", rule: "", isValid: false},
+		{name: "no freq", rule: "INTERVAL=2", isValid: false},
+		{name: "unknown freq", rule: "FREQ=HOURLY", isValid: false},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0", isValid: false},
+		{name: "count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20260301", isValid: false},
+		{name: "invalid byday", rule: "FREQ=WEEKLY;BYDAY=XX", isValid: false},
+		{name: "ordinal byday in weekly", rule: "FREQ=WEEKLY;BYDAY=1MO", isValid: false},
+		{name: "unsupported part", rule: "FREQ=DAILY;BYHOUR=10", isValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.rule)
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     string
+		start    string
+		from     string
+		to       string
+		expected []string
+	}{
+		{
+			name:     "daily with interval",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			start:    "2026-02-01 09:00",
+			from:     "2026-02-01 00:00",
+			to:       "2026-02-08 00:00",
+			expected: []string{"2026-02-01 09:00", "2026-02-03 09:00", "2026-02-05 09:00", "2026-02-07 09:00"},
+		},
+		{
+			name:     "weekly by day",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE",
+			start:    "2026-02-04 10:00",
+			from:     "2026-02-01 00:00",
+			to:       "2026-02-12 00:00",
+			expected: []string{"2026-02-04 10:00", "2026-02-09 10:00", "2026-02-11 10:00"},
+		},
+		{
+			name:     "count is used up before window",
+			rule:     "FREQ=WEEKLY;COUNT=3",
+			start:    "2026-02-02 10:00",
+			from:     "2026-02-10 00:00",
+			to:       "2026-03-31 00:00",
+			expected: []string{"2026-02-16 10:00"},
+		},
+		{
+			name:     "until is inclusive",
+			rule:     "FREQ=DAILY;UNTIL=20260203",
+			start:    "2026-02-01 23:00",
+			from:     "2026-01-01 00:00",
+			to:       "2026-03-01 00:00",
+			expected: []string{"2026-02-01 23:00", "2026-02-02 23:00", "2026-02-03 23:00"},
+		},
+		{
+			name:     "monthly skips short months",
+			rule:     "FREQ=MONTHLY",
+			start:    "2026-01-31 08:00",
+			from:     "2026-01-01 00:00",
+			to:       "2026-05-01 00:00",
+			expected: []string{"2026-01-31 08:00", "2026-03-31 08:00"},
+		},
+		{
+			name:     "monthly last friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2",
+			start:    "2026-02-01 18:00",
+			from:     "2026-01-01 00:00",
+			to:       "2027-01-01 00:00",
+			expected: []string{"2026-02-27 18:00", "2026-03-27 18:00"},
+		},
+		{
+			name:     "yearly leap day",
+			rule:     "FREQ=YEARLY",
+			start:    "2024-02-29 12:00",
+			from:     "2024-01-01 00:00",
+			to:       "2029-01-01 00:00",
+			expected: []string{"2024-02-29 12:00", "2028-02-29 12:00"},
+		},
+		{
+			name:     "window before start",
+			rule:     "FREQ=DAILY",
+			start:    "2026-02-01 09:00",
+			from:     "2026-01-01 00:00",
+			to:       "2026-01-31 00:00",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			assert.NoError(t, err)
+
+			occurrences := rule.Between(date(tc.start), date(tc.from), date(tc.to))
+			assert.Equal(t, tc.expected, format(occurrences))
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3")
+	assert.NoError(t, err)
+
+	start := date("2026-02-02 10:00")
+
+	next1, ok1 := rule.Next(start, date("2026-01-01 00:00"))
+	next2, ok2 := rule.Next(start, date("2026-02-02 10:00"))
+	next3, ok3 := rule.Next(start, date("2026-02-05 00:00"))
+	_, ok4 := rule.Next(start, date("2026-02-09 10:00"))
+
+	assert.True(t, ok1)
+	assert.Equal(t, date("2026-02-02 10:00"), next1)
+	assert.True(t, ok2)
+	assert.Equal(t, date("2026-02-04 10:00"), next2)
+	assert.True(t, ok3)
+	assert.Equal(t, date("2026-02-09 10:00"), next3)
+	assert.False(t, ok4)
+}
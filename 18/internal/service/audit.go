@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+)
+
+type AuditService struct {
+	repo repository.Audit
+}
+
+func NewAuditService(repo repository.Audit) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// GetEventHistory returns a page of the changes of the event of ownerID, the
+// latest first.
+func (s *AuditService) GetEventHistory(ctx context.Context, ownerID, eventID int, auditRange model.AuditRange) (model.AuditPage, error) {
+	return auditPage(auditRange, func(auditRange model.AuditRange) ([]model.AuditRecord, error) {
+		return s.repo.GetEventHistory(ctx, ownerID, eventID, auditRange)
+	})
+}
+
+// GetUserHistory returns a page of the changes of the events of userID and
+// of the changes userID made in shared calendars, the latest first.
+func (s *AuditService) GetUserHistory(ctx context.Context, userID int, auditRange model.AuditRange) (model.AuditPage, error) {
+	return auditPage(auditRange, func(auditRange model.AuditRange) ([]model.AuditRecord, error) {
+		return s.repo.GetUserHistory(ctx, userID, auditRange)
+	})
+}
+
+// auditPage works like EventService.GetEvents: one more record tells whether there
+// is a next page, which continues below the ID of the last record.
+func auditPage(auditRange model.AuditRange, query func(model.AuditRange) ([]model.AuditRecord, error)) (model.AuditPage, error) {
+	limit := auditRange.Limit
+	if limit > 0 {
+		auditRange.Limit++
+	}
+
+	records, err := query(auditRange)
+	if err != nil {
+		return model.AuditPage{}, err
+	}
+
+	if limit == 0 || len(records) <= limit {
+		return model.AuditPage{Records: records}, nil
+	}
+
+	records = records[:limit]
+
+	return model.AuditPage{
+		Records:    records,
+		NextCursor: strconv.Itoa(records[limit-1].ID),
+	}, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"wbtech_l2/18/internal/repository"
+)
+
+var UnauthorizedError = errors.New("invalid or missing api key")
+
+// apiKeyPrefix makes API keys easy to recognize, e.g. by secret scanners.
+const apiKeyPrefix = "cal_"
+
+type AuthService struct {
+	repo repository.APIKey
+}
+
+func NewAuthService(repo repository.APIKey) *AuthService {
+	return &AuthService{repo: repo}
+}
+
+// CreateAPIKey issues a new API key of the user. The key itself is returned
+// only once: the repository keeps just its hash.
+func (s *AuthService) CreateAPIKey(ctx context.Context, userID int) (int, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return 0, "", err
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+
+	id, err := s.repo.CreateAPIKey(ctx, userID, hashAPIKey(key))
+	if err != nil {
+		return 0, "", err
+	}
+
+	return id, key, nil
+}
+
+// RegisterAPIKey stores a key issued elsewhere, e.g. given in the config.
+func (s *AuthService) RegisterAPIKey(ctx context.Context, userID int, key string) (int, error) {
+	if key == "" {
+		return 0, UnauthorizedError
+	}
+
+	return s.repo.CreateAPIKey(ctx, userID, hashAPIKey(key))
+}
+
+// Authenticate returns the ID of the user the key belongs to or
+// UnauthorizedError if the key is unknown or revoked.
+func (s *AuthService) Authenticate(ctx context.Context, key string) (int, error) {
+	if key == "" {
+		return 0, UnauthorizedError
+	}
+
+	userID, err := s.repo.GetUserIDByAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repository.APIKeyNotFoundError) {
+			return 0, UnauthorizedError
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *AuthService) RevokeAPIKey(ctx context.Context, keyID int) error {
+	return s.repo.RevokeAPIKey(ctx, keyID)
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"wbtech_l2/18/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := NewService(repos)
+
+	userID := 1
+	keyID, key, err := services.CreateAPIKey(ctx, userID)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, apiKeyPrefix))
+
+	_, otherKey, err := services.CreateAPIKey(ctx, userID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+
+	// Only the hash is stored
+	_, err = repos.APIKey.GetUserIDByAPIKey(ctx, key)
+	assert.Equal(t, repository.APIKeyNotFoundError, err)
+
+	authenticated, err1 := services.Authenticate(ctx, key)
+	_, err2 := services.Authenticate(ctx, "cal_unknown")
+	_, err3 := services.Authenticate(ctx, "")
+
+	assert.NoError(t, err1)
+	assert.Equal(t, userID, authenticated)
+	assert.Equal(t, UnauthorizedError, err2)
+	assert.Equal(t, UnauthorizedError, err3)
+
+	assert.NoError(t, services.RevokeAPIKey(ctx, keyID))
+
+	_, err4 := services.Authenticate(ctx, key)
+	_, err5 := services.Authenticate(ctx, otherKey)
+
+	assert.Equal(t, UnauthorizedError, err4)
+	assert.NoError(t, err5)
+
+	_, err6 := services.RegisterAPIKey(ctx, 2, "configured-key")
+	authenticated, err7 := services.Authenticate(ctx, "configured-key")
+
+	assert.NoError(t, err6)
+	assert.NoError(t, err7)
+	assert.Equal(t, 2, authenticated)
+}
@@ -1,38 +1,611 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"sort"
+	"time"
 	"wbtech_l2/18/internal/model"
 	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/rrule"
+)
+
+var (
+	NotRecurringError       = errors.New("event is not recurring")
+	OccurrenceNotFoundError = errors.New("event has no occurrence on given date")
 )
 
 type EventService struct {
-	repo repository.Event
+	repo            repository.Event
+	reminders       repository.Reminder
+	attendees       repository.Attendee
+	units           repository.UnitOfWork
+	publisher       Publisher
+	now             func() time.Time
+	rejectConflicts bool
+}
+
+// EventOption configures optional behaviour of EventService.
+type EventOption func(*EventService)
+
+// WithRejectConflicts makes Create and Update fail with ConflictError when the
+// event overlaps with other events of the user. Only events with a duration
+// can overlap.
+func WithRejectConflicts() EventOption {
+	return func(s *EventService) {
+		s.rejectConflicts = true
+	}
+}
+
+// Publisher gets the changes of events once they are committed.
+type Publisher interface {
+	Publish(change model.EventChange)
+}
+
+// WithPublisher publishes the changes the service makes, e.g. to a
+// pubsub.Broker.
+func WithPublisher(publisher Publisher) EventOption {
+	return func(s *EventService) {
+		s.publisher = publisher
+	}
+}
+
+// NewEventService runs every write with its reminder in a unit of work, so
+// that events, their reminders, the audit log and the outbox change together.
+// attendees are told about the changes of the events they are invited to.
+func NewEventService(repo repository.Event, reminders repository.Reminder, attendees repository.Attendee, units repository.UnitOfWork, opts ...EventOption) *EventService {
+	s := &EventService{repo: repo, reminders: reminders, attendees: attendees, units: units, now: time.Now}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// As returns a copy of the service whose writes are recorded as made by
+// actorID.
+func (s *EventService) As(actorID int) Event {
+	actor := *s
+	actor.repo = s.repo.As(actorID)
+
+	return &actor
+}
+
+func (s *EventService) Atomically(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.units.Do(ctx, fn)
+}
+
+// publish sends the change of the event to the publisher for the owner and
+// each attendee once the unit of work of ctx commits. The attendees are read
+// in the unit, so they are the ones of the committed event.
+func (s *EventService) publish(ctx context.Context, changeType string, userID, eventID int) error {
+	if s.publisher == nil {
+		return nil
+	}
+
+	attendees, err := s.attendees.GetAttendees(ctx, eventID)
+	if err != nil {
+		return err
+	}
+
+	occurredAt := s.now().UTC()
+	changes := []model.EventChange{{Type: changeType, UserID: userID, EventID: eventID, OccurredAt: occurredAt}}
+	for _, attendee := range attendees {
+		changes = append(changes, model.EventChange{Type: changeType, UserID: attendee.UserID, EventID: eventID, OccurredAt: occurredAt})
+	}
+
+	s.units.AfterCommit(ctx, func() {
+		for _, change := range changes {
+			s.publisher.Publish(change)
+		}
+	})
+
+	return nil
+}
+
+func (s *EventService) Create(ctx context.Context, userID int, event model.Event) (int, error) {
+	var id int
+	err := s.units.Do(ctx, func(ctx context.Context) error {
+		if err := s.checkConflicts(ctx, userID, event, 0); err != nil {
+			return err
+		}
+
+		var err error
+		if id, err = s.repo.Create(ctx, userID, event); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeCreated, userID, id); err != nil {
+			return err
+		}
+
+		return scheduleReminder(ctx, s.repo, s.reminders, userID, id, s.now())
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func NewEventService(repo repository.Event) *EventService {
-	return &EventService{repo: repo}
+func (s *EventService) Update(ctx context.Context, userID, eventID int, event model.Event) error {
+	return s.units.Do(ctx, func(ctx context.Context) error {
+		if s.rejectConflicts {
+			current, err := s.repo.GetByID(ctx, userID, eventID)
+			if err != nil {
+				return err
+			}
+
+			if err = s.checkConflicts(ctx, userID, merge(current, event), eventID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.Update(ctx, userID, eventID, event); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeUpdated, userID, eventID); err != nil {
+			return err
+		}
+
+		return scheduleReminder(ctx, s.repo, s.reminders, userID, eventID, s.now())
+	})
 }
 
-func (s *EventService) Create(userID int, event model.Event) (int, error) {
-	return s.repo.Create(userID, event)
+// UpdateOccurrence detaches the occurrence of a recurring event on
+// occurrenceDate: the date becomes an exception of the series and a single
+// event with the changed fields takes its place. It returns the ID of the
+// new event. A non-zero event.Version is checked against the series.
+func (s *EventService) UpdateOccurrence(ctx context.Context, userID, eventID int, occurrenceDate string, event model.Event) (int, error) {
+	series, err := s.getOccurrence(ctx, userID, eventID, occurrenceDate)
+	if err != nil {
+		return 0, err
+	}
+
+	// The series is rendered in its own timezone, so the detached event
+	// keeps the wall clock time of the series.
+	detached := model.Event{
+		Description:  series.Description,
+		Date:         occurrenceDate,
+		Time:         series.Time,
+		Timezone:     series.Timezone,
+		RemindBefore: series.RemindBefore,
+		Duration:     series.Duration,
+	}
+	detached = merge(detached, model.Event{
+		Description: event.Description,
+		Date:        event.Date,
+		Time:        event.Time,
+		Timezone:    event.Timezone,
+		Duration:    event.Duration,
+	})
+
+	// The series gets the exception and the detached event together, so the
+	// occurrence never disappears or shows up twice
+	var id int
+	err = s.units.Do(ctx, func(ctx context.Context) error {
+		// The detached event takes the place of the occurrence, so the
+		// series doesn't conflict with it
+		if err := s.checkConflicts(ctx, userID, detached, eventID); err != nil {
+			return err
+		}
+
+		if err := s.repo.AddException(ctx, userID, eventID, occurrenceDate, event.Version); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeUpdated, userID, eventID); err != nil {
+			return err
+		}
+
+		if err := scheduleReminder(ctx, s.repo, s.reminders, userID, eventID, s.now()); err != nil {
+			return err
+		}
+
+		var err error
+		if id, err = s.repo.Create(ctx, userID, detached); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeCreated, userID, id); err != nil {
+			return err
+		}
+
+		return scheduleReminder(ctx, s.repo, s.reminders, userID, id, s.now())
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
 }
 
-func (s *EventService) Update(eventID int, event model.Event) error {
-	return s.repo.Update(eventID, event)
+// Delete moves the event to the trash. A non-zero version has to be the
+// current version of the event.
+func (s *EventService) Delete(ctx context.Context, userID, eventID, version int) error {
+	return s.units.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, userID, eventID, version); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeDeleted, userID, eventID); err != nil {
+			return err
+		}
+
+		return s.reminders.CancelReminder(ctx, eventID)
+	})
 }
 
-func (s *EventService) Delete(userID, eventID int) error {
-	return s.repo.Delete(userID, eventID)
+// DeleteOccurrence removes a single occurrence of a recurring event by adding
+// an exception to the series. A non-zero version has to be the current
+// version of the series.
+func (s *EventService) DeleteOccurrence(ctx context.Context, userID, eventID int, occurrenceDate string, version int) error {
+	if _, err := s.getOccurrence(ctx, userID, eventID, occurrenceDate); err != nil {
+		return err
+	}
+
+	return s.units.Do(ctx, func(ctx context.Context) error {
+		if err := s.repo.AddException(ctx, userID, eventID, occurrenceDate, version); err != nil {
+			return err
+		}
+		if err := s.publish(ctx, model.ChangeUpdated, userID, eventID); err != nil {
+			return err
+		}
+
+		return scheduleReminder(ctx, s.repo, s.reminders, userID, eventID, s.now())
+	})
 }
 
-func (s *EventService) GetEventsForDay(userID int, date string) ([]model.Event, error) {
-	return s.repo.GetEventsForDay(userID, date)
+// Restore takes the event out of the trash and schedules its reminder again.
+// With conflicts rejected the event must not overlap with the events created
+// since it was deleted.
+func (s *EventService) Restore(ctx context.Context, userID, eventID int) error {
+	return s.units.Do(ctx, func(ctx context.Context) error {
+		if s.rejectConflicts {
+			trash, err := s.repo.GetDeleted(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range trash {
+				if event.ID != eventID {
+					continue
+				}
+
+				if err = s.checkConflicts(ctx, userID, event, eventID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.repo.Restore(ctx, userID, eventID); err != nil {
+			return err
+		}
+		// To subscribers the restored event is a new one
+		if err := s.publish(ctx, model.ChangeCreated, userID, eventID); err != nil {
+			return err
+		}
+
+		return scheduleReminder(ctx, s.repo, s.reminders, userID, eventID, s.now())
+	})
 }
 
-func (s *EventService) GetEventsForWeek(userID int, date string) ([]model.Event, error) {
-	return s.repo.GetEventsForWeek(userID, date)
+// GetDeleted returns the trash of the user rendered in loc, most recently
+// deleted first.
+func (s *EventService) GetDeleted(ctx context.Context, userID int, loc *time.Location) ([]model.Event, error) {
+	events, err := s.repo.GetDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		events[i].Render(loc)
+	}
+
+	return events, nil
+}
+
+// Get returns the event (or the series) rendered in loc.
+func (s *EventService) Get(ctx context.Context, userID, eventID int, loc *time.Location) (model.Event, error) {
+	event, err := s.repo.GetByID(ctx, userID, eventID)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	event.Render(loc)
+
+	return event, nil
 }
 
-func (s *EventService) GetEventsForMonth(userID int, date string) ([]model.Event, error) {
-	return s.repo.GetEventsForMonth(userID, date)
+// GetEvents returns a page of user's single events and occurrences of
+// recurring events starting in [r.From, r.To) ordered by start and ID, all
+// rendered in loc. Occurrences are expanded for the whole range on every page,
+// so the cursor of the last event on the page is enough to continue.
+func (s *EventService) GetEvents(ctx context.Context, userID int, eventRange model.EventRange, loc *time.Location) (model.EventPage, error) {
+	limit := eventRange.Limit
+	if limit > 0 {
+		// One more event tells whether there is a next page
+		eventRange.Limit++
+	}
+
+	events, err := s.repo.GetEventsInRange(ctx, userID, eventRange)
+	if err != nil {
+		return model.EventPage{}, err
+	}
+
+	events, err = s.withOccurrences(ctx, userID, events, eventRange.From, eventRange.To, loc)
+	if err != nil {
+		return model.EventPage{}, err
+	}
+
+	if !eventRange.After.IsZero() {
+		page := make([]model.Event, 0, len(events))
+		for _, event := range events {
+			if eventRange.After.Before(event) {
+				page = append(page, event)
+			}
+		}
+		events = page
+	}
+
+	if limit == 0 || len(events) <= limit {
+		return model.EventPage{Events: events}, nil
+	}
+
+	events = events[:limit]
+
+	return model.EventPage{
+		Events:     events,
+		NextCursor: model.CursorOf(events[limit-1]).String(),
+	}, nil
+}
+
+func (s *EventService) GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	events, err := s.repo.GetEventsForDay(ctx, userID, date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withOccurrences(ctx, userID, events, from, from.AddDate(0, 0, 1), loc)
+}
+
+func (s *EventService) GetEventsForWeek(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	events, err := s.repo.GetEventsForWeek(ctx, userID, date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withOccurrences(ctx, userID, events, from, from.AddDate(0, 0, 7), loc)
+}
+
+func (s *EventService) GetEventsForMonth(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error) {
+	events, err := s.repo.GetEventsForMonth(ctx, userID, date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withOccurrences(ctx, userID, events, from, from.AddDate(0, 0, 31), loc)
+}
+
+// Search returns a page of user's events whose descriptions match the query,
+// the most relevant first, rendered in loc. Recurring events are returned as
+// series.
+func (s *EventService) Search(ctx context.Context, userID int, search model.EventSearch, loc *time.Location) (model.SearchPage, error) {
+	limit := search.Limit
+	if limit > 0 {
+		// One more match tells whether there is a next page
+		search.Limit++
+	}
+
+	matches, err := s.repo.Search(ctx, userID, search)
+	if err != nil {
+		return model.SearchPage{}, err
+	}
+
+	for i := range matches {
+		matches[i].Render(loc)
+	}
+
+	if limit == 0 || len(matches) <= limit {
+		return model.SearchPage{Events: matches}, nil
+	}
+
+	return model.SearchPage{
+		Events:     matches[:limit],
+		NextOffset: search.Offset + limit,
+	}, nil
+}
+
+// Export returns user's single events starting in [from, to) and the
+// recurring events that have occurrences in the same window; from and to are
+// dates in loc. Recurring events are not expanded, so they keep their RRULE
+// and exception dates. Events keep their own timezone.
+func (s *EventService) Export(ctx context.Context, userID int, from, to string, loc *time.Location) ([]model.Event, error) {
+	parsedFrom, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedTo, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.repo.GetEventsInRange(ctx, userID, model.EventRange{From: parsedFrom, To: parsedTo})
+	if err != nil {
+		return nil, err
+	}
+
+	recurring, err := s.repo.GetRecurringEvents(ctx, userID, parsedTo)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, series := range recurring {
+		occurrences, err := expand(series, parsedFrom, parsedTo)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(occurrences) > 0 {
+			events = append(events, series)
+		}
+	}
+
+	return events, nil
+}
+
+// withOccurrences adds occurrences of user's recurring events that start in
+// [from, to) to the single events of the same window. All events are
+// rendered in loc and ordered by start, then by ID.
+func (s *EventService) withOccurrences(ctx context.Context, userID int, events []model.Event, from, to time.Time, loc *time.Location) ([]model.Event, error) {
+	recurring, err := s.repo.GetRecurringEvents(ctx, userID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, series := range recurring {
+		occurrences, err := expand(series, from, to)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, occurrences...)
+	}
+
+	for i := range events {
+		events[i].Render(loc)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].StartsAt.Equal(events[j].StartsAt) {
+			return events[i].StartsAt.Before(events[j].StartsAt)
+		}
+		return events[i].ID < events[j].ID
+	})
+
+	return events, nil
+}
+
+// merge returns the event with the fields given in update changed, the way
+// the repository applies updates. Date and Time of the event have to be on
+// the wall clock of its Timezone.
+func merge(event, update model.Event) model.Event {
+	if update.Description != "" {
+		event.Description = update.Description
+	}
+
+	if update.Date != "" {
+		event.Date = update.Date
+	}
+
+	if update.Time != "" {
+		event.Time = update.Time
+	}
+
+	if update.Timezone != "" {
+		event.Timezone = update.Timezone
+	}
+
+	if update.RRule != "" {
+		event.RRule = update.RRule
+	}
+
+	if update.ExDates != nil {
+		event.ExDates = update.ExDates
+	}
+
+	if update.RemindBefore != "" {
+		event.RemindBefore = update.RemindBefore
+	}
+
+	if update.Duration != "" {
+		event.Duration = update.Duration
+	}
+
+	return event
+}
+
+// getOccurrence returns the recurring event if it has an occurrence on date
+// in the event's timezone.
+func (s *EventService) getOccurrence(ctx context.Context, userID, eventID int, date string) (model.Event, error) {
+	series, err := s.repo.GetByID(ctx, userID, eventID)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	if series.RRule == "" {
+		return model.Event{}, NotRecurringError
+	}
+
+	loc, err := model.LoadLocation(series.Timezone)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	occurrences, err := expand(series, from, from.AddDate(0, 0, 1))
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	if len(occurrences) == 0 {
+		return model.Event{}, OccurrenceNotFoundError
+	}
+
+	return series, nil
+}
+
+// expand returns the occurrences of the series starting in [from, to) except
+// the ones on its exception dates. Occurrences are computed on the wall clock
+// of the series timezone, so they keep their local time across DST changes.
+// Each occurrence keeps the ID of the series.
+func expand(series model.Event, from, to time.Time) ([]model.Event, error) {
+	rule, err := rrule.Parse(series.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := model.LoadLocation(series.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	start := series.StartsAt.In(loc)
+
+	exDates := make(map[string]bool, len(series.ExDates))
+	for _, exDate := range series.ExDates {
+		exDates[exDate] = true
+	}
+
+	events := make([]model.Event, 0)
+	for _, occurrence := range rule.Between(start, from, to) {
+		date := occurrence.Format("2006-01-02")
+		if exDates[date] {
+			continue
+		}
+
+		event := series
+		event.StartsAt = occurrence
+		event.OccurrenceDate = date
+		event.ExDates = nil
+		event.Render(loc)
+		events = append(events, event)
+	}
+
+	return events, nil
 }
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventDates(events []model.Event) []string {
+	dates := make([]string, 0, len(events))
+	for _, event := range events {
+		dates = append(dates, event.Date)
+	}
+	return dates
+}
+
+func TestGetEventsWithOccurrences(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	seriesID, err := services.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		ExDates:     []string{"2026-02-06"},
+	})
+	assert.NoError(t, err)
+
+	_, err = services.Create(ctx, userID, model.Event{
+		Description: "dentist",
+		Date:        "2026-02-04",
+		Time:        "09:00",
+	})
+	assert.NoError(t, err)
+
+	eventsForDay, err := services.GetEventsForDay(ctx, userID, "2026-02-04", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(eventsForDay))
+	assert.Equal(t, "dentist", eventsForDay[0].Description)
+	assert.Equal(t, "stand-up", eventsForDay[1].Description)
+	assert.Equal(t, seriesID, eventsForDay[1].ID)
+
+	eventsForWeek, err := services.GetEventsForWeek(ctx, userID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-02", "2026-02-04", "2026-02-04"}, eventDates(eventsForWeek))
+
+	eventsBeforeStart, err := services.GetEventsForWeek(ctx, userID, "2026-01-01", time.UTC)
+	assert.NoError(t, err)
+	assert.Empty(t, eventsBeforeStart)
+
+	eventsForOtherUser, err := services.GetEventsForMonth(ctx, 12345, "2026-02-01", time.UTC)
+	assert.NoError(t, err)
+	assert.Empty(t, eventsForOtherUser)
+}
+
+func TestUpdateOccurrence(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	seriesID, _ := services.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+	singleID, _ := services.Create(ctx, userID, model.Event{
+		Description: "dentist",
+		Date:        "2026-02-04",
+		Time:        "09:00",
+	})
+
+	detachedID, err1 := services.UpdateOccurrence(ctx, userID, seriesID, "2026-02-03", model.Event{Time: "12:00"})
+	_, err2 := services.UpdateOccurrence(ctx, userID, seriesID, "2026-02-10", model.Event{Time: "12:00"})
+	_, err3 := services.UpdateOccurrence(ctx, userID, singleID, "2026-02-04", model.Event{Time: "12:00"})
+	_, err4 := services.UpdateOccurrence(ctx, userID, 12345, "2026-02-04", model.Event{Time: "12:00"})
+	_, err5 := services.UpdateOccurrence(ctx, 12345, seriesID, "2026-02-04", model.Event{Time: "12:00"})
+
+	assert.NoError(t, err1)
+	assert.NotEqual(t, seriesID, detachedID)
+	assert.Equal(t, OccurrenceNotFoundError, err2)
+	assert.Equal(t, NotRecurringError, err3)
+	assert.Equal(t, repository.NotFoundError, err4)
+	assert.Equal(t, repository.NotFoundError, err5)
+
+	events, err := services.GetEventsForDay(ctx, userID, "2026-02-03", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, detachedID, events[0].ID)
+	assert.Equal(t, "stand-up", events[0].Description)
+	assert.Equal(t, "12:00:00", events[0].Time)
+	assert.Empty(t, events[0].RRule)
+
+	// Editing the whole series keeps the detached occurrence.
+	assert.NoError(t, services.Update(ctx, userID, seriesID, model.Event{Description: "daily"}))
+	events, err = services.GetEventsForWeek(ctx, userID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-02", "2026-02-03", "2026-02-04", "2026-02-04", "2026-02-05", "2026-02-06"}, eventDates(events))
+	assert.Equal(t, "daily", events[0].Description)
+	assert.Equal(t, "stand-up", events[1].Description)
+}
+
+func TestDeleteOccurrence(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	seriesID, _ := services.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY",
+	})
+
+	err1 := services.DeleteOccurrence(ctx, userID, seriesID, "2026-02-03", 0)
+	err2 := services.DeleteOccurrence(ctx, userID, seriesID, "2026-02-03", 0)
+	err3 := services.DeleteOccurrence(ctx, 12345, seriesID, "2026-02-04", 0)
+
+	assert.NoError(t, err1)
+	assert.Equal(t, OccurrenceNotFoundError, err2)
+	assert.Equal(t, repository.NotFoundError, err3)
+
+	events, err := services.GetEventsForWeek(ctx, userID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-02", "2026-02-04", "2026-02-05", "2026-02-06", "2026-02-07", "2026-02-08"}, eventDates(events))
+
+	// Deleting the series removes every occurrence.
+	assert.NoError(t, services.Delete(ctx, userID, seriesID, 0))
+	events, err = services.GetEventsForWeek(ctx, userID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestOccurrencesAcrossDST(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// New York moves to daylight saving time on 2026-03-08
+	userID := 1
+	seriesID, err := services.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-03-02",
+		Time:        "09:00",
+		Timezone:    "America/New_York",
+		RRule:       "FREQ=WEEKLY",
+	})
+	assert.NoError(t, err)
+
+	eventsInNewYork, err := services.GetEventsForMonth(ctx, userID, "2026-03-01", newYork)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(eventsInNewYork))
+	for _, event := range eventsInNewYork {
+		assert.Equal(t, "09:00:00", event.Time)
+		assert.Equal(t, event.Date, event.OccurrenceDate)
+	}
+
+	eventsInUTC, err := services.GetEventsForWeek(ctx, userID, "2026-03-01", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(eventsInUTC))
+	assert.Equal(t, "14:00:00", eventsInUTC[0].Time)
+
+	eventsInUTC, err = services.GetEventsForWeek(ctx, userID, "2026-03-08", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(eventsInUTC))
+	assert.Equal(t, "13:00:00", eventsInUTC[0].Time)
+
+	// Occurrence dates are dates in the timezone of the series
+	assert.NoError(t, services.DeleteOccurrence(ctx, userID, seriesID, "2026-03-09", 0))
+
+	eventsInNewYork, err = services.GetEventsForWeek(ctx, userID, "2026-03-08", newYork)
+	assert.NoError(t, err)
+	assert.Empty(t, eventsInNewYork)
+}
+
+func TestGetEventsPages(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	seriesID, _ := services.Create(ctx, userID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=3",
+	})
+	// Starts at the same time as an occurrence, so the ID decides the order
+	singleID, _ := services.Create(ctx, userID, model.Event{
+		Description: "dentist",
+		Date:        "2026-02-03",
+		Time:        "10:00",
+	})
+	services.Create(ctx, userID, model.Event{
+		Description: "lunch",
+		Date:        "2026-02-03",
+		Time:        "12:00",
+	})
+
+	eventRange := model.EventRange{
+		From:  time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:    time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Limit: 2,
+	}
+
+	ids := make([]int, 0)
+	for pages := 1; ; pages++ {
+		page, err := services.GetEvents(ctx, userID, eventRange, time.UTC)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(page.Events), eventRange.Limit)
+
+		for _, event := range page.Events {
+			ids = append(ids, event.ID)
+		}
+
+		if page.NextCursor == "" {
+			assert.Equal(t, 3, pages)
+			break
+		}
+
+		eventRange.After, err = model.ParseCursor(page.NextCursor)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{seriesID, seriesID, singleID, singleID + 1, seriesID}, ids)
+
+	// Without a limit everything is on a single page
+	eventRange.After, eventRange.Limit = model.Cursor{}, 0
+	page, err := services.GetEvents(ctx, userID, eventRange, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(page.Events))
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository(), WithRejectConflicts())
+
+	userID := 1
+	start := time.Now().Add(time.Hour)
+	eventID, err := services.Create(ctx, userID, model.Event{
+		Description:  "planning",
+		Date:         start.Format("2006-01-02"),
+		Time:         start.Format("15:04:05"),
+		Duration:     "1h",
+		RemindBefore: "2h",
+	})
+	assert.NoError(t, err)
+
+	// Deleting cancels the reminder, restoring schedules it again
+	assert.NoError(t, services.Delete(ctx, userID, eventID, 0))
+	reminders, err := services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	assert.NoError(t, services.Restore(ctx, userID, eventID))
+	assert.Equal(t, repository.NotFoundError, services.Restore(ctx, userID, eventID))
+
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, eventID, reminders[0].EventID)
+	}
+
+	// An event created in place of the deleted one blocks the restore
+	assert.NoError(t, services.Delete(ctx, userID, eventID, 0))
+	_, err = services.Create(ctx, userID, model.Event{
+		Description: "review",
+		Date:        start.Format("2006-01-02"),
+		Time:        start.Format("15:04:05"),
+		Duration:    "30m",
+	})
+	assert.NoError(t, err)
+
+	var conflictErr *ConflictError
+	assert.True(t, errors.As(services.Restore(ctx, userID, eventID), &conflictErr))
+
+	trash, err := services.GetDeleted(ctx, userID, time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, eventID, trash[0].ID)
+		assert.NotNil(t, trash[0].DeletedAt)
+	}
+}
+
+var reminderError = errors.New("reminder is not scheduled")
+
+// failingReminders fails to schedule reminders after the event is written.
+type failingReminders struct {
+	repository.Reminder
+}
+
+func (failingReminders) ScheduleReminder(context.Context, model.Reminder) error {
+	return reminderError
+}
+
+func TestWritesRollBack(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	repos.Reminder = failingReminders{Reminder: repos.Reminder}
+	services := NewService(repos)
+
+	userID := 1
+	changes := services.Subscribe(userID, 0)
+	defer changes.Close()
+
+	_, err := services.Create(ctx, userID, model.Event{Description: "dentist", Date: "2027-02-04", Time: "09:00", RemindBefore: "1h"})
+	assert.Equal(t, reminderError, err)
+
+	eventID, err := services.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2027-02-04", Time: "10:00", RRule: "FREQ=DAILY"})
+	assert.NoError(t, err)
+
+	err = services.Update(ctx, userID, eventID, model.Event{Description: "daily", RemindBefore: "1h"})
+	assert.Equal(t, reminderError, err)
+
+	events, err := services.GetEventsForDay(ctx, userID, "2027-02-04", time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "stand-up", events[0].Description)
+		assert.Empty(t, events[0].RemindBefore)
+	}
+
+	// Only the writes that succeeded are in the audit log and published
+	records, err := repos.Audit.GetUserHistory(ctx, userID, model.AuditRange{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	if assert.Len(t, changes.C, 1) {
+		change := <-changes.C
+		assert.Equal(t, model.ChangeCreated, change.Type)
+		assert.Equal(t, eventID, change.EventID)
+	}
+}
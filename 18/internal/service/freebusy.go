@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"wbtech_l2/18/internal/model"
+)
+
+// conflictHorizon bounds how far occurrences of a recurring event are checked
+// for conflicts.
+const conflictHorizon = 1 // year
+
+// ConflictError is returned by Create and Update when conflicts are rejected
+// and the event overlaps with Events.
+type ConflictError struct {
+	Events []model.Event
+}
+
+func (e *ConflictError) Error() string {
+	ids := make([]string, 0, len(e.Events))
+	seen := make(map[int]bool)
+
+	for _, event := range e.Events {
+		if !seen[event.ID] {
+			seen[event.ID] = true
+			ids = append(ids, strconv.Itoa(event.ID))
+		}
+	}
+
+	return fmt.Sprintf("event overlaps with events %s", strings.Join(ids, ", "))
+}
+
+// FreeBusy returns the merged intervals in [from, to) taken by user's events
+// with a duration and the free intervals between them, all in loc.
+func (s *EventService) FreeBusy(ctx context.Context, userID int, from, to time.Time, loc *time.Location) (model.FreeBusy, error) {
+	events, err := s.busyEvents(ctx, userID, from, to)
+	if err != nil {
+		return model.FreeBusy{}, err
+	}
+
+	busy := make([]model.Interval, 0, len(events))
+	for _, event := range events {
+		busy = append(busy, model.Interval{Start: event.StartsAt, End: event.End()})
+	}
+
+	return model.NewFreeBusy(busy, from, to).In(loc), nil
+}
+
+// busyEvents returns single events and occurrences of recurring events with
+// a duration that overlap [from, to).
+func (s *EventService) busyEvents(ctx context.Context, userID int, from, to time.Time) ([]model.Event, error) {
+	events, err := s.repo.GetEventsOverlapping(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	recurring, err := s.repo.GetRecurringEvents(ctx, userID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, series := range recurring {
+		duration := series.End().Sub(series.StartsAt)
+		if duration == 0 {
+			continue
+		}
+
+		occurrences, err := expand(series, from.Add(-duration), to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, occurrence := range occurrences {
+			if occurrence.End().After(from) {
+				events = append(events, occurrence)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// conflicts returns user's events and occurrences that overlap with the
+// event, except the ones of the event excludeID. Occurrences of a recurring
+// event are checked up to conflictHorizon after its start.
+func (s *EventService) conflicts(ctx context.Context, userID int, event model.Event, excludeID int) ([]model.Event, error) {
+	if event.End().Equal(event.StartsAt) {
+		return nil, nil
+	}
+
+	intervals := []model.Interval{{Start: event.StartsAt, End: event.End()}}
+	if event.RRule != "" {
+		occurrences, err := expand(event, event.StartsAt, event.StartsAt.AddDate(conflictHorizon, 0, 0))
+		if err != nil {
+			return nil, err
+		}
+
+		intervals = intervals[:0]
+		for _, occurrence := range occurrences {
+			intervals = append(intervals, model.Interval{Start: occurrence.StartsAt, End: occurrence.End()})
+		}
+	}
+
+	if len(intervals) == 0 {
+		return nil, nil
+	}
+
+	busy, err := s.busyEvents(ctx, userID, intervals[0].Start, intervals[len(intervals)-1].End)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]model.Event, 0)
+	for _, other := range busy {
+		if other.ID == excludeID {
+			continue
+		}
+
+		otherInterval := model.Interval{Start: other.StartsAt, End: other.End()}
+		for _, interval := range intervals {
+			if interval.Overlaps(otherInterval) {
+				conflicts = append(conflicts, other)
+				break
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// checkConflicts returns ConflictError if conflicts are rejected and the
+// event overlaps with other events. Date and Time of the event are on the
+// wall clock of its Timezone.
+func (s *EventService) checkConflicts(ctx context.Context, userID int, event model.Event, excludeID int) error {
+	if !s.rejectConflicts {
+		return nil
+	}
+
+	loc, err := model.LoadLocation(event.Timezone)
+	if err != nil {
+		return err
+	}
+
+	start, err := time.ParseInLocation("2006-01-02 15:04", event.Date+" "+event.Time, loc)
+	if err != nil {
+		if start, err = time.ParseInLocation("2006-01-02 15:04:05", event.Date+" "+event.Time, loc); err != nil {
+			return err
+		}
+	}
+	event.StartsAt = start
+
+	conflicts, err := s.conflicts(ctx, userID, event, excludeID)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		return &ConflictError{Events: conflicts}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeBusy(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	services.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "10:00", Duration: "15m", RRule: "FREQ=DAILY"})
+	services.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-04", Time: "10:10", Duration: "50m"})
+	// Started the day before
+	services.Create(ctx, userID, model.Event{Description: "on call", Date: "2026-02-03", Time: "20:00", Duration: "14h"})
+	// Events without a duration never make the user busy
+	services.Create(ctx, userID, model.Event{Description: "reminder", Date: "2026-02-04", Time: "15:00"})
+	services.Create(ctx, 12345, model.Event{Description: "other user", Date: "2026-02-04", Time: "15:00", Duration: "1h"})
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 2, 4, hour, minute, 0, 0, time.UTC)
+	}
+
+	freeBusy, err := services.FreeBusy(ctx, userID, at(0, 0), at(0, 0).AddDate(0, 0, 1), time.UTC)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []model.Interval{
+		{Start: at(0, 0), End: at(11, 0)},
+	}, freeBusy.Busy)
+	assert.Equal(t, []model.Interval{
+		{Start: at(11, 0), End: at(24, 0)},
+	}, freeBusy.Free)
+
+	// Intervals are in the requested location
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	freeBusy, err = services.FreeBusy(ctx, userID, at(10, 0), at(12, 0), moscow)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(freeBusy.Busy))
+	assert.Equal(t, moscow, freeBusy.Busy[0].Start.Location())
+	assert.True(t, at(11, 0).Equal(freeBusy.Busy[0].End))
+}
+
+func TestRejectConflicts(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository(), WithRejectConflicts())
+
+	userID := 1
+	seriesID, err := services.Create(ctx, userID, model.Event{Description: "stand-up", Date: "2026-02-02", Time: "10:00", Duration: "15m", RRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR"})
+	assert.NoError(t, err)
+
+	planningID, err := services.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-03", Time: "10:00", Duration: "1h"})
+	assert.NoError(t, err)
+
+	var conflictErr *ConflictError
+
+	// Overlaps with an occurrence of the series
+	_, err = services.Create(ctx, userID, model.Event{Description: "review", Date: "2026-02-04", Time: "09:30", Duration: "45m"})
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, seriesID, conflictErr.Events[0].ID)
+	assert.Equal(t, "2026-02-04", conflictErr.Events[0].OccurrenceDate)
+
+	// Ends when the occurrence starts
+	_, err = services.Create(ctx, userID, model.Event{Description: "review", Date: "2026-02-04", Time: "09:30", Duration: "30m"})
+	assert.NoError(t, err)
+
+	// Events without a duration never conflict
+	_, err = services.Create(ctx, userID, model.Event{Description: "reminder", Date: "2026-02-04", Time: "10:05"})
+	assert.NoError(t, err)
+
+	// Occurrences of a new series conflict as well
+	_, err = services.Create(ctx, userID, model.Event{Description: "daily", Date: "2026-02-10", Time: "10:30", Duration: "1h", RRule: "FREQ=DAILY"})
+	assert.NoError(t, err)
+
+	_, err = services.Create(ctx, userID, model.Event{Description: "tuesdays", Date: "2026-01-06", Time: "11:00", Duration: "1h", RRule: "FREQ=WEEKLY"})
+	assert.True(t, errors.As(err, &conflictErr))
+
+	// The event doesn't conflict with itself
+	assert.NoError(t, services.Update(ctx, userID, planningID, model.Event{Time: "10:30"}))
+	err = services.Update(ctx, userID, planningID, model.Event{Date: "2026-02-10"})
+	assert.True(t, errors.As(err, &conflictErr))
+	err = services.Update(ctx, userID, planningID, model.Event{Duration: "2h"})
+	assert.NoError(t, err)
+
+	// A detached occurrence replaces the original one
+	_, err = services.UpdateOccurrence(ctx, userID, seriesID, "2026-02-02", model.Event{Time: "10:05"})
+	assert.NoError(t, err)
+	_, err = services.UpdateOccurrence(ctx, userID, seriesID, "2026-02-06", model.Event{Date: "2026-02-03", Time: "10:45"})
+	assert.True(t, errors.As(err, &conflictErr))
+
+	// Conflicts are allowed unless rejected
+	services = NewService(repository.NewMemoryRepository())
+	services.Create(ctx, userID, model.Event{Description: "planning", Date: "2026-02-03", Time: "10:00", Duration: "1h"})
+	_, err = services.Create(ctx, userID, model.Event{Description: "review", Date: "2026-02-03", Time: "10:00", Duration: "1h"})
+	assert.NoError(t, err)
+}
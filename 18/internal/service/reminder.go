@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/rrule"
+)
+
+type ReminderService struct {
+	events    repository.Event
+	reminders repository.Reminder
+	now       func() time.Time
+}
+
+func NewReminderService(events repository.Event, reminders repository.Reminder) *ReminderService {
+	return &ReminderService{events: events, reminders: reminders, now: time.Now}
+}
+
+func (s *ReminderService) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.Reminder, error) {
+	return s.reminders.ClaimDueReminders(ctx, s.now(), limit, lease)
+}
+
+// Complete marks the reminder as sent and, for recurring events, schedules
+// the reminder about the next occurrence.
+func (s *ReminderService) Complete(ctx context.Context, reminder model.Reminder) error {
+	if err := s.reminders.MarkReminderSent(ctx, reminder.ID); err != nil {
+		return err
+	}
+
+	return scheduleReminder(ctx, s.events, s.reminders, reminder.UserID, reminder.EventID, reminder.EventAt)
+}
+
+func (s *ReminderService) Retry(ctx context.Context, reminder model.Reminder, retryAt time.Time) error {
+	return s.reminders.ReleaseReminder(ctx, reminder.ID, retryAt)
+}
+
+// scheduleReminder replaces the pending reminder of the event with the one
+// about its first occurrence after after. Events without remind_before or
+// without further occurrences are left with no pending reminder.
+func scheduleReminder(ctx context.Context, events repository.Event, reminders repository.Reminder, userID, eventID int, after time.Time) error {
+	event, err := events.GetByID(ctx, userID, eventID)
+	if err != nil {
+		return err
+	}
+
+	if event.RemindBefore == "" {
+		return reminders.CancelReminder(ctx, eventID)
+	}
+
+	remindBefore, err := time.ParseDuration(event.RemindBefore)
+	if err != nil {
+		return err
+	}
+
+	eventAt, ok, err := nextOccurrence(event, after)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return reminders.CancelReminder(ctx, eventID)
+	}
+
+	return reminders.ScheduleReminder(ctx, model.Reminder{
+		EventID:     eventID,
+		UserID:      event.UserID,
+		Description: event.Description,
+		EventAt:     eventAt,
+		RemindAt:    eventAt.Add(-remindBefore),
+	})
+}
+
+// nextOccurrence returns the start of the first occurrence of the event after
+// after. Occurrences of recurring events follow the wall clock of the event's
+// timezone.
+func nextOccurrence(event model.Event, after time.Time) (time.Time, bool, error) {
+	loc, err := model.LoadLocation(event.Timezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	start := event.StartsAt.In(loc)
+
+	if event.RRule == "" {
+		return start, start.After(after), nil
+	}
+
+	rule, err := rrule.Parse(event.RRule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	exDates := make(map[string]bool, len(event.ExDates))
+	for _, exDate := range event.ExDates {
+		exDates[exDate] = true
+	}
+
+	for {
+		next, ok := rule.Next(start, after)
+		if !ok || !exDates[next.In(loc).Format("2006-01-02")] {
+			return next, ok, nil
+		}
+		after = next
+	}
+}
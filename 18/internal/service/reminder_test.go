@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestService(now time.Time) *Service {
+	services := NewService(repository.NewMemoryRepository())
+	services.Event.(*EventService).now = func() time.Time { return now }
+	services.Reminder.(*ReminderService).now = func() time.Time { return now }
+	return services
+}
+
+func localTime(s string) time.Time {
+	parsed, _ := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+	return parsed
+}
+
+func TestScheduleReminder(t *testing.T) {
+	ctx := context.Background()
+	services := newTestService(localTime("2026-02-01 12:00"))
+
+	userID := 1
+	eventID, err := services.Create(ctx, userID, model.Event{
+		Description:  "dentist",
+		Date:         "2026-02-02",
+		Time:         "09:00",
+		RemindBefore: "1h",
+	})
+	assert.NoError(t, err)
+
+	// Events in the past and events without remind_before get no reminder.
+	_, err = services.Create(ctx, userID, model.Event{Description: "past", Date: "2026-01-01", Time: "09:00", RemindBefore: "1h"})
+	assert.NoError(t, err)
+	_, err = services.Create(ctx, userID, model.Event{Description: "silent", Date: "2026-02-02", Time: "09:00"})
+	assert.NoError(t, err)
+
+	services.Reminder.(*ReminderService).now = func() time.Time { return localTime("2026-02-02 07:59") }
+	reminders, err := services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	services.Reminder.(*ReminderService).now = func() time.Time { return localTime("2026-02-02 08:00") }
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reminders))
+	assert.Equal(t, eventID, reminders[0].EventID)
+	assert.Equal(t, userID, reminders[0].UserID)
+	assert.Equal(t, localTime("2026-02-02 09:00"), reminders[0].EventAt)
+	assert.Equal(t, 1, reminders[0].Attempts)
+
+	// A claimed reminder is invisible until its lease expires.
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	services.Reminder.(*ReminderService).now = func() time.Time { return localTime("2026-02-02 08:01") }
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reminders))
+	assert.Equal(t, 2, reminders[0].Attempts)
+
+	assert.NoError(t, services.Complete(ctx, reminders[0]))
+
+	services.Reminder.(*ReminderService).now = func() time.Time { return localTime("2026-02-03 00:00") }
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+}
+
+func TestRescheduleReminder(t *testing.T) {
+	ctx := context.Background()
+	services := newTestService(localTime("2026-02-01 12:00"))
+
+	userID := 1
+	seriesID, _ := services.Create(ctx, userID, model.Event{
+		Description:  "stand-up",
+		Date:         "2026-02-02",
+		Time:         "10:00",
+		RRule:        "FREQ=DAILY;COUNT=3",
+		ExDates:      []string{"2026-02-03"},
+		RemindBefore: "15m",
+	})
+	eventID, _ := services.Create(ctx, userID, model.Event{
+		Description:  "dentist",
+		Date:         "2026-02-02",
+		Time:         "09:00",
+		RemindBefore: "1h",
+	})
+
+	// Moving the event moves its reminder, deleting it cancels the reminder.
+	assert.NoError(t, services.Update(ctx, userID, eventID, model.Event{Date: "2026-02-05"}))
+	assert.NoError(t, services.Delete(ctx, userID, eventID, 0))
+
+	services.Reminder.(*ReminderService).now = func() time.Time { return localTime("2026-02-10 00:00") }
+	reminders, err := services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reminders))
+	assert.Equal(t, seriesID, reminders[0].EventID)
+	assert.Equal(t, localTime("2026-02-02 10:00"), reminders[0].EventAt)
+	assert.Equal(t, localTime("2026-02-02 09:45"), reminders[0].RemindAt)
+
+	// Completing a reminder of a series schedules the next occurrence,
+	// skipping exception dates, until the series ends.
+	assert.NoError(t, services.Complete(ctx, reminders[0]))
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(reminders))
+	assert.Equal(t, localTime("2026-02-04 10:00"), reminders[0].EventAt)
+
+	assert.NoError(t, services.Complete(ctx, reminders[0]))
+	reminders, err = services.ClaimDue(ctx, 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+}
@@ -1,25 +1,122 @@
 package service
 
 import (
+	"context"
+	"time"
 	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/pubsub"
 	"wbtech_l2/18/internal/repository"
 )
 
+// Event changes events on behalf of their owner. As returns the service that
+// records actorID as the author of the changes in the audit log, which
+// differs from the owner in shared calendars. The queries of every method run
+// with ctx, usually the one of the request. Atomically runs fn in a unit of
+// work: the changes made with the ctx fn is given are rolled back together if
+// fn fails.
 type Event interface {
-	Create(userID int, event model.Event) (int, error)
-	Update(eventID int, event model.Event) error
-	Delete(userID, eventID int) error
-	GetEventsForDay(userID int, date string) ([]model.Event, error)
-	GetEventsForWeek(userID int, date string) ([]model.Event, error)
-	GetEventsForMonth(userID int, date string) ([]model.Event, error)
+	As(actorID int) Event
+	Atomically(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, userID int, event model.Event) (int, error)
+	Update(ctx context.Context, userID, eventID int, event model.Event) error
+	UpdateOccurrence(ctx context.Context, userID, eventID int, occurrenceDate string, event model.Event) (int, error)
+	Delete(ctx context.Context, userID, eventID, version int) error
+	DeleteOccurrence(ctx context.Context, userID, eventID int, occurrenceDate string, version int) error
+	Restore(ctx context.Context, userID, eventID int) error
+	GetDeleted(ctx context.Context, userID int, loc *time.Location) ([]model.Event, error)
+	Get(ctx context.Context, userID, eventID int, loc *time.Location) (model.Event, error)
+	GetEvents(ctx context.Context, userID int, eventRange model.EventRange, loc *time.Location) (model.EventPage, error)
+	GetEventsForDay(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	GetEventsForWeek(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	GetEventsForMonth(ctx context.Context, userID int, date string, loc *time.Location) ([]model.Event, error)
+	Search(ctx context.Context, userID int, search model.EventSearch, loc *time.Location) (model.SearchPage, error)
+	FreeBusy(ctx context.Context, userID int, from, to time.Time, loc *time.Location) (model.FreeBusy, error)
+	Export(ctx context.Context, userID int, from, to string, loc *time.Location) ([]model.Event, error)
+}
+
+type Reminder interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.Reminder, error)
+	Complete(ctx context.Context, reminder model.Reminder) error
+	Retry(ctx context.Context, reminder model.Reminder, retryAt time.Time) error
+}
+
+type Auth interface {
+	CreateAPIKey(ctx context.Context, userID int) (int, string, error)
+	RegisterAPIKey(ctx context.Context, userID int, key string) (int, error)
+	Authenticate(ctx context.Context, key string) (int, error)
+	RevokeAPIKey(ctx context.Context, keyID int) error
+}
+
+type Attendee interface {
+	Invite(ctx context.Context, ownerID, eventID, userID int) error
+	Uninvite(ctx context.Context, ownerID, eventID, userID int) error
+	GetAttendees(ctx context.Context, userID, eventID int) ([]model.Attendee, error)
+	RespondToInvite(ctx context.Context, userID, eventID int, status string) error
+}
+
+type Share interface {
+	ShareCalendar(ctx context.Context, ownerID, userID int, permission string) error
+	UnshareCalendar(ctx context.Context, ownerID, userID int) error
+	GetShares(ctx context.Context, userID int) ([]model.Share, error)
+	CalendarPermission(ctx context.Context, ownerID, userID int) (string, error)
+}
+
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userID int, url string) (model.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, webhookID int) error
+	GetWebhooks(ctx context.Context, userID int) ([]model.Webhook, error)
+}
+
+type Outbox interface {
+	ClaimDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	CompleteDelivery(delivery model.WebhookDelivery) error
+	RetryDelivery(delivery model.WebhookDelivery, retryAt time.Time, cause error) error
+	FailDelivery(delivery model.WebhookDelivery, cause error) error
+}
+
+// Changes streams the changes of the events of a user, see pubsub.Broker.
+type Changes interface {
+	Subscribe(userID, lastID int) *pubsub.Subscription
+}
+
+type Trash interface {
+	Purge(ctx context.Context, retention time.Duration, limit int) (int, error)
+}
+
+type Audit interface {
+	GetEventHistory(ctx context.Context, ownerID, eventID int, auditRange model.AuditRange) (model.AuditPage, error)
+	GetUserHistory(ctx context.Context, userID int, auditRange model.AuditRange) (model.AuditPage, error)
 }
 
 type Service struct {
 	Event
+	Reminder
+	Auth
+	Attendee
+	Share
+	Webhook
+	Outbox
+	Changes
+	Trash
+	Audit
 }
 
-func NewService(repo *repository.Repository) *Service {
+// NewService publishes the changes of events to a broker of its own, which
+// Changes subscribes to.
+func NewService(repo *repository.Repository, opts ...EventOption) *Service {
+	webhooks := NewWebhookService(repo.Webhook, repo.Outbox)
+	changes := pubsub.NewBroker(0)
+
 	return &Service{
-		Event: NewEventService(repo.Event),
+		Event:    NewEventService(repo.Event, repo.Reminder, repo.Attendee, repo.UnitOfWork, append([]EventOption{WithPublisher(changes)}, opts...)...),
+		Reminder: NewReminderService(repo.Event, repo.Reminder),
+		Auth:     NewAuthService(repo.APIKey),
+		Attendee: NewAttendeeService(repo.Event, repo.Attendee),
+		Share:    NewShareService(repo.Share),
+		Webhook:  webhooks,
+		Outbox:   webhooks,
+		Changes:  changes,
+		Trash:    NewTrashService(repo.Trash),
+		Audit:    NewAuditService(repo.Audit),
 	}
 }
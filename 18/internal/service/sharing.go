@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+)
+
+var (
+	SelfInviteError = errors.New("organizer can't be invited to own event")
+	SelfShareError  = errors.New("calendar can't be shared with its owner")
+)
+
+type AttendeeService struct {
+	events    repository.Event
+	attendees repository.Attendee
+}
+
+func NewAttendeeService(events repository.Event, attendees repository.Attendee) *AttendeeService {
+	return &AttendeeService{events: events, attendees: attendees}
+}
+
+// Invite adds userID to the attendees of the event of ownerID. The invitation
+// needs an answer until the attendee responds to it.
+func (s *AttendeeService) Invite(ctx context.Context, ownerID, eventID, userID int) error {
+	if ownerID == userID {
+		return SelfInviteError
+	}
+
+	return s.attendees.AddAttendee(ctx, ownerID, eventID, userID)
+}
+
+func (s *AttendeeService) Uninvite(ctx context.Context, ownerID, eventID, userID int) error {
+	return s.attendees.RemoveAttendee(ctx, ownerID, eventID, userID)
+}
+
+// GetAttendees returns the attendees of an event to its owner and to its
+// attendees.
+func (s *AttendeeService) GetAttendees(ctx context.Context, userID, eventID int) ([]model.Attendee, error) {
+	if _, err := s.events.GetByID(ctx, userID, eventID); err != nil {
+		return nil, err
+	}
+
+	return s.attendees.GetAttendees(ctx, eventID)
+}
+
+func (s *AttendeeService) RespondToInvite(ctx context.Context, userID, eventID int, status string) error {
+	return s.attendees.SetRSVP(ctx, userID, eventID, status)
+}
+
+type ShareService struct {
+	repo repository.Share
+}
+
+func NewShareService(repo repository.Share) *ShareService {
+	return &ShareService{repo: repo}
+}
+
+func (s *ShareService) ShareCalendar(ctx context.Context, ownerID, userID int, permission string) error {
+	if ownerID == userID {
+		return SelfShareError
+	}
+
+	return s.repo.ShareCalendar(ctx, ownerID, userID, permission)
+}
+
+func (s *ShareService) UnshareCalendar(ctx context.Context, ownerID, userID int) error {
+	return s.repo.UnshareCalendar(ctx, ownerID, userID)
+}
+
+// GetShares returns both the calendars userID shares and the calendars shared
+// with userID.
+func (s *ShareService) GetShares(ctx context.Context, userID int) ([]model.Share, error) {
+	return s.repo.GetShares(ctx, userID)
+}
+
+// CalendarPermission returns the permission userID has on the calendar of
+// ownerID. Owners always have write permission on their own calendars.
+func (s *ShareService) CalendarPermission(ctx context.Context, ownerID, userID int) (string, error) {
+	if ownerID == userID {
+		return model.PermissionWrite, nil
+	}
+
+	return s.repo.GetPermission(ctx, ownerID, userID)
+}
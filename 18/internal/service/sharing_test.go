@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvite(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	ownerID, attendeeID := 1, 2
+	seriesID, _ := services.Create(ctx, ownerID, model.Event{
+		Description: "stand-up",
+		Date:        "2026-02-02",
+		Time:        "10:00",
+		RRule:       "FREQ=DAILY;COUNT=5",
+	})
+
+	err1 := services.Invite(ctx, ownerID, seriesID, ownerID)
+	err2 := services.Invite(ctx, ownerID, seriesID, attendeeID)
+	_, err3 := services.GetAttendees(ctx, 3, seriesID)
+
+	assert.Equal(t, SelfInviteError, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, repository.NotFoundError, err3)
+
+	// Occurrences of invited series are expanded for the attendee too
+	events, err := services.GetEventsForWeek(ctx, attendeeID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2026-02-02", "2026-02-03", "2026-02-04", "2026-02-05", "2026-02-06"}, eventDates(events))
+	assert.Equal(t, model.RSVPNeedsAction, events[0].RSVP)
+
+	assert.NoError(t, services.RespondToInvite(ctx, attendeeID, seriesID, model.RSVPDeclined))
+
+	events, err = services.GetEventsForWeek(ctx, attendeeID, "2026-02-02", time.UTC)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	attendees, err := services.GetAttendees(ctx, attendeeID, seriesID)
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Attendee{{EventID: seriesID, UserID: attendeeID, Status: model.RSVPDeclined}}, attendees)
+}
+
+func TestCalendarPermission(t *testing.T) {
+	ctx := context.Background()
+	services := NewService(repository.NewMemoryRepository())
+
+	ownerID, userID := 1, 2
+	assert.Equal(t, SelfShareError, services.ShareCalendar(ctx, ownerID, ownerID, model.PermissionRead))
+	assert.NoError(t, services.ShareCalendar(ctx, ownerID, userID, model.PermissionRead))
+
+	permission, err := services.CalendarPermission(ctx, ownerID, ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PermissionWrite, permission)
+
+	permission, err = services.CalendarPermission(ctx, ownerID, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PermissionRead, permission)
+
+	_, err = services.CalendarPermission(ctx, userID, ownerID)
+	assert.Equal(t, repository.ShareNotFoundError, err)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"time"
+	"wbtech_l2/18/internal/repository"
+)
+
+type TrashService struct {
+	repo repository.Trash
+	now  func() time.Time
+}
+
+func NewTrashService(repo repository.Trash) *TrashService {
+	return &TrashService{repo: repo, now: time.Now}
+}
+
+// Purge permanently removes at most limit events that have been in the trash
+// for longer than retention and returns their number.
+func (s *TrashService) Purge(ctx context.Context, retention time.Duration, limit int) (int, error) {
+	return s.repo.PurgeDeleted(ctx, s.now().Add(-retention), limit)
+}
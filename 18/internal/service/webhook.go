@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+)
+
+// webhookSecretPrefix makes webhook secrets easy to tell from API keys.
+const webhookSecretPrefix = "whsec_"
+
+type WebhookService struct {
+	webhooks repository.Webhook
+	outbox   repository.Outbox
+	now      func() time.Time
+}
+
+func NewWebhookService(webhooks repository.Webhook, outbox repository.Outbox) *WebhookService {
+	return &WebhookService{webhooks: webhooks, outbox: outbox, now: time.Now}
+}
+
+// CreateWebhook registers url for the changes of user's events. The
+// returned webhook carries the secret that signs its payloads; it is not
+// returned again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID int, url string) (model.Webhook, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return model.Webhook{}, err
+	}
+
+	return s.webhooks.CreateWebhook(ctx, userID, url, webhookSecretPrefix+hex.EncodeToString(secret))
+}
+
+// DeleteWebhook removes the webhook together with its pending deliveries.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID int) error {
+	return s.webhooks.DeleteWebhook(ctx, userID, webhookID)
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID int) ([]model.Webhook, error) {
+	return s.webhooks.GetWebhooks(ctx, userID)
+}
+
+func (s *WebhookService) ClaimDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	return s.outbox.ClaimDueDeliveries(s.now(), limit, lease)
+}
+
+func (s *WebhookService) CompleteDelivery(delivery model.WebhookDelivery) error {
+	return s.outbox.MarkDelivered(delivery.ID)
+}
+
+func (s *WebhookService) RetryDelivery(delivery model.WebhookDelivery, retryAt time.Time, cause error) error {
+	return s.outbox.ReleaseDelivery(delivery.ID, retryAt, cause.Error())
+}
+
+// FailDelivery gives up on the delivery, which keeps the last error.
+func (s *WebhookService) FailDelivery(delivery model.WebhookDelivery, cause error) error {
+	return s.outbox.MarkFailed(delivery.ID, cause.Error())
+}
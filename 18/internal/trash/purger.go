@@ -0,0 +1,97 @@
+package trash
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	// Retention is how long deleted events stay restorable.
+	Retention time.Duration
+	Interval  time.Duration
+	// BatchSize bounds the events removed by one query, so that a large
+	// backlog doesn't hold locks on the table for long.
+	BatchSize int
+}
+
+// Purger periodically removes the events that have been in the trash for
+// longer than the retention.
+type Purger struct {
+	service service.Trash
+	cfg     Config
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewPurger(service service.Trash, cfg Config) *Purger {
+	if cfg.Retention <= 0 {
+		cfg.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+
+	return &Purger{service: service, cfg: cfg}
+}
+
+func (p *Purger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go p.run(ctx)
+}
+
+// Stop stops purging after the batch in progress.
+func (p *Purger) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Purger) run(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge removes expired events batch by batch until a batch comes back
+// short.
+func (p *Purger) purge(ctx context.Context) {
+	total := 0
+
+	for ctx.Err() == nil {
+		purged, err := p.service.Purge(ctx, p.cfg.Retention, p.cfg.BatchSize)
+		if err != nil {
+			logrus.Errorf("Error purging trash: %s", err.Error())
+			break
+		}
+
+		total += purged
+		if purged < p.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logrus.Printf("Purged %d events from the trash.", total)
+	}
+}
@@ -0,0 +1,47 @@
+package trash
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurger(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepository()
+	services := service.NewService(repos)
+
+	userID := 1
+	ids := make([]int, 0)
+	for i := 0; i < 5; i++ {
+		id, err := services.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00"})
+		assert.NoError(t, err)
+		assert.NoError(t, services.Delete(ctx, userID, id, 0))
+		ids = append(ids, id)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	keptID, _ := services.Create(ctx, userID, model.Event{Description: "test_data", Date: "2026-02-06", Time: "14:00"})
+	assert.NoError(t, services.Delete(ctx, userID, keptID, 0))
+
+	// Events deleted within the retention survive; the expired ones are
+	// purged in several batches
+	purger := NewPurger(services, Config{Retention: 50 * time.Millisecond, Interval: time.Hour, BatchSize: 2})
+	purger.purge(context.Background())
+
+	trash, err := services.GetDeleted(ctx, userID, time.UTC)
+	assert.NoError(t, err)
+	if assert.Len(t, trash, 1) {
+		assert.Equal(t, keptID, trash[0].ID)
+	}
+
+	for _, id := range ids {
+		assert.Equal(t, repository.NotFoundError, services.Restore(ctx, userID, id))
+	}
+	assert.NoError(t, services.Restore(ctx, userID, keptID))
+}
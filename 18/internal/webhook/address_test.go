@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckURL(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		url         string
+		expectedErr error
+	}{
+		{name: "public", url: "https://93.184.215.14/hook"},
+		{name: "public IPv6", url: "https://[2606:2800:21f:cb07:6820:80da:af6b:8b2c]/hook"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", expectedErr: ForbiddenAddressError},
+		{name: "IPv4-mapped loopback", url: "http://[::ffff:127.0.0.1]/hook", expectedErr: ForbiddenAddressError},
+		{name: "private", url: "http://10.0.0.5/hook", expectedErr: ForbiddenAddressError},
+		{name: "link-local", url: "http://169.254.169.254/latest/meta-data", expectedErr: ForbiddenAddressError},
+		{name: "unspecified", url: "http://0.0.0.0/hook", expectedErr: ForbiddenAddressError},
+		{name: "shared address space", url: "http://100.64.0.1/hook", expectedErr: ForbiddenAddressError},
+		{name: "IPv6 unique local", url: "http://[fd00::1]/hook", expectedErr: ForbiddenAddressError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedErr, CheckURL(ctx, tc.url))
+		})
+	}
+
+	assert.Error(t, CheckURL(ctx, "ftp://93.184.215.14/hook"))
+	assert.Error(t, CheckURL(ctx, "/hook"))
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.RedirectHandler("http://169.254.169.254/", http.StatusFound))
+	defer srv.Close()
+
+	// Addresses are checked when connecting, whatever the host resolved to
+	// when the webhook was created
+	_, err := NewClient(time.Second).Post(srv.URL, "application/json", nil)
+	assert.True(t, errors.Is(err, ForbiddenAddressError))
+
+	client := NewClient(time.Second)
+	client.Transport = srv.Client().Transport
+	resp, err := client.Post(srv.URL, "application/json", nil)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+	}
+}
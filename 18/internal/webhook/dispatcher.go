@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxDrainedBytes bounds what is read of responses, which are ignored.
+const maxDrainedBytes = 64 << 10
+
+type Config struct {
+	Workers      int
+	BatchSize    int
+	PollInterval time.Duration
+	// Lease is how long a claimed delivery stays invisible to other pollers.
+	// It must be longer than SendTimeout.
+	Lease       time.Duration
+	SendTimeout time.Duration
+	RetryDelay  time.Duration
+	MaxRetry    time.Duration
+	// MaxAttempts is the number of attempts after which a delivery is given
+	// up; zero retries forever.
+	MaxAttempts int
+}
+
+// Dispatcher polls the outbox and posts the payloads to the webhooks with a
+// pool of workers. Delivery is at-least-once: a payload is marked as
+// delivered only after the webhook responded with 2xx, and failed attempts
+// are retried with exponential backoff.
+type Dispatcher struct {
+	service service.Outbox
+	client  *http.Client
+	cfg     Config
+
+	queue  chan model.WebhookDelivery
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher posts with the client of NewClient unless client is given.
+func NewDispatcher(service service.Outbox, client *http.Client, cfg Config) *Dispatcher {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = cfg.Workers * 10
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 5 * time.Second
+	}
+	if cfg.SendTimeout <= 0 {
+		cfg.SendTimeout = 10 * time.Second
+	}
+	if cfg.Lease <= cfg.SendTimeout {
+		cfg.Lease = 6 * cfg.SendTimeout
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 10 * time.Second
+	}
+	if cfg.MaxRetry <= 0 {
+		cfg.MaxRetry = time.Hour
+	}
+	if client == nil {
+		client = NewClient(cfg.SendTimeout)
+	}
+
+	return &Dispatcher{
+		service: service,
+		client:  client,
+		cfg:     cfg,
+		queue:   make(chan model.WebhookDelivery, cfg.BatchSize),
+	}
+}
+
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	d.wg.Add(1)
+	go d.poll(ctx)
+
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Stop stops polling and waits for the workers to post the payloads that
+// were already claimed.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) poll(ctx context.Context) {
+	defer d.wg.Done()
+	defer close(d.queue)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliveries, err := d.service.ClaimDeliveries(d.cfg.BatchSize, d.cfg.Lease)
+		if err != nil {
+			logrus.Errorf("Error claiming webhook deliveries: %s", err.Error())
+		}
+
+		for _, delivery := range deliveries {
+			d.queue <- delivery
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for delivery := range d.queue {
+		d.deliver(delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(delivery model.WebhookDelivery) {
+	err := d.post(delivery)
+	if err == nil {
+		if err = d.service.CompleteDelivery(delivery); err != nil {
+			logrus.Errorf("Error completing webhook delivery %d: %s", delivery.ID, err.Error())
+		}
+		return
+	}
+
+	logrus.Errorf("Error posting webhook delivery %d (attempt %d): %s", delivery.ID, delivery.Attempts, err.Error())
+
+	if d.cfg.MaxAttempts > 0 && delivery.Attempts >= d.cfg.MaxAttempts {
+		if err = d.service.FailDelivery(delivery, err); err != nil {
+			logrus.Errorf("Error failing webhook delivery %d: %s", delivery.ID, err.Error())
+		}
+		return
+	}
+
+	if err = d.service.RetryDelivery(delivery, time.Now().Add(d.backoff(delivery.Attempts)), err); err != nil {
+		logrus.Errorf("Error releasing webhook delivery %d: %s", delivery.ID, err.Error())
+	}
+}
+
+// post sends the signed payload. Any status other than 2xx is treated as a
+// failed delivery.
+func (d *Dispatcher) post(delivery model.WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.SendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	// Reading the rest of the body lets the connection be reused
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBytes))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff doubles the retry delay with every failed attempt up to MaxRetry.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryDelay
+	for i := 1; i < attempts && delay < d.cfg.MaxRetry; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.cfg.MaxRetry)
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"wbtech_l2/18/internal/model"
+	"wbtech_l2/18/internal/repository"
+	"wbtech_l2/18/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// receiver is a webhook endpoint that fails the first failures requests and
+// records the payloads with valid signatures.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	failures int
+	requests int
+	payloads []model.WebhookPayload
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.requests++
+
+	body, _ := io.ReadAll(r.Body)
+	if !Verify(rc.secret, body, r.Header.Get(SignatureHeader)) || r.Header.Get(DeliveryHeader) == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var payload model.WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	rc.payloads = append(rc.payloads, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rc *receiver) counts() (int, int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.requests, len(rc.payloads)
+}
+
+func newReceiver(t *testing.T, services *service.Service, userID, failures int) (*receiver, *httptest.Server) {
+	t.Helper()
+
+	rc := &receiver{failures: failures}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	webhook, err := services.CreateWebhook(context.Background(), userID, srv.URL)
+	assert.NoError(t, err)
+	rc.secret = webhook.Secret
+
+	return rc, srv
+}
+
+func TestDispatcherDeliversSignedPayloads(t *testing.T) {
+	ctx := context.Background()
+	services := service.NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	rc, srv := newReceiver(t, services, userID, 2)
+
+	eventID, err := services.Create(ctx, userID, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+	assert.NoError(t, err)
+	assert.NoError(t, services.Update(ctx, userID, eventID, model.Event{Description: "dentist appointment"}))
+	assert.NoError(t, services.Delete(ctx, userID, eventID, 0))
+
+	// Events of other users don't reach the webhook
+	services.Create(ctx, 12345, model.Event{Description: "stand-up", Date: "2026-02-04", Time: "10:00"})
+
+	dispatcher := NewDispatcher(services.Outbox, srv.Client(), Config{
+		Workers:      2,
+		PollInterval: 5 * time.Millisecond,
+		RetryDelay:   5 * time.Millisecond,
+	})
+	dispatcher.Start()
+
+	assert.Eventually(t, func() bool {
+		_, delivered := rc.counts()
+		return delivered == 3
+	}, 2*time.Second, 5*time.Millisecond)
+	dispatcher.Stop()
+
+	types := make([]string, 0, len(rc.payloads))
+	for _, payload := range rc.payloads {
+		types = append(types, payload.Type)
+		assert.Equal(t, userID, payload.UserID)
+		assert.Equal(t, eventID, payload.Event.ID)
+	}
+	assert.ElementsMatch(t, []string{model.WebhookEventCreated, model.WebhookEventUpdated, model.WebhookEventDeleted}, types)
+
+	// Delivered payloads are not posted again
+	deliveries, err := services.ClaimDeliveries(10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	ctx := context.Background()
+	services := service.NewService(repository.NewMemoryRepository())
+
+	userID := 1
+	rc, srv := newReceiver(t, services, userID, 1000)
+	services.Create(ctx, userID, model.Event{Description: "dentist", Date: "2026-02-04", Time: "09:00"})
+
+	dispatcher := NewDispatcher(services.Outbox, srv.Client(), Config{
+		PollInterval: 5 * time.Millisecond,
+		RetryDelay:   time.Millisecond,
+		MaxAttempts:  3,
+	})
+	dispatcher.Start()
+
+	assert.Eventually(t, func() bool {
+		requests, _ := rc.counts()
+		return requests == 3
+	}, 2*time.Second, 5*time.Millisecond)
+
+	// Failed deliveries stay in the outbox but are not due anymore
+	time.Sleep(50 * time.Millisecond)
+	dispatcher.Stop()
+
+	requests, _ := rc.counts()
+	assert.Equal(t, 3, requests)
+}
+
+func TestDispatcherStop(t *testing.T) {
+	services := service.NewService(repository.NewMemoryRepository())
+	dispatcher := NewDispatcher(services.Outbox, nil, Config{PollInterval: time.Hour})
+	dispatcher.Start()
+
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(nil, nil, Config{RetryDelay: time.Second, MaxRetry: 5 * time.Second})
+
+	assert.Equal(t, time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 4*time.Second, dispatcher.backoff(3))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(4))
+	assert.Equal(t, 5*time.Second, dispatcher.backoff(100))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"event.created"}`)
+	signature := Sign("secret", body)
+
+	assert.True(t, Verify("secret", body, signature))
+	assert.False(t, Verify("other", body, signature))
+	assert.False(t, Verify("secret", []byte(`{"type":"event.deleted"}`), signature))
+	assert.False(t, Verify("secret", body, signature[len("sha256="):]))
+}
@@ -0,0 +1,3 @@
+ALTER TABLE event
+    DROP COLUMN rrule,
+    DROP COLUMN exdates;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE event
+    ADD COLUMN IF NOT EXISTS rrule TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS exdates DATE[] NOT NULL DEFAULT '{}';
\ No newline at end of file
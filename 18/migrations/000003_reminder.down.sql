@@ -0,0 +1,3 @@
+DROP TABLE reminder;
+
+ALTER TABLE event DROP COLUMN remind_before;
\ No newline at end of file
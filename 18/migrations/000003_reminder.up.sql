@@ -0,0 +1,16 @@
+ALTER TABLE event ADD COLUMN IF NOT EXISTS remind_before BIGINT;
+
+CREATE TABLE IF NOT EXISTS reminder (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES event (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    event_at TIMESTAMPTZ NOT NULL,
+    remind_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reminder_pending_event_idx ON reminder (event_id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS reminder_due_idx ON reminder (remind_at) WHERE sent_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS event_user_starts_at_idx;
+
+ALTER TABLE event ADD COLUMN IF NOT EXISTS date DATE, ADD COLUMN IF NOT EXISTS time TIME;
+
+UPDATE event SET date = (starts_at AT TIME ZONE timezone)::date, time = (starts_at AT TIME ZONE timezone)::time;
+
+ALTER TABLE event ALTER COLUMN date SET NOT NULL, ALTER COLUMN time SET NOT NULL, DROP COLUMN starts_at, DROP COLUMN timezone;
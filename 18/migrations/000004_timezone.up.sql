@@ -0,0 +1,7 @@
+ALTER TABLE event ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ, ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+UPDATE event SET starts_at = (date + time) AT TIME ZONE 'UTC' WHERE starts_at IS NULL;
+
+ALTER TABLE event ALTER COLUMN starts_at SET NOT NULL, DROP COLUMN IF EXISTS date, DROP COLUMN IF EXISTS time;
+
+CREATE INDEX IF NOT EXISTS event_user_starts_at_idx ON event (user_id, starts_at);
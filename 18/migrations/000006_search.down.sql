@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS event_search_vector_idx;
+
+ALTER TABLE event DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,3 @@
+ALTER TABLE event ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', description)) STORED;
+
+CREATE INDEX IF NOT EXISTS event_search_vector_idx ON event USING GIN (search_vector);
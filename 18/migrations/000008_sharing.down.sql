@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS calendar_share;
+
+DROP TABLE IF EXISTS attendee;
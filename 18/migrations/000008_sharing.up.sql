@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS attendee (
+    event_id INTEGER NOT NULL REFERENCES event (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'needs-action' CHECK (status IN ('needs-action', 'accepted', 'declined', 'tentative')),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS attendee_user_idx ON attendee (user_id);
+
+CREATE TABLE IF NOT EXISTS calendar_share (
+    owner_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    permission TEXT NOT NULL CHECK (permission IN ('read', 'write')),
+    PRIMARY KEY (owner_id, user_id)
+);
@@ -0,0 +1,5 @@
+DELETE FROM event WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS event_deleted_at_idx;
+
+ALTER TABLE event DROP COLUMN IF EXISTS deleted_at;
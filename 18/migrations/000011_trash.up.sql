@@ -0,0 +1,3 @@
+ALTER TABLE event ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS event_deleted_at_idx ON event (deleted_at) WHERE deleted_at IS NOT NULL;
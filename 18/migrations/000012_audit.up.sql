@@ -0,0 +1,15 @@
+-- Records outlive the events they describe, so event_id has no foreign key
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL,
+    owner_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge')),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_event_idx ON audit_log (owner_id, event_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_owner_idx ON audit_log (owner_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, id);
@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations of the calendar database, so
+// that the binary can apply them without the migrations directory.
+package migrations
+
+import "embed"
+
+//go:embed *.up.sql *.down.sql
+var FS embed.FS